@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"net/http"
+	"os"
 
 	"github.com/gin-gonic/gin"
 	"github.com/imranzahoor/banking-ledger/internal/api"
@@ -12,62 +14,233 @@ import (
 	"github.com/imranzahoor/banking-ledger/internal/service"
 	"github.com/imranzahoor/banking-ledger/pkg/config"
 	queue "github.com/imranzahoor/banking-ledger/pkg/kafka"
+	"github.com/imranzahoor/banking-ledger/pkg/logger"
+	"github.com/imranzahoor/banking-ledger/pkg/tracing"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"gorm.io/gorm"
 )
 
 func main() {
 	cfg := config.Load()
+	slog.SetDefault(logger.New(os.Stdout, cfg.LogLevel))
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	shutdownTracing := initTracing(ctx, cfg)
+	defer shutdownTracing(context.Background())
 
-	accountRepo := initPostgresAccountRepo(cfg)
-	transactionRepo := initMongoLedgerRepo(cfg)
+	db := initPostgres(cfg)
+	accountRepo := postgres.NewAccountRepo(db)
+	idempotencyRepo := postgres.NewIdempotencyRepo(db)
+	outboxRepo := postgres.NewOutboxRepo(db)
+	txnStatusRepo := postgres.NewTransactionRepo(db)
+	scheduleRepo := postgres.NewScheduleRepo(db)
+	apiKeyRepo := postgres.NewAPIKeyRepo(db)
+	mongoClient := initMongo(cfg)
+	transactionRepo := initMongoLedgerRepo(mongoClient, cfg)
+	reconciliationRepo := mongo.NewReconciliationRepo(mongoClient, cfg.MongoDB)
 
-	startTransactionConsumer(ctx, cfg, accountRepo, transactionRepo)
+	startOutboxRelay(ctx, cfg, outboxRepo)
+	consumer := startTransactionConsumer(ctx, cfg, accountRepo, transactionRepo, txnStatusRepo)
+	startHoldExpirer(ctx, cfg, accountRepo)
 
-	accountService := service.NewAccountService(accountRepo)
-	transactionService := service.NewTransactionService(accountRepo, transactionRepo, cfg)
+	accountService := service.NewAccountService(accountRepo, transactionRepo, cfg)
+	transactionService := service.NewTransactionService(accountRepo, transactionRepo, idempotencyRepo, txnStatusRepo, cfg)
+	scheduleService := service.NewScheduleService(scheduleRepo, transactionService)
+	reconciliationService := service.NewReconciliationService(accountRepo, transactionRepo, reconciliationRepo, cfg)
+	authService := initAuthService(apiKeyRepo, cfg)
 
-	runHTTPServer(cfg, accountService, transactionService)
+	startScheduler(ctx, cfg, scheduleService)
+	startReconciler(ctx, cfg, reconciliationService)
+
+	startMetricsServer(cfg)
+	health := newHealthHandler(cfg, db, mongoClient, consumer)
+	runHTTPServer(cfg, health, accountService, transactionService, scheduleService, reconciliationService, authService)
+}
+
+func initTracing(ctx context.Context, cfg config.Config) func(context.Context) error {
+	shutdown, err := tracing.Setup(ctx, cfg.TracingExporter, cfg.ServiceName)
+	if err != nil {
+		fatal("failed to configure tracing", err)
+	}
+	return shutdown
 }
 
-func initPostgresAccountRepo(cfg config.Config) *postgres.AccountRepo {
+func initPostgres(cfg config.Config) *gorm.DB {
 	db, err := postgres.NewPostgresDB(cfg)
 	if err != nil {
-		log.Fatalf("failed to connect to Postgres: %v", err)
+		fatal("failed to connect to Postgres", err)
 	}
-	return postgres.NewAccountRepo(db)
+	return db
 }
 
-func initMongoLedgerRepo(cfg config.Config) *mongo.LedgerRepo {
+func initMongo(cfg config.Config) *mongodriver.Client {
 	client, err := mongo.NewMongoClient(cfg)
 	if err != nil {
-		log.Fatalf("failed to connect to MongoDB: %v", err)
+		fatal("failed to connect to MongoDB", err)
+	}
+	return client
+}
+
+func initMongoLedgerRepo(client *mongodriver.Client, cfg config.Config) *mongo.LedgerRepo {
+	repo := mongo.NewLedgerRepo(client, cfg.MongoDB)
+	if err := repo.EnsureIndexes(context.Background()); err != nil {
+		fatal("failed to create MongoDB indexes", err)
+	}
+	return repo
+}
+
+func initAuthService(kr *postgres.APIKeyRepo, cfg config.Config) *service.AuthService {
+	auth, err := service.NewAuthService(kr, cfg)
+	if err != nil {
+		fatal("failed to configure authentication", err)
 	}
-	return mongo.NewLedgerRepo(client, cfg.MongoDB)
+	return auth
 }
 
-func startTransactionConsumer(ctx context.Context, cfg config.Config, ar *postgres.AccountRepo, lr *mongo.LedgerRepo) {
+func startOutboxRelay(ctx context.Context, cfg config.Config, or *postgres.OutboxRepo) {
 	kafkaCfg := config.KafkaConfig{
 		Brokers: cfg.KafkaBrokers,
-		Topic:   cfg.KafkaTopic,
-		GroupID: cfg.KafkaGroupID,
 	}
-	consumer := queue.NewTransactionConsumer(kafkaCfg, ar, lr)
+	relay := queue.NewOutboxRelay(kafkaCfg, or, cfg.OutboxPollInterval, cfg.OutboxBatchSize)
 	go func() {
-		if err := consumer.Run(ctx); err != nil {
-			log.Fatalf("Kafka consumer failed: %v", err)
+		if err := relay.Run(ctx); err != nil {
+			fatal("outbox relay failed", err)
 		}
 	}()
 }
 
-func runHTTPServer(cfg config.Config, accountService *service.AccountService, transactionService *service.TransactionService) {
-	router := gin.Default()
-	router.Use(middleware.Recovery())
+func startTransactionConsumer(
+	ctx context.Context,
+	cfg config.Config,
+	ar *postgres.AccountRepo,
+	lr *mongo.LedgerRepo,
+	tr *postgres.TransactionRepo,
+) *queue.TransactionConsumer {
+	kafkaCfg := config.KafkaConfig{
+		Brokers:         cfg.KafkaBrokers,
+		Topic:           cfg.KafkaTopic,
+		GroupID:         cfg.KafkaGroupID,
+		DLQTopic:        cfg.KafkaDLQTopic,
+		MaxRetries:      cfg.ConsumerMaxRetries,
+		RetryBackoff:    cfg.ConsumerRetryBackoff,
+		MaxRetryBackoff: cfg.ConsumerMaxRetryBackoff,
+	}
+	consumer := queue.NewTransactionConsumer(kafkaCfg, ar, lr, tr)
+	go func() {
+		// the API keeps serving; /readyz reports the stopped consumer so the
+		// orchestrator can restart the instance
+		if err := consumer.Run(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Kafka consumer stopped", "error", err)
+		}
+	}()
+	return consumer
+}
 
-	handler := api.NewHandler(accountService, transactionService)
-	handler.RegisterRoutes(router)
+func startHoldExpirer(ctx context.Context, cfg config.Config, ar *postgres.AccountRepo) {
+	expirer := service.NewHoldExpirer(ar, cfg.HoldExpiryInterval)
+	go func() {
+		if err := expirer.Run(ctx); err != nil {
+			fatal("hold expirer failed", err)
+		}
+	}()
+}
+
+func startScheduler(ctx context.Context, cfg config.Config, ss *service.ScheduleService) {
+	scheduler := service.NewScheduler(ss, cfg.SchedulerInterval)
+	go func() {
+		if err := scheduler.Run(ctx); err != nil {
+			fatal("scheduler failed", err)
+		}
+	}()
+}
+
+func startReconciler(ctx context.Context, cfg config.Config, rs *service.ReconciliationService) {
+	if cfg.ReconciliationInterval <= 0 {
+		return
+	}
+	reconciler := service.NewReconciler(rs, cfg.ReconciliationInterval, cfg.ReconciliationAutoRepair)
+	go func() {
+		if err := reconciler.Run(ctx); err != nil {
+			fatal("reconciler failed", err)
+		}
+	}()
+}
+
+// startMetricsServer serves /metrics on its own port, so that it can be kept
+// off the public network rather than sit unauthenticated next to the API.
+func startMetricsServer(cfg config.Config) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		if err := http.ListenAndServe(":"+cfg.MetricsPort, mux); err != nil {
+			fatal("failed to run metrics server", err)
+		}
+	}()
+}
+
+// newHealthHandler checks the dependencies every request needs: the
+// databases, a Kafka broker for the outbox relay, and the consumer.
+func newHealthHandler(cfg config.Config, db *gorm.DB, mc *mongodriver.Client, consumer *queue.TransactionConsumer) *api.HealthHandler {
+	return api.NewHealthHandler(cfg.ReadinessTimeout, consumer.State,
+		api.HealthCheck{Name: "postgres", Ping: func(ctx context.Context) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		}},
+		api.HealthCheck{Name: "mongo", Ping: func(ctx context.Context) error {
+			return mc.Ping(ctx, readpref.Primary())
+		}},
+		api.HealthCheck{Name: "kafka", Ping: func(ctx context.Context) error {
+			return queue.Ping(ctx, cfg.KafkaBrokers)
+		}},
+	)
+}
+
+// probePaths are polled by orchestrators and are not traced.
+var probePaths = map[string]bool{"/healthz": true, "/readyz": true}
+
+func runHTTPServer(
+	cfg config.Config,
+	health *api.HealthHandler,
+	accountService *service.AccountService,
+	transactionService *service.TransactionService,
+	scheduleService *service.ScheduleService,
+	reconciliationService *service.ReconciliationService,
+	authService *service.AuthService,
+) {
+	router := gin.New()
+	router.Use(
+		otelgin.Middleware(cfg.ServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+			return !probePaths[r.URL.Path]
+		})),
+		middleware.RequestID(),
+		middleware.Logger("/healthz", "/readyz"),
+		middleware.Metrics(),
+		middleware.Recovery(),
+	)
+	health.RegisterRoutes(router)
+
+	authenticate := middleware.Authenticate(authService)
+	if !cfg.AuthEnabled {
+		slog.Warn("authentication is disabled; every caller is treated as an administrator")
+		authenticate = middleware.Anonymous()
+	}
+
+	handler := api.NewHandler(accountService, transactionService, scheduleService, reconciliationService, authService, cfg)
+	handler.RegisterRoutes(router, authenticate)
 
 	if err := router.Run(":" + cfg.Port); err != nil {
-		log.Fatalf("failed to run server: %v", err)
+		fatal("failed to run server", err)
 	}
 }
+
+// fatal logs err and exits; slog has no counterpart to log.Fatalf.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -1,11 +1,23 @@
 package api
 
 import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/middleware"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/policy"
 	"github.com/imranzahoor/banking-ledger/internal/service"
+	"github.com/imranzahoor/banking-ledger/pkg/currency"
 	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/pkg/statement"
+	"github.com/imranzahoor/banking-ledger/pkg/utils"
 )
 
 type AccountHandler struct {
@@ -16,15 +28,28 @@ func NewAccountHandler(s *service.AccountService) *AccountHandler {
 	return &AccountHandler{accountService: s}
 }
 
+// RegisterRoutes mounts the account routes. Reads are checked per account in
+// the handlers, since customers may only see their own.
 func (h *AccountHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	manage := middleware.Require(policy.ManageAccounts)
+
 	accounts := rg.Group("/accounts")
-	accounts.POST("", h.CreateAccount)
+	accounts.POST("", manage, h.CreateAccount)
 	accounts.GET("/:id", h.GetAccount)
+	accounts.GET("/:id/balance", h.GetBalance)
+	accounts.GET("/:id/statement", h.GetStatement)
+	accounts.POST("/:id/freeze", manage, h.FreezeAccount)
+	accounts.POST("/:id/unfreeze", manage, h.UnfreezeAccount)
+	accounts.POST("/:id/close", manage, h.CloseAccount)
+	accounts.PUT("/:id/overdraft-limit", manage, h.SetOverdraftLimit)
+	accounts.PUT("/:id/owner", manage, h.SetOwner)
 }
 
 type createAccountRequest struct {
 	OwnerName      string `json:"owner_name" binding:"required"`
+	OwnerID        string `json:"owner_id"` // principal of the customer who may use the account, e.g. "jwt:user-42"
 	InitialBalance int64  `json:"initial_balance" binding:"gte=0"`
+	Currency       string `json:"currency" binding:"omitempty,len=3"` // ISO 4217, defaults to USD
 }
 
 func (h *AccountHandler) CreateAccount(c *gin.Context) {
@@ -34,21 +59,315 @@ func (h *AccountHandler) CreateAccount(c *gin.Context) {
 		return
 	}
 
-	account, err := h.accountService.CreateAccount(c.Request.Context(), req.OwnerName, req.InitialBalance)
+	account, err := h.accountService.CreateAccount(c.Request.Context(), req.OwnerName, req.OwnerID, req.InitialBalance, req.Currency)
+	if stderrors.Is(err, errors.ErrUnsupportedCurrency) || stderrors.Is(err, errors.ErrInvalidOwnerID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, account)
+	c.JSON(http.StatusCreated, newAccountResponse(account))
 }
 
 func (h *AccountHandler) GetAccount(c *gin.Context) {
-	id := c.Param("id")
-	account, err := h.accountService.GetAccountByID(c.Request.Context(), id)
+	id, err := utils.ParseUUID(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusNotFound, errors.ErrAccountNotFound)
 		return
 	}
-	c.JSON(http.StatusOK, account)
+
+	account, err := h.accountService.GetAccountByID(c.Request.Context(), id.String())
+	if err == nil && account != nil {
+		err = policy.AllowAccount(middleware.IdentityFrom(c), policy.ReadAccounts, account)
+	}
+	if stderrors.Is(err, errors.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil || account == nil {
+		c.JSON(http.StatusNotFound, errors.ErrAccountNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, newAccountResponse(account))
+}
+
+// GetBalance answers the account's ledger balance as of the RFC 3339 timestamp
+// in ?as_of=, or now when it is omitted.
+func (h *AccountHandler) GetBalance(c *gin.Context) {
+	id, err := utils.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.ErrInvalidInput)
+		return
+	}
+	if !authorizeAccount(c, h.accountService, policy.ReadAccounts, id) {
+		return
+	}
+
+	asOf := time.Now().UTC()
+	if raw := c.Query("as_of"); raw != "" {
+		asOf, err = time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errors.ErrInvalidAsOf.Error()})
+			return
+		}
+	}
+
+	snap, err := h.accountService.GetBalanceAsOf(c.Request.Context(), id, asOf)
+	if stderrors.Is(err, errors.ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"account_id":      snap.AccountID,
+		"as_of":           snap.AsOf,
+		"balance":         snap.Balance,
+		"currency":        snap.Currency,
+		"balance_decimal": currency.Format(snap.Balance, snap.Currency),
+	})
+}
+
+// statementPeriod is the default length of a statement when ?from= is omitted.
+const statementPeriod = 30 * 24 * time.Hour
+
+// GetStatement downloads the account's statement for ?from= to ?to= in the
+// ?format= given (csv, ofx or camt053; csv by default). Bounds are RFC 3339
+// timestamps or dates; a date in ?to= includes that whole day. The statement
+// is streamed, so an error after the first entry can only cut it short.
+func (h *AccountHandler) GetStatement(c *gin.Context) {
+	id, err := utils.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.ErrInvalidInput)
+		return
+	}
+	if !authorizeAccount(c, h.accountService, policy.ReadAccounts, id) {
+		return
+	}
+
+	format, err := statement.ParseFormat(c.DefaultQuery("format", string(statement.FormatCSV)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	to := time.Now().UTC()
+	if raw := c.Query("to"); raw != "" {
+		if to, err = parseRangeBound(raw, true); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	from := to.Add(-statementPeriod)
+	if raw := c.Query("from"); raw != "" {
+		if from, err = parseRangeBound(raw, false); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	out := &statementResponse{
+		c:           c,
+		contentType: format.ContentType(),
+		filename: fmt.Sprintf("statement-%s-%s-%s.%s",
+			id, from.Format("20060102"), to.Format("20060102"), format.Extension()),
+	}
+	err = h.accountService.WriteStatement(c.Request.Context(), id, from, to, statement.NewWriter(format, out))
+	if err == nil {
+		return
+	}
+	if out.started {
+		slog.ErrorContext(c.Request.Context(), "statement interrupted", "account_id", id, "error", err)
+		return
+	}
+
+	switch {
+	case stderrors.Is(err, errors.ErrAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case stderrors.Is(err, errors.ErrInvalidStatementPeriod):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// parseRangeBound parses an RFC 3339 timestamp or a YYYY-MM-DD date bounding a
+// date range. A date that ends the range stands for the end of that day.
+func parseRangeBound(raw string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		return t.UTC(), nil
+	}
+	day, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, errors.ErrInvalidDateRange
+	}
+	if end {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
+// statementResponse sends the download headers with the first bytes of the
+// statement, so errors found before anything is written still get a JSON reply.
+type statementResponse struct {
+	c           *gin.Context
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (r *statementResponse) Write(p []byte) (int, error) {
+	if !r.started {
+		r.started = true
+		r.c.Header("Content-Type", r.contentType)
+		r.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", r.filename))
+		r.c.Status(http.StatusOK)
+	}
+	return r.c.Writer.Write(p)
+}
+
+func (h *AccountHandler) FreezeAccount(c *gin.Context) {
+	h.changeStatus(c, h.accountService.FreezeAccount)
+}
+
+func (h *AccountHandler) UnfreezeAccount(c *gin.Context) {
+	h.changeStatus(c, h.accountService.UnfreezeAccount)
+}
+
+func (h *AccountHandler) changeStatus(c *gin.Context, change func(context.Context, uuid.UUID) (*model.Account, error)) {
+	id, err := utils.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.ErrInvalidInput)
+		return
+	}
+
+	account, err := change(c.Request.Context(), id)
+	if err != nil {
+		respondStatusError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newAccountResponse(account))
+}
+
+type closeAccountRequest struct {
+	PayoutAccountID string `json:"payout_account_id"` // required when the balance is not zero
+}
+
+func (h *AccountHandler) CloseAccount(c *gin.Context) {
+	id, err := utils.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.ErrInvalidInput)
+		return
+	}
+
+	var req closeAccountRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var payoutID uuid.UUID
+	if req.PayoutAccountID != "" {
+		payoutID, err = utils.ParseUUID(req.PayoutAccountID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errors.ErrInvalidInput)
+			return
+		}
+	}
+
+	account, payout, err := h.accountService.CloseAccount(c.Request.Context(), id, payoutID)
+	if err != nil {
+		respondStatusError(c, err)
+		return
+	}
+
+	resp := gin.H{"account": newAccountResponse(account)}
+	if payout != nil {
+		resp["payout"] = newTransactionResponse(payout)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+type overdraftLimitRequest struct {
+	Limit *int64 `json:"limit" binding:"required,gte=0"` // minor units; 0 removes the overdraft
+}
+
+// SetOverdraftLimit is an administrative operation approving how far an
+// account may be overdrawn.
+func (h *AccountHandler) SetOverdraftLimit(c *gin.Context) {
+	id, err := utils.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.ErrInvalidInput)
+		return
+	}
+
+	var req overdraftLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.accountService.SetOverdraftLimit(c.Request.Context(), id, *req.Limit)
+	if stderrors.Is(err, errors.ErrInvalidOverdraftLimit) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		respondStatusError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newAccountResponse(account))
+}
+
+type ownerRequest struct {
+	OwnerID *string `json:"owner_id" binding:"required,max=255"` // principal of the customer who may use the account; "" for none
+}
+
+// SetOwner is an administrative operation handing an account, such as one
+// opened before roles existed, to a customer.
+func (h *AccountHandler) SetOwner(c *gin.Context) {
+	id, err := utils.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.ErrInvalidInput)
+		return
+	}
+
+	var req ownerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.accountService.SetOwner(c.Request.Context(), id, *req.OwnerID)
+	if err != nil {
+		respondStatusError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newAccountResponse(account))
+}
+
+func respondStatusError(c *gin.Context, err error) {
+	switch {
+	case stderrors.Is(err, errors.ErrAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case stderrors.Is(err, errors.ErrInvalidStatusChange),
+		stderrors.Is(err, errors.ErrAccountNotEmpty),
+		stderrors.Is(err, errors.ErrActiveHolds),
+		stderrors.Is(err, errors.ErrAccountClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case stderrors.Is(err, errors.ErrSameAccountTransfer),
+		stderrors.Is(err, errors.ErrCurrencyMismatch),
+		stderrors.Is(err, errors.ErrInvalidOwnerID):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
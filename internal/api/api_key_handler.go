@@ -0,0 +1,115 @@
+package api
+
+import (
+	stderrors "errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/middleware"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/policy"
+	"github.com/imranzahoor/banking-ledger/internal/service"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/pkg/utils"
+)
+
+// APIKeyHandler manages API keys; every route requires policy.ManageAPIKeys.
+type APIKeyHandler struct {
+	authService *service.AuthService
+}
+
+func NewAPIKeyHandler(s *service.AuthService) *APIKeyHandler {
+	return &APIKeyHandler{authService: s}
+}
+
+func (h *APIKeyHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	keys := rg.Group("/api-keys", middleware.Require(policy.ManageAPIKeys))
+	keys.POST("", h.CreateAPIKey)
+	keys.GET("", h.ListAPIKeys)
+	keys.DELETE("/:id", h.RevokeAPIKey)
+}
+
+type createAPIKeyRequest struct {
+	Name string `json:"name" binding:"required"` // who or what the key is for; the subject of its requests
+	Role string `json:"role" binding:"required"` // admin, teller, auditor or customer
+}
+
+type apiKeyResponse struct {
+	ID        uuid.UUID      `json:"id"`
+	Name      string         `json:"name"`
+	Role      constants.Role `json:"role"`
+	Prefix    string         `json:"prefix"`
+	Key       string         `json:"key,omitempty"` // only when the key is created
+	CreatedAt time.Time      `json:"created_at"`
+	RevokedAt *time.Time     `json:"revoked_at,omitempty"`
+}
+
+func newAPIKeyResponse(key *model.APIKey) apiKeyResponse {
+	return apiKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Role:      key.Role,
+		Prefix:    key.Prefix,
+		CreatedAt: key.CreatedAt,
+		RevokedAt: key.RevokedAt,
+	}
+}
+
+// CreateAPIKey issues a key and returns it once; it cannot be retrieved later.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, secret, err := h.authService.CreateAPIKey(c.Request.Context(), req.Name, constants.Role(req.Role))
+	if stderrors.Is(err, errors.ErrInvalidInput) || stderrors.Is(err, errors.ErrInvalidRole) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := newAPIKeyResponse(key)
+	resp.Key = secret
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.authService.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := make([]apiKeyResponse, len(keys))
+	for i := range keys {
+		resp[i] = newAPIKeyResponse(&keys[i])
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := utils.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.ErrParsingID)
+		return
+	}
+
+	key, err := h.authService.RevokeAPIKey(c.Request.Context(), id)
+	if stderrors.Is(err, errors.ErrAPIKeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, newAPIKeyResponse(key))
+}
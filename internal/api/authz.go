@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/middleware"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/policy"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+)
+
+// accountLookup loads the account a per-account policy check is about.
+type accountLookup interface {
+	GetAccountByID(ctx context.Context, accountID string) (*model.Account, error)
+}
+
+// authorizeAccount checks that the caller may perform action on the account,
+// answering 403 and returning false when not. The account is only loaded for
+// callers confined to their own accounts; to them, an unknown account looks
+// the same as someone else's.
+func authorizeAccount(c *gin.Context, accounts accountLookup, action policy.Action, accountID uuid.UUID) bool {
+	return authorizeAnyAccount(c, accounts, action, accountID)
+}
+
+// authorizeAnyAccount is authorizeAccount for something that involves several
+// accounts, such as a transfer, allowing the caller if action is allowed on
+// any one of them.
+func authorizeAnyAccount(c *gin.Context, accounts accountLookup, action policy.Action, accountIDs ...uuid.UUID) bool {
+	identity := middleware.IdentityFrom(c)
+	err := policy.Allow(identity, action)
+	for _, id := range accountIDs {
+		acc, ok := accountForPolicy(c, accounts, identity, id)
+		if !ok {
+			return false
+		}
+		if err = policy.AllowAccount(identity, action, acc); err == nil {
+			break
+		}
+	}
+	return respondPolicy(c, err)
+}
+
+// authorizeTransaction checks that the caller may post a transaction of type
+// typ from the account.
+func authorizeTransaction(c *gin.Context, accounts accountLookup, typ constants.TransactionType, accountID uuid.UUID) bool {
+	identity := middleware.IdentityFrom(c)
+	acc, ok := accountForPolicy(c, accounts, identity, accountID)
+	if !ok {
+		return false
+	}
+	return respondPolicy(c, policy.AllowTransaction(identity, typ, acc))
+}
+
+func accountForPolicy(c *gin.Context, accounts accountLookup, identity *model.Identity, accountID uuid.UUID) (*model.Account, bool) {
+	if !policy.OwnAccountsOnly(identity) {
+		return nil, true
+	}
+	acc, err := accounts.GetAccountByID(c.Request.Context(), accountID.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return acc, true
+}
+
+func respondPolicy(c *gin.Context, err error) bool {
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
@@ -2,24 +2,55 @@ package api
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/imranzahoor/banking-ledger/internal/middleware"
 	"github.com/imranzahoor/banking-ledger/internal/service"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
 )
 
 type Handler struct {
-	AccountHandler     *AccountHandler
-	TransactionHandler *TransactionHandler
+	AccountHandler        *AccountHandler
+	TransactionHandler    *TransactionHandler
+	ScheduleHandler       *ScheduleHandler
+	ReconciliationHandler *ReconciliationHandler
+	APIKeyHandler         *APIKeyHandler
+
+	ipRateLimit config.RateLimit
+	rateLimit   config.RateLimit
 }
 
-func NewHandler(accountSvc *service.AccountService, transactionSvc *service.TransactionService) *Handler {
+func NewHandler(
+	accountSvc *service.AccountService,
+	transactionSvc *service.TransactionService,
+	scheduleSvc *service.ScheduleService,
+	reconciliationSvc *service.ReconciliationService,
+	authSvc *service.AuthService,
+	cfg config.Config,
+) *Handler {
 	return &Handler{
-		AccountHandler:     NewAccountHandler(accountSvc),
-		TransactionHandler: NewTransactionHandler(transactionSvc),
+		AccountHandler:        NewAccountHandler(accountSvc),
+		TransactionHandler:    NewTransactionHandler(transactionSvc, accountSvc, cfg),
+		ScheduleHandler:       NewScheduleHandler(scheduleSvc, accountSvc),
+		ReconciliationHandler: NewReconciliationHandler(reconciliationSvc),
+		APIKeyHandler:         NewAPIKeyHandler(authSvc),
+		ipRateLimit:           cfg.IPRateLimit,
+		rateLimit:             cfg.RateLimit,
 	}
 }
 
-func (h *Handler) RegisterRoutes(r *gin.Engine) {
-	api := r.Group("/api/v1")
+// RegisterRoutes mounts every route under /api/v1 behind a rate limit per
+// client IP, authenticate, which attaches the caller's identity to the
+// request, and a rate limit per caller; the handlers authorize the caller
+// with the policy package.
+func (h *Handler) RegisterRoutes(r *gin.Engine, authenticate gin.HandlerFunc) {
+	api := r.Group("/api/v1",
+		middleware.RateLimit(h.ipRateLimit, middleware.ByIP),
+		authenticate,
+		middleware.RateLimit(h.rateLimit, middleware.ByCaller),
+	)
 
 	h.AccountHandler.RegisterRoutes(api)
 	h.TransactionHandler.RegisterRoutes(api)
+	h.ScheduleHandler.RegisterRoutes(api)
+	h.ReconciliationHandler.RegisterRoutes(api)
+	h.APIKeyHandler.RegisterRoutes(api)
 }
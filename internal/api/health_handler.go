@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+)
+
+// HealthCheck pings one dependency; Ping must give up when ctx is done.
+type HealthCheck struct {
+	Name string
+	Ping func(ctx context.Context) error
+}
+
+// HealthHandler serves the liveness and readiness probes. They sit outside
+// /api/v1 and need no credentials.
+type HealthHandler struct {
+	checks   []HealthCheck
+	consumer func() constants.ConsumerState
+	timeout  time.Duration // per check
+}
+
+func NewHealthHandler(timeout time.Duration, consumer func() constants.ConsumerState, checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks, consumer: consumer, timeout: timeout}
+}
+
+func (h *HealthHandler) RegisterRoutes(r gin.IRoutes) {
+	r.GET("/healthz", h.Live)
+	r.GET("/readyz", h.Ready)
+}
+
+// checkResult leaves out why a check failed: the probes are unauthenticated,
+// so the cause is only logged.
+type checkResult struct {
+	Status    string  `json:"status"` // "up" or "down"
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+type readinessReport struct {
+	Status   string                  `json:"status"` // "ready" or "not_ready"
+	Checks   map[string]checkResult  `json:"checks"`
+	Consumer constants.ConsumerState `json:"consumer"`
+}
+
+// Live answers 200 as long as the process can serve requests.
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Ready pings every dependency concurrently, each within the timeout, and
+// answers 503 unless all are up and the consumer is running.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	report := readinessReport{
+		Status:   "ready",
+		Checks:   make(map[string]checkResult, len(h.checks)),
+		Consumer: h.consumer(),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := h.run(c.Request.Context(), check)
+			mu.Lock()
+			report.Checks[check.Name] = result
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	for _, result := range report.Checks {
+		if result.Status != "up" {
+			report.Status = "not_ready"
+		}
+	}
+	if report.Consumer != constants.ConsumerRunning {
+		report.Status = "not_ready"
+	}
+	if report.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+func (h *HealthHandler) run(ctx context.Context, check HealthCheck) checkResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Ping(ctx)
+	result := checkResult{Status: "up", LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		result.Status = "down"
+		slog.WarnContext(ctx, "readiness check failed", "check", check.Name, "error", err)
+	}
+	return result
+}
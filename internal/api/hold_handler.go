@@ -0,0 +1,128 @@
+package api
+
+import (
+	stderrors "errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/imranzahoor/banking-ledger/internal/policy"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/pkg/utils"
+)
+
+type createHoldRequest struct {
+	AccountID   string `json:"account_id" binding:"required,uuid4"`
+	Amount      int64  `json:"amount" binding:"required,gt=0"`
+	Currency    string `json:"currency" binding:"omitempty,len=3"`
+	Description string `json:"description"`
+	ExpiresIn   string `json:"expires_in"` // Go duration, e.g. "72h"; defaults to HOLD_TTL
+}
+
+type captureHoldRequest struct {
+	Amount int64 `json:"amount" binding:"gte=0"` // zero captures the full hold
+}
+
+// CreateHold reserves funds on an account. Like CreateTransaction it is
+// processed by the consumer and supports wait mode and Idempotency-Key; the
+// transaction ID doubles as the hold ID.
+func (h *TransactionHandler) CreateHold(c *gin.Context) {
+	var req createHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	wait, err := parseWait(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors.ErrInvalidWait.Error()})
+		return
+	}
+
+	accountID, err := utils.ParseUUID(req.AccountID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.ErrInvalidInput)
+		return
+	}
+
+	var expiresIn time.Duration
+	if req.ExpiresIn != "" {
+		expiresIn, err = time.ParseDuration(req.ExpiresIn)
+		if err != nil || expiresIn <= 0 {
+			c.JSON(http.StatusBadRequest, errors.ErrInvalidInput)
+			return
+		}
+	}
+
+	txn := h.transactionService.NewHold(accountID, req.Amount, req.Currency, req.Description, expiresIn)
+	h.submit(c, txn, wait)
+}
+
+func (h *TransactionHandler) GetHold(c *gin.Context) {
+	holdID, err := utils.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.ErrParsingID)
+		return
+	}
+
+	hold, err := h.transactionService.GetHold(c.Request.Context(), holdID)
+	if stderrors.Is(err, errors.ErrHoldNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !authorizeAccount(c, h.accountService, policy.ReadAccounts, hold.AccountID) {
+		return
+	}
+
+	c.JSON(http.StatusOK, hold)
+}
+
+func (h *TransactionHandler) CaptureHold(c *gin.Context) {
+	var req captureHoldRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	h.settleHold(c, constants.Capture, req.Amount)
+}
+
+func (h *TransactionHandler) VoidHold(c *gin.Context) {
+	h.settleHold(c, constants.Void, 0)
+}
+
+func (h *TransactionHandler) settleHold(c *gin.Context, typ constants.TransactionType, amount int64) {
+	holdID, err := utils.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.ErrParsingID)
+		return
+	}
+	wait, err := parseWait(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors.ErrInvalidWait.Error()})
+		return
+	}
+
+	txn, err := h.transactionService.SettleHold(c.Request.Context(), holdID, typ, amount)
+	switch {
+	case stderrors.Is(err, errors.ErrHoldNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case stderrors.Is(err, errors.ErrHoldNotActive):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case stderrors.Is(err, errors.ErrCaptureExceedsHold):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.submit(c, txn, wait)
+}
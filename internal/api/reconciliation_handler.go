@@ -0,0 +1,96 @@
+package api
+
+import (
+	stderrors "errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/imranzahoor/banking-ledger/internal/middleware"
+	"github.com/imranzahoor/banking-ledger/internal/policy"
+	"github.com/imranzahoor/banking-ledger/internal/service"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/pkg/utils"
+)
+
+type ReconciliationHandler struct {
+	reconciliationService *service.ReconciliationService
+}
+
+func NewReconciliationHandler(s *service.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{reconciliationService: s}
+}
+
+func (h *ReconciliationHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	read := middleware.Require(policy.ReadLedger)
+
+	recs := rg.Group("/reconciliations")
+	recs.POST("", middleware.Require(policy.Reconcile), h.StartReconciliation)
+	recs.GET("", read, h.ListReports)
+	recs.GET("/:id", read, h.GetReport)
+}
+
+type startReconciliationRequest struct {
+	Repair bool `json:"repair"` // write correcting entries for discrepancies
+}
+
+// StartReconciliation starts an on-demand run and answers 202 with its report
+// ID; the run continues in the background.
+func (h *ReconciliationHandler) StartReconciliation(c *gin.Context) {
+	var req startReconciliationRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	report, err := h.reconciliationService.Start(c.Request.Context(), constants.TriggerManual, req.Repair)
+	if stderrors.Is(err, errors.ErrReconciliationRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, report)
+}
+
+func (h *ReconciliationHandler) GetReport(c *gin.Context) {
+	id, err := utils.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.ErrParsingID)
+		return
+	}
+
+	report, err := h.reconciliationService.GetReport(c.Request.Context(), id)
+	if stderrors.Is(err, errors.ErrReportNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *ReconciliationHandler) ListReports(c *gin.Context) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "10"), 10, 64)
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, errors.ErrInvalidLimit)
+		return
+	}
+
+	reports, err := h.reconciliationService.ListReports(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reports)
+}
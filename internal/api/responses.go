@@ -0,0 +1,47 @@
+package api
+
+import (
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/pkg/currency"
+)
+
+// accountResponse adds currency-aware display fields and what the account can
+// still spend to an account.
+type accountResponse struct {
+	*model.Account
+	MinorUnits       int
+	BalanceDecimal   string
+	AvailableBalance int64
+	AvailableCredit  int64
+}
+
+func newAccountResponse(acc *model.Account) accountResponse {
+	return accountResponse{
+		Account:          acc,
+		MinorUnits:       currency.MinorUnits(acc.Currency),
+		BalanceDecimal:   currency.Format(acc.Balance, acc.Currency),
+		AvailableBalance: acc.AvailableBalance(),
+		AvailableCredit:  acc.AvailableCredit(),
+	}
+}
+
+// transactionResponse adds currency-aware display fields to a transaction.
+type transactionResponse struct {
+	*model.Transaction
+	AmountDecimal string
+}
+
+func newTransactionResponse(txn *model.Transaction) transactionResponse {
+	return transactionResponse{
+		Transaction:   txn,
+		AmountDecimal: currency.Format(txn.Amount, txn.Currency),
+	}
+}
+
+func newTransactionResponses(txns []model.Transaction) []transactionResponse {
+	resp := make([]transactionResponse, len(txns))
+	for i := range txns {
+		resp[i] = newTransactionResponse(&txns[i])
+	}
+	return resp
+}
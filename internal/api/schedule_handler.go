@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/policy"
+	"github.com/imranzahoor/banking-ledger/internal/service"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/pkg/utils"
+)
+
+type ScheduleHandler struct {
+	scheduleService *service.ScheduleService
+	accountService  *service.AccountService // looks up account owners for the policy
+}
+
+func NewScheduleHandler(s *service.ScheduleService, as *service.AccountService) *ScheduleHandler {
+	return &ScheduleHandler{scheduleService: s, accountService: as}
+}
+
+func (h *ScheduleHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	schedules := rg.Group("/schedules")
+	schedules.POST("", h.CreateSchedule)
+	schedules.GET("/:id", h.GetSchedule)
+	schedules.GET("/:id/executions", h.GetExecutions)
+	schedules.POST("/:id/pause", h.PauseSchedule)
+	schedules.POST("/:id/resume", h.ResumeSchedule)
+	schedules.POST("/:id/cancel", h.CancelSchedule)
+}
+
+type createScheduleRequest struct {
+	AccountID   string     `json:"account_id" binding:"required,uuid4"`
+	ToAccountID string     `json:"to_account_id" binding:"required_if=Type transfer,omitempty,uuid4"`
+	Type        string     `json:"type" binding:"required,oneof=deposit withdrawal transfer"`
+	Amount      int64      `json:"amount" binding:"required,gt=0"`
+	Currency    string     `json:"currency" binding:"omitempty,len=3"`
+	Description string     `json:"description"`
+	RunAt       *time.Time `json:"run_at"` // one-off, RFC 3339
+	Cron        string     `json:"cron"`   // recurring, e.g. "0 9 1 * *"
+	// SkipInsufficientFunds skips an occurrence the account cannot cover
+	SkipInsufficientFunds bool `json:"skip_insufficient_funds"`
+}
+
+func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
+	var req createScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accountID, err := utils.ParseUUID(req.AccountID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.ErrInvalidInput)
+		return
+	}
+
+	var toAccountID uuid.UUID
+	if req.ToAccountID != "" {
+		toAccountID, err = utils.ParseUUID(req.ToAccountID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errors.ErrInvalidInput)
+			return
+		}
+	}
+
+	sched := &model.Schedule{
+		AccountID:             accountID,
+		CounterpartyID:        toAccountID,
+		Type:                  constants.TransactionType(req.Type),
+		Amount:                req.Amount,
+		Currency:              req.Currency,
+		Description:           req.Description,
+		RunAt:                 req.RunAt,
+		Cron:                  req.Cron,
+		SkipInsufficientFunds: req.SkipInsufficientFunds,
+	}
+	if !authorizeTransaction(c, h.accountService, sched.Type, sched.AccountID) {
+		return
+	}
+
+	err = h.scheduleService.CreateSchedule(c.Request.Context(), sched)
+	switch {
+	case stderrors.Is(err, errors.ErrInvalidSchedule),
+		stderrors.Is(err, errors.ErrSameAccountTransfer),
+		stderrors.Is(err, errors.ErrCurrencyMismatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case stderrors.Is(err, errors.ErrAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case stderrors.Is(err, errors.ErrAccountFrozen), stderrors.Is(err, errors.ErrAccountClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, sched)
+}
+
+func (h *ScheduleHandler) GetSchedule(c *gin.Context) {
+	h.respondSchedule(c, nil)
+}
+
+func (h *ScheduleHandler) PauseSchedule(c *gin.Context) {
+	h.respondSchedule(c, h.scheduleService.PauseSchedule)
+}
+
+func (h *ScheduleHandler) ResumeSchedule(c *gin.Context) {
+	h.respondSchedule(c, h.scheduleService.ResumeSchedule)
+}
+
+func (h *ScheduleHandler) CancelSchedule(c *gin.Context) {
+	h.respondSchedule(c, h.scheduleService.CancelSchedule)
+}
+
+// respondSchedule answers the schedule after applying change, if given.
+func (h *ScheduleHandler) respondSchedule(c *gin.Context, change func(context.Context, uuid.UUID) (*model.Schedule, error)) {
+	id, err := utils.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.ErrParsingID)
+		return
+	}
+
+	sched, ok := h.authorizedSchedule(c, id, change != nil)
+	if !ok {
+		return
+	}
+	if change != nil {
+		if sched, err = change(c.Request.Context(), id); err != nil {
+			respondScheduleError(c, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, sched)
+}
+
+// authorizedSchedule loads the schedule and checks the caller may see it or,
+// to modify it, post the transactions it creates. It writes the response and
+// returns false when either fails.
+func (h *ScheduleHandler) authorizedSchedule(c *gin.Context, id uuid.UUID, modify bool) (*model.Schedule, bool) {
+	sched, err := h.scheduleService.GetSchedule(c.Request.Context(), id)
+	if err != nil {
+		respondScheduleError(c, err)
+		return nil, false
+	}
+
+	if modify {
+		return sched, authorizeTransaction(c, h.accountService, sched.Type, sched.AccountID)
+	}
+	return sched, authorizeAccount(c, h.accountService, policy.ReadAccounts, sched.AccountID)
+}
+
+func (h *ScheduleHandler) GetExecutions(c *gin.Context) {
+	id, err := utils.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.ErrParsingID)
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, errors.ErrInvalidLimit)
+		return
+	}
+	if _, ok := h.authorizedSchedule(c, id, false); !ok {
+		return
+	}
+
+	execs, err := h.scheduleService.GetExecutions(c.Request.Context(), id, limit)
+	if err != nil {
+		respondScheduleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, execs)
+}
+
+func respondScheduleError(c *gin.Context, err error) {
+	switch {
+	case stderrors.Is(err, errors.ErrScheduleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case stderrors.Is(err, errors.ErrInvalidStatusChange):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,37 +1,77 @@
 package api
 
 import (
+	stderrors "errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/middleware"
 	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/policy"
 	"github.com/imranzahoor/banking-ledger/internal/service"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
 	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/currency"
 	"github.com/imranzahoor/banking-ledger/pkg/errors"
 	"github.com/imranzahoor/banking-ledger/pkg/utils"
 )
 
+// idempotencyKeyHeader lets clients safely retry POST /transactions.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// maxWait caps how long CreateTransaction blocks in wait mode.
+const maxWait = 30 * time.Second
+
 type TransactionHandler struct {
-	transactionService *service.TransactionService
+	transactionService service.TransactionServiceInterface
+	accountService     *service.AccountService // looks up account owners for the policy
+	postingLimit       config.RateLimit
+	accountLimit       config.RateLimit
 }
 
-func NewTransactionHandler(s *service.TransactionService) *TransactionHandler {
-	return &TransactionHandler{transactionService: s}
+func NewTransactionHandler(s service.TransactionServiceInterface, as *service.AccountService, cfg config.Config) *TransactionHandler {
+	return &TransactionHandler{
+		transactionService: s,
+		accountService:     as,
+		postingLimit:       cfg.PostingRateLimit,
+		accountLimit:       cfg.AccountRateLimit,
+	}
 }
 
+// RegisterRoutes mounts the transaction, hold and ledger routes. Posting is
+// authorized per transaction type and account in submit, and since every post
+// lands on the Kafka topic it is rate limited per caller and source account.
 func (h *TransactionHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	readLedger := middleware.Require(policy.ReadLedger)
+	perCaller := middleware.RateLimit(h.postingLimit, middleware.ByCaller)
+	perAccount := middleware.RateLimit(h.accountLimit, middleware.ByAccount)
+
 	txns := rg.Group("/transactions")
-	txns.POST("", h.CreateTransaction)
+	txns.POST("", perCaller, perAccount, h.CreateTransaction)
 
 	txns.GET("/account/:id", h.GetTransactionHistory)
+	txns.GET("/:id", h.GetTransaction)
+	txns.GET("/:id/journal", readLedger, h.GetJournalEntry)
+
+	holds := rg.Group("/holds")
+	holds.POST("", perCaller, perAccount, h.CreateHold)
+	holds.GET("/:id", h.GetHold)
+	holds.POST("/:id/capture", perCaller, h.CaptureHold)
+	holds.POST("/:id/void", perCaller, h.VoidHold)
 
+	rg.GET("/ledger/trial-balance", readLedger, h.GetTrialBalance)
 }
 
 type createTransactionRequest struct {
-	AccountID string `json:"account_id" binding:"required,uuid4"`
-	Type      string `json:"type" binding:"required,oneof=deposit withdrawal"`
-	Amount    int64  `json:"amount" binding:"required,gt=0"`
+	AccountID   string `json:"account_id" binding:"required,uuid4"`
+	ToAccountID string `json:"to_account_id" binding:"required_if=Type transfer,omitempty,uuid4"`
+	Type        string `json:"type" binding:"required,oneof=deposit withdrawal transfer"`
+	Amount      int64  `json:"amount" binding:"required,gt=0"`
+	Currency    string `json:"currency" binding:"omitempty,len=3"` // defaults to the source account's currency
 }
 
 func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
@@ -40,6 +80,11 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	wait, err := parseWait(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors.ErrInvalidWait.Error()})
+		return
+	}
 	accountId, err := utils.ParseUUID(req.AccountID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, errors.ErrInvalidInput)
@@ -51,13 +96,102 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 		return
 	}
 
-	if req.Type != string(constants.Deposit) && req.Type != string(constants.Withdrawal) {
+	txnType := constants.TransactionType(req.Type)
+	if txnType != constants.Deposit && txnType != constants.Withdrawal && txnType != constants.Transfer {
 		c.JSON(http.StatusBadRequest, errors.ErrInvalidTransactionType)
 		return
 	}
 
-	if req.Type == string(constants.Withdrawal) {
-		hasFunds, err := h.transactionService.HasSufficientFunds(c.Request.Context(), req.AccountID, req.Amount)
+	var toAccountID uuid.UUID
+	if txnType == constants.Transfer {
+		toAccountID, err = utils.ParseUUID(req.ToAccountID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errors.ErrInvalidInput)
+			return
+		}
+		if toAccountID == accountId {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errors.ErrSameAccountTransfer.Error()})
+			return
+		}
+	}
+
+	txn := &model.Transaction{
+		AccountID:      accountId,
+		CounterpartyID: toAccountID,
+		Type:           txnType,
+		Amount:         req.Amount,
+		Currency:       req.Currency,
+	}
+
+	h.submit(c, txn, wait)
+}
+
+// submit checks that the caller may post txn and replays the caller's
+// earlier answer for a reused Idempotency-Key. Otherwise it validates txn
+// against its accounts and enqueues it for the consumer under the key, if any.
+func (h *TransactionHandler) submit(c *gin.Context, txn *model.Transaction, wait time.Duration) {
+	if !authorizeTransaction(c, h.accountService, txn.Type, txn.AccountID) {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	var key *model.IdempotencyKey
+	if header := c.GetHeader(idempotencyKeyHeader); header != "" {
+		if len(header) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errors.ErrInvalidIdempotencyKey.Error()})
+			return
+		}
+
+		var err error
+		key, err = service.NewIdempotencyKey(idempotencyScope(middleware.IdentityFrom(c)), header, txn)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// answered before the account and funds checks, which may no longer pass
+		// once the original is accepted: its accounts can since have been
+		// frozen, closed or drawn on
+		replayed, err := h.transactionService.ReplayIdempotencyKey(ctx, key, txn)
+		if stderrors.Is(err, errors.ErrDuplicateRequest) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if replayed {
+			h.respondEnqueued(c, txn, wait)
+			return
+		}
+	}
+
+	err := h.transactionService.ValidateAccounts(ctx, txn)
+	if stderrors.Is(err, errors.ErrAccountNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if stderrors.Is(err, errors.ErrAccountFrozen) || stderrors.Is(err, errors.ErrAccountClosed) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if stderrors.Is(err, errors.ErrCurrencyMismatch) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if txn.Type == constants.Withdrawal || txn.Type == constants.Transfer || txn.Type == constants.Hold {
+		hasFunds, err := h.transactionService.HasSufficientFunds(ctx, txn.AccountID.String(), txn.Amount)
+		if stderrors.Is(err, errors.ErrAccountNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -68,24 +202,115 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 		}
 	}
 
-	txn := &model.Transaction{
-		AccountID: accountId,
-		Type:      constants.TransactionType(req.Type),
-		Amount:    req.Amount,
+	if key != nil {
+		// the key is stored with the transaction, so a concurrent retry either
+		// replays it or finds no key and races to enqueue it here
+		_, err = h.transactionService.EnqueueIdempotent(ctx, key, txn)
+	} else {
+		err = h.transactionService.EnqueueTransaction(ctx, txn)
+	}
+	if stderrors.Is(err, errors.ErrDuplicateRequest) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
 	}
-
-	err = h.transactionService.EnqueueTransaction(c.Request.Context(), txn)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	h.respondEnqueued(c, txn, wait)
+}
+
+// idempotencyScope names the caller an Idempotency-Key belongs to: the API key
+// itself, whose name may be reused by a later key, or the authenticated
+// subject.
+func idempotencyScope(identity *model.Identity) string {
+	if identity == nil {
+		return ""
+	}
+	if identity.KeyID != uuid.Nil {
+		return "key:" + identity.KeyID.String()
+	}
+	return identity.Method + ":" + identity.Subject
+}
+
+// respondEnqueued answers 202 for an accepted transaction. In wait mode it
+// first blocks until the consumer has processed it, answering 201 with the
+// resulting balance or 422 with the failure reason, and falls back to 202 on
+// timeout.
+func (h *TransactionHandler) respondEnqueued(c *gin.Context, txn *model.Transaction, wait time.Duration) {
+	if wait > 0 {
+		final, account, err := h.transactionService.WaitForCompletion(c.Request.Context(), txn.ID, wait)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		switch {
+		case final != nil && final.Status == constants.StatusCompleted:
+			resp := gin.H{
+				"message":        "transaction completed",
+				"transaction_id": txn.ID,
+				"status":         final.Status,
+			}
+			if account != nil {
+				resp["balance"] = account.Balance
+				resp["available_balance"] = account.AvailableBalance()
+				resp["currency"] = account.Currency
+				resp["balance_decimal"] = currency.Format(account.Balance, account.Currency)
+			}
+			c.JSON(http.StatusCreated, resp)
+			return
+		case final != nil && final.Status == constants.StatusFailed:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":          final.FailureReason,
+				"transaction_id": txn.ID,
+				"status":         final.Status,
+			})
+			return
+		}
+	}
+
 	c.JSON(http.StatusAccepted, gin.H{
 		"message":        "transaction accepted",
 		"transaction_id": txn.ID,
 	})
 }
 
+// parseWait reads the opt-in wait mode from ?wait=<duration|seconds> or a
+// "Prefer: wait=<seconds>" header (RFC 7240). Zero means fire-and-forget.
+func parseWait(c *gin.Context) (time.Duration, error) {
+	raw, ok := c.GetQuery("wait")
+	if !ok {
+		for _, pref := range strings.Split(c.GetHeader("Prefer"), ",") {
+			name, value, found := strings.Cut(strings.TrimSpace(pref), "=")
+			if found && strings.EqualFold(strings.TrimSpace(name), "wait") {
+				raw, ok = strings.TrimSpace(value)+"s", true
+				break
+			}
+		}
+	}
+	if !ok {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		secs, convErr := strconv.Atoi(raw)
+		if convErr != nil {
+			return 0, err
+		}
+		d = time.Duration(secs) * time.Second
+	}
+	if d < 0 {
+		return 0, errors.ErrInvalidWait
+	}
+	if d > maxWait {
+		d = maxWait
+	}
+	return d, nil
+}
+
 func (h *TransactionHandler) GetTransactionHistory(c *gin.Context) {
 	accountID := c.Param("id")
 
@@ -110,12 +335,169 @@ func (h *TransactionHandler) GetTransactionHistory(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, errors.ErrParsingID)
 		return
 	}
+	if !authorizeAccount(c, h.accountService, policy.ReadAccounts, parsedID) {
+		return
+	}
+
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// ?cursor= (empty for the first page) switches to cursor pagination
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		h.getTransactionPage(c, parsedID, filter, cursor, limit)
+		return
+	}
 
-	txns, err := h.transactionService.GetTransactions(parsedID, limit, offset)
+	txns, err := h.transactionService.GetTransactions(parsedID, filter, limit, offset)
+	if isFilterError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, errors.ErrFetchingTransactions)
 		return
 	}
 
-	c.JSON(http.StatusOK, txns)
+	c.JSON(http.StatusOK, newTransactionResponses(txns))
+}
+
+// getTransactionPage answers one page of history with the cursor of the next
+// page. Unlike offsets, the cursor stays valid as new transactions arrive.
+func (h *TransactionHandler) getTransactionPage(c *gin.Context, accountID uuid.UUID, filter model.TransactionFilter, cursor string, limit int64) {
+	txns, next, err := h.transactionService.GetTransactionsPage(c.Request.Context(), accountID, filter, cursor, limit)
+	if stderrors.Is(err, errors.ErrInvalidCursor) || stderrors.Is(err, errors.ErrInvalidLimit) || isFilterError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errors.ErrFetchingTransactions)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": newTransactionResponses(txns),
+		"next_cursor":  next,
+	})
+}
+
+// parseTransactionFilter reads the history filters: from and to (RFC 3339 or
+// YYYY-MM-DD; entries after from up to and including to, as on statements, and
+// a date in to includes the whole day), type as a
+// comma-separated list, min_amount and max_amount in minor units, and
+// description. Only malformed values are rejected here; the service validates
+// the filter as a whole.
+func parseTransactionFilter(c *gin.Context) (model.TransactionFilter, error) {
+	var filter model.TransactionFilter
+
+	if raw := c.Query("from"); raw != "" {
+		from, err := parseRangeBound(raw, false)
+		if err != nil {
+			return filter, err
+		}
+		filter.From = &from
+	}
+	if raw := c.Query("to"); raw != "" {
+		to, err := parseRangeBound(raw, true)
+		if err != nil {
+			return filter, err
+		}
+		filter.To = &to
+	}
+
+	if raw := c.Query("type"); raw != "" {
+		for _, t := range strings.Split(raw, ",") {
+			filter.Types = append(filter.Types, constants.TransactionType(strings.ToLower(strings.TrimSpace(t))))
+		}
+	}
+
+	var err error
+	if filter.MinAmount, err = parseAmountParam(c, "min_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = parseAmountParam(c, "max_amount"); err != nil {
+		return filter, err
+	}
+
+	filter.Description = strings.TrimSpace(c.Query("description"))
+	return filter, nil
+}
+
+func parseAmountParam(c *gin.Context, name string) (*int64, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+	amount, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return nil, errors.ErrInvalidAmountRange
+	}
+	return &amount, nil
+}
+
+func isFilterError(err error) bool {
+	return stderrors.Is(err, errors.ErrInvalidDateRange) ||
+		stderrors.Is(err, errors.ErrInvalidAmountRange) ||
+		stderrors.Is(err, errors.ErrInvalidTransactionType) ||
+		stderrors.Is(err, errors.ErrInvalidDescriptionFilter)
+}
+
+func (h *TransactionHandler) GetTransaction(c *gin.Context) {
+	txnID, err := utils.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.ErrParsingID)
+		return
+	}
+
+	txn, err := h.transactionService.GetTransaction(c.Request.Context(), txnID)
+	if stderrors.Is(err, errors.ErrTransactionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// both sides of a transfer may look it up
+	accountIDs := []uuid.UUID{txn.AccountID}
+	if txn.CounterpartyID != uuid.Nil {
+		accountIDs = append(accountIDs, txn.CounterpartyID)
+	}
+	if !authorizeAnyAccount(c, h.accountService, policy.ReadAccounts, accountIDs...) {
+		return
+	}
+
+	c.JSON(http.StatusOK, newTransactionResponse(txn))
+}
+
+func (h *TransactionHandler) GetJournalEntry(c *gin.Context) {
+	txnID, err := utils.ParseUUID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errors.ErrParsingID)
+		return
+	}
+
+	entry, err := h.transactionService.GetJournalEntry(c.Request.Context(), txnID)
+	if stderrors.Is(err, errors.ErrJournalEntryNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+func (h *TransactionHandler) GetTrialBalance(c *gin.Context) {
+	tb, err := h.transactionService.GetTrialBalance(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tb)
 }
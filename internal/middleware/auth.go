@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/policy"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	apperrors "github.com/imranzahoor/banking-ledger/pkg/errors"
+)
+
+const (
+	// APIKeyHeader carries an API key; JWTs are sent as Authorization: Bearer.
+	APIKeyHeader = "X-API-Key"
+
+	identityKey = "identity"
+)
+
+// Authenticator verifies the credentials presented with a request.
+type Authenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*model.Identity, error)
+	AuthenticateToken(token string) (*model.Identity, error)
+}
+
+// Authenticate rejects requests without valid credentials with 401 and
+// attaches the caller's identity to the context for the handlers after it.
+func Authenticate(auth Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, err := authenticate(c, auth)
+		if errors.Is(err, apperrors.ErrUnauthenticated) {
+			c.Header("WWW-Authenticate", `Bearer realm="banking-ledger"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "authenticating request", "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+
+		c.Set(identityKey, identity)
+		c.Next()
+	}
+}
+
+// Anonymous stands in for Authenticate when authentication is disabled,
+// treating every caller as an administrator. Only meant for local development.
+func Anonymous() gin.HandlerFunc {
+	identity := &model.Identity{Subject: "anonymous", Method: "none", Role: constants.RoleAdmin}
+	return func(c *gin.Context) {
+		c.Set(identityKey, identity)
+		c.Next()
+	}
+}
+
+// Require answers 403 unless the caller's role grants action. Checks that
+// depend on the account involved are left to the handlers.
+func Require(action policy.Action) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := policy.Allow(IdentityFrom(c), action); err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+// IdentityFrom returns the caller attached by Authenticate, or nil.
+func IdentityFrom(c *gin.Context) *model.Identity {
+	if v, ok := c.Get(identityKey); ok {
+		identity, _ := v.(*model.Identity)
+		return identity
+	}
+	return nil
+}
+
+func authenticate(c *gin.Context, auth Authenticator) (*model.Identity, error) {
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		return auth.AuthenticateAPIKey(c.Request.Context(), key)
+	}
+
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, apperrors.ErrUnauthenticated
+	}
+	return auth.AuthenticateToken(strings.TrimSpace(token))
+}
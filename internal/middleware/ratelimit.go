@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+	apperrors "github.com/imranzahoor/banking-ledger/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// KeyFunc picks the token bucket a request draws from. Requests it returns
+// false for are not limited.
+type KeyFunc func(c *gin.Context) (string, bool)
+
+// RateLimit answers 429 with Retry-After once the bucket for the request's key
+// is empty. Every limited response carries X-RateLimit-Limit, -Remaining and
+// -Reset, the seconds until the bucket is full again; when several limits
+// apply, the headers describe the last one checked.
+func RateLimit(limit config.RateLimit, key KeyFunc) gin.HandlerFunc {
+	if !limit.Enabled() {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	b := newBuckets(limit)
+	return func(c *gin.Context) {
+		k, ok := key(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		remaining, reset, retry := b.take(k, time.Now())
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit.Requests))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Header("X-RateLimit-Reset", seconds(reset))
+		if retry > 0 {
+			c.Header("Retry-After", seconds(retry))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": apperrors.ErrRateLimited.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+// maxKeyedBodyBytes caps how much of a request body ByAccount buffers.
+const maxKeyedBodyBytes = 64 << 10
+
+// ByIP keys on the client IP. It runs before authentication so that floods of
+// bad credentials are limited without a key lookup each.
+func ByIP(c *gin.Context) (string, bool) {
+	return "ip:" + c.ClientIP(), true
+}
+
+// ByCaller keys on the API key or token subject of the caller, or on the
+// client IP when the request is anonymous.
+func ByCaller(c *gin.Context) (string, bool) {
+	identity := IdentityFrom(c)
+	switch {
+	case identity == nil || identity.Method == "none":
+		return "ip:" + c.ClientIP(), true
+	case identity.KeyID != uuid.Nil:
+		return "key:" + identity.KeyID.String(), true
+	default:
+		return identity.Method + ":" + identity.Subject, true
+	}
+}
+
+// ByAccount keys on the account_id in a JSON request body, in canonical form
+// so spellings of one UUID share a bucket, and leaves the body in place for the
+// handler. At most maxKeyedBodyBytes are read; a larger body reaches the
+// handler cut short. Requests without a valid account ID are not limited.
+func ByAccount(c *gin.Context) (string, bool) {
+	if c.Request.Body == nil {
+		return "", false
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxKeyedBodyBytes))
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return "", false
+	}
+
+	var req struct {
+		AccountID string `json:"account_id"`
+	}
+	if json.Unmarshal(body, &req) != nil {
+		return "", false
+	}
+	id, err := uuid.Parse(req.AccountID)
+	if err != nil {
+		return "", false
+	}
+	return "account:" + id.String(), true
+}
+
+// buckets holds a limiter per key. Buckets that have refilled are dropped
+// once per period, since a fresh one behaves the same.
+type buckets struct {
+	limit rate.Limit
+	burst int
+	per   time.Duration
+
+	mu    sync.Mutex
+	byKey map[string]*rate.Limiter
+	swept time.Time
+}
+
+func newBuckets(limit config.RateLimit) *buckets {
+	return &buckets{
+		limit: rate.Limit(float64(limit.Requests) / limit.Per.Seconds()),
+		burst: limit.Requests,
+		per:   limit.Per,
+		byKey: make(map[string]*rate.Limiter),
+		swept: time.Now(),
+	}
+}
+
+// take draws a token for key. It returns the tokens left, how long until the
+// bucket is full and, if the bucket was empty, how long until a token frees up.
+func (b *buckets) take(key string, now time.Time) (remaining int, reset, retry time.Duration) {
+	lim := b.limiter(key, now)
+
+	r := lim.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		retry = delay
+	}
+
+	tokens := max(lim.TokensAt(now), 0)
+	reset = time.Duration((float64(b.burst) - tokens) / float64(b.limit) * float64(time.Second))
+	return int(tokens), reset, retry
+}
+
+func (b *buckets) limiter(key string, now time.Time) *rate.Limiter {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.Sub(b.swept) >= b.per {
+		for k, lim := range b.byKey {
+			if lim.TokensAt(now) >= float64(b.burst) {
+				delete(b.byKey, k)
+			}
+		}
+		b.swept = now
+	}
+
+	lim, ok := b.byKey[key]
+	if !ok {
+		lim = rate.NewLimiter(b.limit, b.burst)
+		b.byKey[key] = lim
+	}
+	return lim
+}
+
+// seconds renders d rounded up to whole seconds, as Retry-After expects.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -4,13 +4,50 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
 )
 
 // Account represents a bank account domain model.
 type Account struct {
-	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	OwnerName string    `gorm:"not null"`
-	Balance   int64     `gorm:"not null"` // smallest currency unit (e.g. cents)
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID             uuid.UUID               `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OwnerName      string                  `gorm:"not null"`
+	OwnerID        string                  `gorm:"type:varchar(255);index"`             // principal of the customer who may use the account
+	Balance        int64                   `gorm:"not null"`                            // smallest currency unit (e.g. cents)
+	Currency       string                  `gorm:"type:char(3);not null;default:'USD'"` // ISO 4217 code
+	HeldBalance    int64                   `gorm:"not null;default:0"`                  // reserved by active holds, included in Balance
+	OverdraftLimit int64                   `gorm:"not null;default:0"`                  // how far Balance may go below zero
+	Status         constants.AccountStatus `gorm:"type:varchar(10);not null;default:'active'"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// AvailableBalance is what can still be spent: the ledger balance plus the
+// overdraft limit, less what holds reserve.
+func (a *Account) AvailableBalance() int64 {
+	return a.Balance + a.OverdraftLimit - a.HeldBalance
+}
+
+// AvailableCredit is the part of the overdraft limit not yet drawn on.
+func (a *Account) AvailableCredit() int64 {
+	return min(a.OverdraftLimit, max(a.AvailableBalance(), 0))
+}
+
+// CanDebit reports whether funds may be taken from the account.
+func (a *Account) CanDebit() error {
+	switch a.Status {
+	case constants.AccountFrozen:
+		return errors.ErrAccountFrozen
+	case constants.AccountClosed:
+		return errors.ErrAccountClosed
+	}
+	return nil
+}
+
+// CanCredit reports whether funds may be paid into the account.
+func (a *Account) CanCredit() error {
+	if a.Status == constants.AccountClosed {
+		return errors.ErrAccountClosed
+	}
+	return nil
 }
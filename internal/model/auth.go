@@ -0,0 +1,58 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+)
+
+// APIKey is a credential issued to a client. Only the SHA-256 hash of the key
+// is stored; the key itself is shown once, when it is created.
+type APIKey struct {
+	ID        uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name      string         `gorm:"not null"`
+	Role      constants.Role `gorm:"type:varchar(20);not null"`
+	Prefix    string         `gorm:"type:varchar(16);not null"` // identifies the key in listings
+	KeyHash   string         `gorm:"type:char(64);not null;uniqueIndex"`
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// Active reports whether the key has not been revoked.
+func (k *APIKey) Active() bool {
+	return k.RevokedAt == nil
+}
+
+// Principal prefixes tell apart the kinds of caller that can own an account,
+// so an API key named like a token subject never matches that subject's
+// accounts.
+const (
+	APIKeyPrincipal = "key:"
+	JWTPrincipal    = "jwt:"
+)
+
+// Identity is the authenticated caller of a request. For customers, Principal
+// is matched against Account.OwnerID.
+type Identity struct {
+	Subject   string // API key name, JWT subject, or "admin"
+	Method    string // "api_key", "jwt" or "admin_key"
+	Principal string // Subject prefixed with APIKeyPrincipal or JWTPrincipal; unset for the admin key
+	Role      constants.Role
+	KeyID     uuid.UUID // set for API keys
+}
+
+// ValidOwnerID reports whether id is the principal of an API key or token
+// subject, or empty for no owner.
+func ValidOwnerID(id string) bool {
+	if id == "" {
+		return true
+	}
+	for _, prefix := range []string{APIKeyPrincipal, JWTPrincipal} {
+		if name, ok := strings.CutPrefix(id, prefix); ok {
+			return name != ""
+		}
+	}
+	return false
+}
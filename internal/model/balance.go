@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BalanceSnapshot is an account's ledger balance as of a point in time: the
+// signed sum of its ledger entries written up to AsOf. Snapshots of settled
+// points in time are stored so later queries only sum the entries after them.
+type BalanceSnapshot struct {
+	AccountID uuid.UUID
+	Currency  string
+	Balance   int64
+	AsOf      time.Time
+	Entries   int64 `bson:"-"` // entries summed on top of the snapshot it was built from
+	CreatedAt time.Time
+}
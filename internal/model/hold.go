@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+)
+
+// Hold reserves part of an account's balance for a later capture. While active
+// its Amount is counted in the account's HeldBalance. A hold shares its ID with
+// the transaction that placed it.
+type Hold struct {
+	ID          uuid.UUID            `gorm:"type:uuid;primaryKey"`
+	AccountID   uuid.UUID            `gorm:"type:uuid;not null;index"`
+	Amount      int64                `gorm:"not null"`
+	Captured    int64                `gorm:"not null;default:0"`
+	Currency    string               `gorm:"type:char(3);not null"`
+	Status      constants.HoldStatus `gorm:"type:varchar(10);not null;default:'active'"`
+	Description string
+	ExpiresAt   time.Time `gorm:"not null;index:idx_holds_active_expiry,where:status = 'active'"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKey records the transaction accepted for a client-supplied
+// Idempotency-Key together with a fingerprint of the request body. Keys are
+// scoped to the caller that sent them, so one caller's key never replays
+// another caller's transaction.
+type IdempotencyKey struct {
+	Scope         string    `gorm:"type:varchar(255);primaryKey"`
+	Key           string    `gorm:"type:varchar(255);primaryKey"`
+	Fingerprint   string    `gorm:"type:char(64);not null"`
+	TransactionID uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt     time.Time
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+)
+
+// Posting is one side of a journal entry against a single account.
+type Posting struct {
+	AccountID uuid.UUID
+	Direction constants.EntryDirection
+	Amount    int64 // always positive; Direction carries the sign
+}
+
+// JournalEntry is a double-entry record of a transaction. The sum of its debit
+// postings must equal the sum of its credit postings; all postings share the
+// entry's currency.
+type JournalEntry struct {
+	ID            uuid.UUID
+	TransactionID uuid.UUID
+	Type          constants.TransactionType
+	Currency      string
+	Description   string
+	Postings      []Posting
+	CreatedAt     time.Time
+}
+
+// TrialBalance is the total of all debit and credit postings in the journal
+// for one currency.
+type TrialBalance struct {
+	Currency string
+	Debits   int64
+	Credits  int64
+	Balanced bool
+}
+
+// Validate checks that the entry has at least two positive postings and that
+// debits equal credits.
+func (e *JournalEntry) Validate() error {
+	if len(e.Postings) < 2 {
+		return errors.ErrUnbalancedEntry
+	}
+
+	var debits, credits int64
+	for _, p := range e.Postings {
+		if p.Amount <= 0 {
+			return errors.ErrInvalidAmount
+		}
+		switch p.Direction {
+		case constants.Debit:
+			debits += p.Amount
+		case constants.Credit:
+			credits += p.Amount
+		default:
+			return errors.ErrUnbalancedEntry
+		}
+	}
+
+	if debits != credits {
+		return errors.ErrUnbalancedEntry
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxMessage is a Kafka message persisted in Postgres in the request path and
+// published to the broker later by the outbox relay.
+type OutboxMessage struct {
+	ID            uuid.UUID         `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Topic         string            `gorm:"not null"`
+	Key           string            `gorm:"not null"`
+	Payload       []byte            `gorm:"type:bytea;not null"`
+	Headers       map[string]string `gorm:"serializer:json;type:jsonb"` // published as Kafka message headers
+	Attempts      int               `gorm:"not null;default:0"`
+	LastError     string
+	NextAttemptAt time.Time `gorm:"not null;index:idx_outbox_pending,where:sent_at IS NULL"`
+	SentAt        *time.Time
+	CreatedAt     time.Time
+}
+
+// NewTransactionMessage builds the outbox message that carries txn to the
+// transaction consumer on topic, keyed by the transaction ID.
+func NewTransactionMessage(topic string, txn *Transaction, headers map[string]string) (*OutboxMessage, error) {
+	data, err := json.Marshal(txn)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxMessage{Topic: topic, Key: txn.ID.String(), Payload: data, Headers: headers}, nil
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProcessedTransaction marks a transaction whose balance changes have been
+// applied. It is written in the same database transaction as the balance update
+// so redelivered messages can be detected.
+type ProcessedTransaction struct {
+	TransactionID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	LedgerWritten bool      `gorm:"not null;default:false"`
+	ProcessedAt   time.Time
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+)
+
+// ReconciliationReport is the outcome of comparing every account's Postgres
+// balance with the balance recomputed from the Mongo ledger.
+type ReconciliationReport struct {
+	ID              uuid.UUID
+	Trigger         constants.ReconciliationTrigger
+	Repair          bool // correcting entries were written for discrepancies
+	Status          constants.ReconciliationStatus
+	Error           string
+	AccountsChecked int
+	AccountsSkipped int         // changed within the grace period, so possibly mid-flight
+	PendingLedger   []uuid.UUID // not checked: applied transactions still await their ledger entries
+	Discrepancies   []Discrepancy
+	StartedAt       time.Time
+	FinishedAt      *time.Time
+}
+
+// Discrepancy is an account whose ledger does not add up to its balance.
+// Postgres is authoritative: balances are applied there first and the ledger
+// written after, so a repair adjusts the ledger rather than the balance.
+type Discrepancy struct {
+	AccountID      uuid.UUID
+	Currency       string
+	AccountBalance int64
+	LedgerBalance  int64
+	Difference     int64     // AccountBalance - LedgerBalance
+	CorrectionID   uuid.UUID // adjustment transaction written by a repair
+	RepairError    string
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule is a standing order that enqueues a transaction when due, either
+// once at RunAt or on every occurrence of the Cron expression.
+type Schedule struct {
+	ID             uuid.UUID                 `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	AccountID      uuid.UUID                 `gorm:"type:uuid;not null;index"`
+	CounterpartyID uuid.UUID                 `gorm:"type:uuid"` // destination account for transfers
+	Type           constants.TransactionType `gorm:"type:varchar(20);not null"`
+	Amount         int64                     `gorm:"not null"`
+	Currency       string                    `gorm:"type:char(3);not null"`
+	Description    string
+	RunAt          *time.Time // one-off schedules
+	Cron           string     // recurring schedules, standard 5-field syntax with optional CRON_TZ= prefix
+	// SkipInsufficientFunds skips an occurrence the account cannot cover
+	// instead of enqueueing a transaction the consumer will reject.
+	SkipInsufficientFunds bool
+	Status                constants.ScheduleStatus `gorm:"type:varchar(10);not null;default:'active'"`
+	NextRunAt             *time.Time               `gorm:"index:idx_schedules_due,where:status = 'active'"`
+	LastRunAt             *time.Time
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+}
+
+// ScheduleExecution records the outcome of one occurrence of a schedule. The
+// transaction's own status tracks what the consumer made of it.
+type ScheduleExecution struct {
+	ID            uuid.UUID                  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ScheduleID    uuid.UUID                  `gorm:"type:uuid;not null;uniqueIndex:idx_schedule_occurrence"`
+	DueAt         time.Time                  `gorm:"not null;uniqueIndex:idx_schedule_occurrence"`
+	TransactionID uuid.UUID                  `gorm:"type:uuid"` // unset when nothing was enqueued
+	Outcome       constants.ExecutionOutcome `gorm:"type:varchar(10);not null"`
+	Reason        string
+	CreatedAt     time.Time
+}
+
+// NextRun returns the first occurrence after t, or nil once a one-off schedule
+// has run.
+func (s *Schedule) NextRun(t time.Time) (*time.Time, error) {
+	if s.Cron == "" {
+		if s.RunAt != nil && s.RunAt.After(t) {
+			return s.RunAt, nil
+		}
+		return nil, nil
+	}
+
+	spec, err := cron.ParseStandard(s.Cron)
+	if err != nil {
+		return nil, err
+	}
+	next := spec.Next(t).UTC()
+	return &next, nil
+}
+
+// Transaction builds the transaction enqueued on each occurrence.
+func (s *Schedule) Transaction() *Transaction {
+	return &Transaction{
+		AccountID:      s.AccountID,
+		CounterpartyID: s.CounterpartyID,
+		Type:           s.Type,
+		Amount:         s.Amount,
+		Currency:       s.Currency,
+		Description:    s.Description,
+	}
+}
@@ -9,10 +9,103 @@ import (
 
 // Transaction represents a banking ledger entry.
 type Transaction struct {
-	ID          uuid.UUID                 `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	AccountID   uuid.UUID                 `gorm:"type:uuid;not null"`
-	Type        constants.TransactionType `gorm:"type:varchar(20);not null"`
-	Amount      int64                     `gorm:"not null"`
-	Description string
-	CreatedAt   time.Time
+	ID             uuid.UUID                 `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	AccountID      uuid.UUID                 `gorm:"type:uuid;not null"`
+	CounterpartyID uuid.UUID                 `gorm:"type:uuid"` // destination account for transfers
+	HoldID         uuid.UUID                 `gorm:"type:uuid"` // hold placed, captured or voided
+	Type           constants.TransactionType `gorm:"type:varchar(20);not null"`
+	Direction      constants.EntryDirection  `gorm:"type:varchar(10)"` // set on ledger entries
+	Amount         int64                     `gorm:"not null"`
+	Currency       string                    `gorm:"type:char(3)"` // ISO 4217 code; empty on legacy messages
+	Description    string
+	Status         constants.TransactionStatus `gorm:"type:varchar(20);not null;default:'pending'"`
+	FailureReason  string
+	ExpiresAt      *time.Time // when a hold lapses
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// MovesFunds reports whether the transaction changes a ledger balance. Placing
+// and voiding a hold only change what is available and write no ledger entries.
+func (t *Transaction) MovesFunds() bool {
+	return t.Type != constants.Hold && t.Type != constants.Void
+}
+
+// SignedAmount is a ledger entry's effect on its account's balance: positive
+// for credits and negative for debits. Legacy entries without a direction are
+// signed by their type, as the ledger's balance sums do.
+func (t *Transaction) SignedAmount() int64 {
+	if t.Direction == constants.Debit || (t.Direction == "" && t.Type == constants.Withdrawal) {
+		return -t.Amount
+	}
+	return t.Amount
+}
+
+// LedgerEntries expands the transaction into the per-account entries written to
+// the ledger. A transfer yields a debit on the source and a credit on the
+// destination, linked by the transaction ID.
+func (t *Transaction) LedgerEntries() []*Transaction {
+	switch t.Type {
+	case constants.Deposit:
+		return []*Transaction{t.entry(t.AccountID, constants.CashAccountID, constants.Credit)}
+	case constants.Withdrawal, constants.Capture:
+		return []*Transaction{t.entry(t.AccountID, constants.CashAccountID, constants.Debit)}
+	case constants.Transfer:
+		return []*Transaction{
+			t.entry(t.AccountID, t.CounterpartyID, constants.Debit),
+			t.entry(t.CounterpartyID, t.AccountID, constants.Credit),
+		}
+	case constants.Adjustment:
+		return []*Transaction{t.entry(t.AccountID, t.CounterpartyID, t.Direction)}
+	default:
+		return nil
+	}
+}
+
+// JournalEntry builds the balanced double-entry record for the transaction.
+// Deposits, withdrawals and captures are posted against the system cash account.
+func (t *Transaction) JournalEntry() *JournalEntry {
+	var debit, credit uuid.UUID
+	switch t.Type {
+	case constants.Deposit:
+		debit, credit = constants.CashAccountID, t.AccountID
+	case constants.Withdrawal, constants.Capture:
+		debit, credit = t.AccountID, constants.CashAccountID
+	case constants.Transfer:
+		debit, credit = t.AccountID, t.CounterpartyID
+	case constants.Adjustment:
+		// Direction is the side posted to the account
+		debit, credit = t.AccountID, t.CounterpartyID
+		if t.Direction == constants.Credit {
+			debit, credit = credit, debit
+		}
+	default:
+		return nil
+	}
+
+	return &JournalEntry{
+		ID:            t.ID,
+		TransactionID: t.ID,
+		Type:          t.Type,
+		Currency:      t.Currency,
+		Description:   t.Description,
+		Postings: []Posting{
+			{AccountID: debit, Direction: constants.Debit, Amount: t.Amount},
+			{AccountID: credit, Direction: constants.Credit, Amount: t.Amount},
+		},
+	}
+}
+
+func (t *Transaction) entry(accountID, counterpartyID uuid.UUID, dir constants.EntryDirection) *Transaction {
+	return &Transaction{
+		ID:             t.ID,
+		AccountID:      accountID,
+		CounterpartyID: counterpartyID,
+		Type:           t.Type,
+		Direction:      dir,
+		Amount:         t.Amount,
+		Currency:       t.Currency,
+		Description:    t.Description,
+		Status:         constants.StatusCompleted,
+	}
 }
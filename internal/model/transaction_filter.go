@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	apperrors "github.com/imranzahoor/banking-ledger/pkg/errors"
+)
+
+// maxDescriptionFilter bounds the description search text.
+const maxDescriptionFilter = 100
+
+// TransactionFilter narrows an account's transaction history. Zero-valued
+// fields match every entry. From and To bound the booking time the way
+// statements do: entries after From, up to and including To.
+type TransactionFilter struct {
+	From        *time.Time // exclusive
+	To          *time.Time // inclusive
+	Types       []constants.TransactionType
+	MinAmount   *int64 // minor units, inclusive
+	MaxAmount   *int64 // minor units, inclusive
+	Description string // case-insensitive substring
+}
+
+// Validate rejects filters that cannot match anything or that name types
+// which never appear in the ledger, such as holds.
+func (f TransactionFilter) Validate() error {
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return apperrors.ErrInvalidDateRange
+	}
+	if (f.MinAmount != nil && *f.MinAmount < 0) || (f.MaxAmount != nil && *f.MaxAmount < 0) ||
+		(f.MinAmount != nil && f.MaxAmount != nil && *f.MinAmount > *f.MaxAmount) {
+		return apperrors.ErrInvalidAmountRange
+	}
+	for _, t := range f.Types {
+		switch t {
+		case constants.Deposit, constants.Withdrawal, constants.Transfer, constants.Capture, constants.Adjustment:
+		default:
+			return apperrors.ErrInvalidTransactionType
+		}
+	}
+	if utf8.RuneCountInString(f.Description) > maxDescriptionFilter {
+		return apperrors.ErrInvalidDescriptionFilter
+	}
+	return nil
+}
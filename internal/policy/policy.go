@@ -0,0 +1,75 @@
+// Package policy decides what each role may do. Handlers check an Action per
+// route and, for callers confined to their own accounts, the account involved.
+package policy
+
+import (
+	"slices"
+
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	apperrors "github.com/imranzahoor/banking-ledger/pkg/errors"
+)
+
+// Action is a class of operations granted to roles as a whole.
+type Action string
+
+const (
+	ReadAccounts   Action = "read_accounts"   // account details, balances, statements, history, holds, schedules
+	ManageAccounts Action = "manage_accounts" // open, freeze, unfreeze, close, overdraft limits
+	Transact       Action = "transact"        // post transactions, of the types in transactionTypes
+	ReadLedger     Action = "read_ledger"     // journal entries, trial balance, reconciliation reports
+	Reconcile      Action = "reconcile"       // start reconciliation runs
+	ManageAPIKeys  Action = "manage_api_keys"
+)
+
+var grants = map[constants.Role][]Action{
+	constants.RoleAdmin:    {ReadAccounts, ManageAccounts, Transact, ReadLedger, Reconcile, ManageAPIKeys},
+	constants.RoleAuditor:  {ReadAccounts, ReadLedger},
+	constants.RoleTeller:   {ReadAccounts, Transact},
+	constants.RoleCustomer: {ReadAccounts, Transact},
+}
+
+// transactionTypes lists what each role may post, scheduled transactions
+// included. Admins may post any type.
+var transactionTypes = map[constants.Role][]constants.TransactionType{
+	constants.RoleTeller:   {constants.Deposit, constants.Withdrawal},
+	constants.RoleCustomer: {constants.Transfer},
+}
+
+// Allow returns ErrForbidden unless the caller's role grants action.
+func Allow(identity *model.Identity, action Action) error {
+	if identity == nil || !slices.Contains(grants[identity.Role], action) {
+		return apperrors.ErrForbidden
+	}
+	return nil
+}
+
+// OwnAccountsOnly reports whether the caller is confined to accounts they own,
+// so that handlers need to load the account before calling AllowAccount.
+func OwnAccountsOnly(identity *model.Identity) bool {
+	return identity != nil && identity.Role == constants.RoleCustomer
+}
+
+// AllowAccount checks action on acc. acc is only consulted for callers that
+// OwnAccountsOnly, and may be nil otherwise.
+func AllowAccount(identity *model.Identity, action Action, acc *model.Account) error {
+	if err := Allow(identity, action); err != nil {
+		return err
+	}
+	if OwnAccountsOnly(identity) && (acc == nil || acc.OwnerID == "" || acc.OwnerID != identity.Principal) {
+		return apperrors.ErrForbidden
+	}
+	return nil
+}
+
+// AllowTransaction checks posting a transaction of type typ from acc, the
+// account it debits or, for deposits, credits.
+func AllowTransaction(identity *model.Identity, typ constants.TransactionType, acc *model.Account) error {
+	if err := AllowAccount(identity, Transact, acc); err != nil {
+		return err
+	}
+	if identity.Role != constants.RoleAdmin && !slices.Contains(transactionTypes[identity.Role], typ) {
+		return apperrors.ErrForbidden
+	}
+	return nil
+}
@@ -2,27 +2,89 @@ package mongo
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/google/uuid"
 	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	apperrors "github.com/imranzahoor/banking-ledger/pkg/errors"
 )
 
 type LedgerRepo struct {
-	coll *mongo.Collection
+	coll      *mongo.Collection
+	journal   *mongo.Collection
+	snapshots *mongo.Collection
 }
 type LedgerRepository interface {
 	InsertTransaction(ctx context.Context, txn *model.Transaction) error
-	GetTransactionsByAccountID(ctx context.Context, accountID uuid.UUID, limit, offset int64) ([]model.Transaction, error)
+	InsertTransactions(ctx context.Context, txns []*model.Transaction) error
+	GetTransactionsByAccountID(ctx context.Context, accountID uuid.UUID, filter model.TransactionFilter, limit, offset int64) ([]model.Transaction, error)
+	GetTransactionsPage(ctx context.Context, accountID uuid.UUID, filter model.TransactionFilter, cursor string, limit int64) ([]model.Transaction, string, error)
+	StreamTransactionsByAccountID(ctx context.Context, accountID uuid.UUID, from, to time.Time, fn func(*model.Transaction) error) error
+	InsertJournalEntry(ctx context.Context, entry *model.JournalEntry) error
+	GetJournalEntry(ctx context.Context, transactionID uuid.UUID) (*model.JournalEntry, error)
+	GetTrialBalance(ctx context.Context) ([]model.TrialBalance, error)
+	GetBalanceAsOf(ctx context.Context, accountID uuid.UUID, asOf time.Time) (*model.BalanceSnapshot, error)
+	SaveBalanceSnapshot(ctx context.Context, snap *model.BalanceSnapshot) error
 }
 
 func NewLedgerRepo(client *mongo.Client, dbName string) *LedgerRepo {
-	coll := client.Database(dbName).Collection("transactions")
-	return &LedgerRepo{coll: coll}
+	db := client.Database(dbName)
+	return &LedgerRepo{
+		coll:      db.Collection("transactions"),
+		journal:   db.Collection("journal_entries"),
+		snapshots: db.Collection("balance_snapshots"),
+	}
+}
+
+// EnsureIndexes creates the unique indexes that make ledger writes idempotent
+func (r *LedgerRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}, {Key: "accountid", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// serves balance-as-of sums
+	_, err = r.coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "accountid", Value: 1}, {Key: "createdat", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// serves cursor pagination, newest first
+	_, err = r.coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "accountid", Value: 1}, {Key: "createdat", Value: -1}, {Key: "_id", Value: -1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.journal.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "transactionid", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.snapshots.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "accountid", Value: 1}, {Key: "asof", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
 }
 
 // InsertTransaction adds a new transaction log entry
@@ -37,9 +99,35 @@ func (r *LedgerRepo) InsertTransaction(ctx context.Context, txn *model.Transacti
 	return err
 }
 
-// GetTransactionsByAccountID fetches transaction logs for account with optional limit/offset
-func (r *LedgerRepo) GetTransactionsByAccountID(ctx context.Context, accountID uuid.UUID, limit, offset int64) ([]model.Transaction, error) {
-	filter := bson.M{"accountid": accountID}
+// InsertTransactions adds several linked transaction log entries, e.g. both legs
+// of a transfer. Entries are upserted by ID and account, so writing the entries
+// of an already recorded transaction again leaves the stored ones untouched.
+func (r *LedgerRepo) InsertTransactions(ctx context.Context, txns []*model.Transaction) error {
+	if len(txns) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	writes := make([]mongo.WriteModel, 0, len(txns))
+	for _, txn := range txns {
+		if txn.ID == uuid.Nil {
+			txn.ID = uuid.New()
+		}
+		txn.CreatedAt = now
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"id": txn.ID, "accountid": txn.AccountID}).
+			SetUpdate(bson.M{"$setOnInsert": txn}).
+			SetUpsert(true))
+	}
+
+	// unordered so entries already stored by an earlier attempt don't block the rest
+	_, err := r.coll.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return ignoreDuplicates(err)
+}
+
+// GetTransactionsByAccountID fetches transaction logs for account matching filter with optional limit/offset
+func (r *LedgerRepo) GetTransactionsByAccountID(ctx context.Context, accountID uuid.UUID, filter model.TransactionFilter, limit, offset int64) ([]model.Transaction, error) {
+	query := transactionQuery(accountID, filter)
 
 	findOptions := options.Find()
 	if limit > 0 {
@@ -50,7 +138,7 @@ func (r *LedgerRepo) GetTransactionsByAccountID(ctx context.Context, accountID u
 	}
 	findOptions.SetSort(bson.D{{Key: "createdat", Value: -1}}) // newest first
 
-	cursor, err := r.coll.Find(ctx, filter, findOptions)
+	cursor, err := r.coll.Find(ctx, query, findOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -70,3 +158,337 @@ func (r *LedgerRepo) GetTransactionsByAccountID(ctx context.Context, accountID u
 
 	return results, nil
 }
+
+// GetTransactionsPage returns up to limit of the account's ledger entries that
+// match filter, newest first, starting after cursor; an empty cursor starts at the newest
+// entry. It also returns the cursor of the next page, empty on the last one.
+// Pages are keyed on (createdat, _id), so entries written while a client pages
+// through neither shift nor repeat the entries it has yet to see.
+func (r *LedgerRepo) GetTransactionsPage(ctx context.Context, accountID uuid.UUID, filter model.TransactionFilter, cursor string, limit int64) ([]model.Transaction, string, error) {
+	query := transactionQuery(accountID, filter)
+	if cursor != "" {
+		createdAt, id, err := decodePageCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query["$or"] = bson.A{
+			bson.M{"createdat": bson.M{"$lt": createdAt}},
+			bson.M{"createdat": createdAt, "_id": bson.M{"$lt": id}},
+		}
+	}
+
+	// one extra entry tells whether there is a next page
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "createdat", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(limit + 1)
+
+	cursorRes, err := r.coll.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cursorRes.Close(ctx)
+
+	var (
+		results []model.Transaction
+		lastID  primitive.ObjectID
+	)
+	for cursorRes.Next(ctx) {
+		if int64(len(results)) == limit {
+			last := results[len(results)-1]
+			return results, encodePageCursor(last.CreatedAt, lastID), nil
+		}
+
+		var txn model.Transaction
+		if err := cursorRes.Decode(&txn); err != nil {
+			return nil, "", err
+		}
+		var key struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursorRes.Decode(&key); err != nil {
+			return nil, "", err
+		}
+		results = append(results, txn)
+		lastID = key.ID
+	}
+	if err := cursorRes.Err(); err != nil {
+		return nil, "", err
+	}
+	return results, "", nil
+}
+
+// transactionQuery translates filter into a query on the account's entries.
+func transactionQuery(accountID uuid.UUID, filter model.TransactionFilter) bson.M {
+	query := bson.M{"accountid": accountID}
+	if createdAt := bookedBetween(filter.From, filter.To); len(createdAt) > 0 {
+		query["createdat"] = createdAt
+	}
+
+	if len(filter.Types) > 0 {
+		query["type"] = bson.M{"$in": filter.Types}
+	}
+
+	amount := bson.M{}
+	if filter.MinAmount != nil {
+		amount["$gte"] = *filter.MinAmount
+	}
+	if filter.MaxAmount != nil {
+		amount["$lte"] = *filter.MaxAmount
+	}
+	if len(amount) > 0 {
+		query["amount"] = amount
+	}
+
+	if filter.Description != "" {
+		query["description"] = primitive.Regex{Pattern: regexp.QuoteMeta(filter.Description), Options: "i"}
+	}
+	return query
+}
+
+// bookedBetween matches creation times after from, up to and including to; a
+// nil bound is open. These are the bounds GetBalanceAsOf uses, so the entries
+// in a range are exactly those between the balances at from and at to.
+func bookedBetween(from, to *time.Time) bson.M {
+	createdAt := bson.M{}
+	if from != nil {
+		createdAt["$gt"] = from.UTC().Truncate(time.Millisecond)
+	}
+	if to != nil {
+		createdAt["$lte"] = to.UTC().Truncate(time.Millisecond)
+	}
+	return createdAt
+}
+
+// encodePageCursor packs the sort key of the last entry on a page: the
+// creation time in Unix milliseconds followed by the 12-byte _id.
+func encodePageCursor(createdAt time.Time, id primitive.ObjectID) string {
+	buf := make([]byte, 8, 8+len(id))
+	binary.BigEndian.PutUint64(buf, uint64(createdAt.UnixMilli()))
+	buf = append(buf, id[:]...)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func decodePageCursor(cursor string) (time.Time, primitive.ObjectID, error) {
+	var id primitive.ObjectID
+	buf, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(buf) != 8+len(id) {
+		return time.Time{}, id, apperrors.ErrInvalidCursor
+	}
+	copy(id[:], buf[8:])
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(buf))).UTC(), id, nil
+}
+
+// StreamTransactionsByAccountID calls fn for each of the account's ledger entries
+// written after from and up to to, oldest first. Entries are decoded one at a
+// time from the cursor; an error from fn stops the iteration and is returned.
+func (r *LedgerRepo) StreamTransactionsByAccountID(ctx context.Context, accountID uuid.UUID, from, to time.Time, fn func(*model.Transaction) error) error {
+	filter := bson.M{"accountid": accountID, "createdat": bookedBetween(&from, &to)}
+	findOptions := options.Find().SetSort(bson.D{{Key: "createdat", Value: 1}, {Key: "id", Value: 1}})
+
+	cursor, err := r.coll.Find(ctx, filter, findOptions)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var txn model.Transaction
+		if err := cursor.Decode(&txn); err != nil {
+			return err
+		}
+		if err := fn(&txn); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// InsertJournalEntry validates that the entry balances and stores it as a single
+// document, unless an entry for the same transaction is already stored.
+func (r *LedgerRepo) InsertJournalEntry(ctx context.Context, entry *model.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+
+	entry.CreatedAt = time.Now().UTC()
+
+	// keyed by transaction so a retried write leaves the first entry in place
+	_, err := r.journal.UpdateOne(ctx,
+		bson.M{"transactionid": entry.TransactionID},
+		bson.M{"$setOnInsert": entry},
+		options.Update().SetUpsert(true))
+	return ignoreDuplicates(err)
+}
+
+// GetJournalEntry fetches the journal entry recorded for a transaction
+func (r *LedgerRepo) GetJournalEntry(ctx context.Context, transactionID uuid.UUID) (*model.JournalEntry, error) {
+	var entry model.JournalEntry
+	err := r.journal.FindOne(ctx, bson.M{"transactionid": transactionID}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, apperrors.ErrJournalEntryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetTrialBalance sums every debit and credit posting in the journal per currency
+func (r *LedgerRepo) GetTrialBalance(ctx context.Context) ([]model.TrialBalance, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$unwind", Value: "$postings"}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "currency", Value: "$currency"},
+				{Key: "direction", Value: "$postings.direction"},
+			}},
+			{Key: "total", Value: bson.D{{Key: "$sum", Value: "$postings.amount"}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id.currency", Value: 1}}}},
+	}
+
+	cursor, err := r.journal.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []model.TrialBalance
+	byCurrency := map[string]int{}
+	for cursor.Next(ctx) {
+		var row struct {
+			ID struct {
+				Currency  string                   `bson:"currency"`
+				Direction constants.EntryDirection `bson:"direction"`
+			} `bson:"_id"`
+			Total int64 `bson:"total"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+
+		i, ok := byCurrency[row.ID.Currency]
+		if !ok {
+			i = len(results)
+			byCurrency[row.ID.Currency] = i
+			results = append(results, model.TrialBalance{Currency: row.ID.Currency})
+		}
+		switch row.ID.Direction {
+		case constants.Debit:
+			results[i].Debits = row.Total
+		case constants.Credit:
+			results[i].Credits = row.Total
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		results[i].Balanced = results[i].Debits == results[i].Credits
+	}
+	return results, nil
+}
+
+const duplicateKeyCode = 11000
+
+// ignoreDuplicates treats duplicate key errors as success so that re-inserting
+// the entries of an already recorded transaction is a no-op.
+func ignoreDuplicates(err error) error {
+	var bwe mongo.BulkWriteException
+	if errors.As(err, &bwe) {
+		for _, we := range bwe.WriteErrors {
+			if we.Code != duplicateKeyCode {
+				return err
+			}
+		}
+		if bwe.WriteConcernError == nil {
+			return nil
+		}
+		return err
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// GetBalanceAsOf sums the signed amounts of the account's ledger entries written
+// up to asOf, starting from the nearest stored snapshot at or before asOf.
+// Credits count positive and debits negative; legacy entries without a
+// direction are signed by their type.
+func (r *LedgerRepo) GetBalanceAsOf(ctx context.Context, accountID uuid.UUID, asOf time.Time) (*model.BalanceSnapshot, error) {
+	// Mongo stores milliseconds
+	asOf = asOf.UTC().Truncate(time.Millisecond)
+
+	base := model.BalanceSnapshot{AccountID: accountID}
+	err := r.snapshots.FindOne(ctx,
+		bson.M{"accountid": accountID, "asof": bson.M{"$lte": asOf}},
+		options.FindOne().SetSort(bson.D{{Key: "asof", Value: -1}}),
+	).Decode(&base)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	createdAt := bson.M{"$lte": asOf}
+	if !base.AsOf.IsZero() {
+		createdAt["$gt"] = base.AsOf
+	}
+
+	debit := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "$eq", Value: bson.A{"$direction", constants.Debit}}},
+		bson.D{{Key: "$and", Value: bson.A{
+			bson.D{{Key: "$eq", Value: bson.A{bson.D{{Key: "$ifNull", Value: bson.A{"$direction", ""}}}, ""}}},
+			bson.D{{Key: "$eq", Value: bson.A{"$type", constants.Withdrawal}}},
+		}}},
+	}}}
+	signed := bson.D{{Key: "$cond", Value: bson.A{debit, bson.D{{Key: "$multiply", Value: bson.A{"$amount", -1}}}, "$amount"}}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"accountid": accountID, "createdat": createdAt}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: nil},
+			{Key: "balance", Value: bson.D{{Key: "$sum", Value: signed}}},
+			{Key: "entries", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}
+
+	cursor, err := r.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sum struct {
+		Balance int64 `bson:"balance"`
+		Entries int64 `bson:"entries"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&sum); err != nil {
+			return nil, err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return &model.BalanceSnapshot{
+		AccountID: accountID,
+		Currency:  base.Currency,
+		Balance:   base.Balance + sum.Balance,
+		AsOf:      asOf,
+		Entries:   sum.Entries,
+	}, nil
+}
+
+// SaveBalanceSnapshot stores snap for later GetBalanceAsOf queries. Only
+// snapshots of points in time no new entries can be written before are valid;
+// a snapshot already stored for the same instant is kept.
+func (r *LedgerRepo) SaveBalanceSnapshot(ctx context.Context, snap *model.BalanceSnapshot) error {
+	snap.CreatedAt = time.Now().UTC()
+	_, err := r.snapshots.InsertOne(ctx, snap)
+	return ignoreDuplicates(err)
+}
@@ -14,7 +14,7 @@ func NewMongoClient(cfg config.Config) (*mongo.Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
-	clientOpts := options.Client().ApplyURI(cfg.MongoURI)
+	clientOpts := options.Client().ApplyURI(cfg.MongoURI).SetMonitor(NewCommandMonitor())
 	client, err := mongo.Connect(ctx, clientOpts)
 	if err != nil {
 		return nil, err
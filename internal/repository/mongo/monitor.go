@@ -0,0 +1,65 @@
+package mongo
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/imranzahoor/banking-ledger/pkg/metrics"
+	"github.com/imranzahoor/banking-ledger/pkg/tracing"
+)
+
+// commandMonitor times every command the driver sends, which covers all the
+// repository calls, in the db_call_duration_seconds histogram and a client
+// span under the caller's.
+type commandMonitor struct {
+	inflight sync.Map // request ID -> *command, between started and finished
+}
+
+type command struct {
+	collection string
+	span       trace.Span
+}
+
+// NewCommandMonitor returns the monitor NewMongoClient installs on the client.
+func NewCommandMonitor() *event.CommandMonitor {
+	m := &commandMonitor{}
+	return &event.CommandMonitor{
+		Started:   m.started,
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) { m.finished(e.CommandFinishedEvent, "") },
+		Failed:    func(_ context.Context, e *event.CommandFailedEvent) { m.finished(e.CommandFinishedEvent, e.Failure) },
+	}
+}
+
+func (m *commandMonitor) started(ctx context.Context, e *event.CommandStartedEvent) {
+	// most commands name their collection as the value of the command itself
+	collection, _ := e.Command.Lookup(e.CommandName).StringValueOK()
+	_, span := tracing.Tracer().Start(ctx, "mongo "+e.CommandName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "mongodb"),
+			attribute.String("db.namespace", e.DatabaseName),
+			attribute.String("db.operation.name", e.CommandName),
+			attribute.String("db.collection.name", collection),
+		))
+	m.inflight.Store(e.RequestID, &command{collection: collection, span: span})
+}
+
+// finished ends the command's span; failure is the driver's error message, or
+// "" on success.
+func (m *commandMonitor) finished(e event.CommandFinishedEvent, failure string) {
+	v, ok := m.inflight.LoadAndDelete(e.RequestID)
+	if !ok {
+		return
+	}
+	cmd := v.(*command)
+	if failure != "" {
+		cmd.span.SetStatus(codes.Error, failure)
+	}
+	cmd.span.End()
+	metrics.ObserveDBCall("mongo", e.CommandName, cmd.collection, e.Duration, failure != "")
+}
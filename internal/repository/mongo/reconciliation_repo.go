@@ -0,0 +1,66 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	apperrors "github.com/imranzahoor/banking-ledger/pkg/errors"
+)
+
+type ReconciliationRepo struct {
+	coll *mongo.Collection
+}
+
+type ReconciliationRepository interface {
+	SaveReport(ctx context.Context, report *model.ReconciliationReport) error
+	GetReport(ctx context.Context, id uuid.UUID) (*model.ReconciliationReport, error)
+	ListReports(ctx context.Context, limit int64) ([]model.ReconciliationReport, error)
+}
+
+func NewReconciliationRepo(client *mongo.Client, dbName string) *ReconciliationRepo {
+	return &ReconciliationRepo{coll: client.Database(dbName).Collection("reconciliation_reports")}
+}
+
+// SaveReport inserts the report or replaces the stored copy as a run progresses
+func (r *ReconciliationRepo) SaveReport(ctx context.Context, report *model.ReconciliationReport) error {
+	_, err := r.coll.ReplaceOne(ctx, bson.M{"id": report.ID}, report, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *ReconciliationRepo) GetReport(ctx context.Context, id uuid.UUID) (*model.ReconciliationReport, error) {
+	var report model.ReconciliationReport
+	err := r.coll.FindOne(ctx, bson.M{"id": id}).Decode(&report)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, apperrors.ErrReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ListReports returns the most recent reports, newest first
+func (r *ReconciliationRepo) ListReports(ctx context.Context, limit int64) ([]model.ReconciliationReport, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "startedat", Value: -1}})
+	if limit > 0 {
+		findOptions.SetLimit(limit)
+	}
+
+	cursor, err := r.coll.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reports []model.ReconciliationReport
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
@@ -3,10 +3,13 @@ package postgres
 import (
 	"context"
 	"errors"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	apperrors "github.com/imranzahoor/banking-ledger/pkg/errors"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -16,17 +19,33 @@ type AccountRepo struct {
 }
 
 type AccountRepository interface {
-	CreateAccount(ctx context.Context, acc *model.Account) error
+	CreateAccount(ctx context.Context, acc *model.Account, newMessage func(*model.Transaction) (*model.OutboxMessage, error)) (*model.Transaction, error)
 	GetAccountByID(ctx context.Context, id string) (*model.Account, error)
-	UpdateBalance(ctx context.Context, accountID uuid.UUID, delta int64) error
+	ListAccounts(ctx context.Context, afterID uuid.UUID, limit int) ([]model.Account, error)
+	ApplyTransaction(ctx context.Context, txn *model.Transaction) (*model.ProcessedTransaction, error)
+	MarkLedgerWritten(ctx context.Context, transactionID uuid.UUID) error
+	ListPendingLedgerAccounts(ctx context.Context) ([]uuid.UUID, error)
+	UpdateStatus(ctx context.Context, id uuid.UUID, from []constants.AccountStatus, to constants.AccountStatus) (*model.Account, error)
+	CloseAccount(ctx context.Context, id, payoutID uuid.UUID, newMessage func(*model.Transaction) (*model.OutboxMessage, error)) (*model.Account, *model.Transaction, error)
+	SetOverdraftLimit(ctx context.Context, id uuid.UUID, limit int64) (*model.Account, error)
+	SetOwner(ctx context.Context, id uuid.UUID, ownerID string) (*model.Account, error)
+	GetHold(ctx context.Context, id uuid.UUID) (*model.Hold, error)
+	ExpireHolds(ctx context.Context, now time.Time, limit int) (int, error)
 }
 
 func NewAccountRepo(db *gorm.DB) *AccountRepo {
 	return &AccountRepo{db: db}
 }
 
-// CreateAccount inserts new account with initial balance
-func (r *AccountRepo) CreateAccount(ctx context.Context, acc *model.Account) error {
+// CreateAccount inserts a new account. A positive opening balance is recorded
+// as a deposit applied in the same database transaction and enqueued through
+// newMessage, so the consumer journals it even if the ledger is unavailable
+// right now. The deposit is returned, or nil for a zero balance.
+func (r *AccountRepo) CreateAccount(
+	ctx context.Context,
+	acc *model.Account,
+	newMessage func(*model.Transaction) (*model.OutboxMessage, error),
+) (*model.Transaction, error) {
 	if acc.ID == uuid.Nil {
 		acc.ID = uuid.New()
 	}
@@ -34,7 +53,29 @@ func (r *AccountRepo) CreateAccount(ctx context.Context, acc *model.Account) err
 	acc.CreatedAt = time.Now().UTC()
 	acc.UpdatedAt = acc.CreatedAt
 
-	return r.db.WithContext(ctx).Create(acc).Error
+	var opening *model.Transaction
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(acc).Error; err != nil {
+			return err
+		}
+		if acc.Balance <= 0 {
+			return nil
+		}
+
+		opening = &model.Transaction{
+			ID:          uuid.New(),
+			AccountID:   acc.ID,
+			Type:        constants.Deposit,
+			Amount:      acc.Balance,
+			Currency:    acc.Currency,
+			Description: "opening balance",
+		}
+		return recordApplied(tx, opening, newMessage)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return opening, nil
 }
 
 // GetAccountByID fetches account by ID
@@ -50,24 +91,557 @@ func (r *AccountRepo) GetAccountByID(ctx context.Context, id string) (*model.Acc
 	return &acc, nil
 }
 
-// UpdateBalance atomically updates the balance by delta amount (positive or negative)
-// Returns error if balance would go negative.
-func (r *AccountRepo) UpdateBalance(ctx context.Context, accountID uuid.UUID, delta int64) error {
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		var acc model.Account
+// ListAccounts returns up to limit accounts ordered by ID, starting after
+// afterID; pass uuid.Nil for the first page.
+func (r *AccountRepo) ListAccounts(ctx context.Context, afterID uuid.UUID, limit int) ([]model.Account, error) {
+	var accs []model.Account
+	err := r.db.WithContext(ctx).
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&accs).Error
+	return accs, err
+}
+
+// ApplyTransaction applies the balance changes of txn, records it as processed
+// and sets its status in the same database transaction. If txn was processed
+// before, the balances are left untouched and the existing record is returned,
+// so a redelivered message is a no-op.
+//
+// When the change violates a business rule (e.g. insufficient funds) the
+// balances are rolled back but the transaction is still committed as processed
+// with failed status, and the rule violation is returned.
+func (r *AccountRepo) ApplyTransaction(ctx context.Context, txn *model.Transaction) (*model.ProcessedTransaction, error) {
+	var (
+		processed model.ProcessedTransaction
+		rejection error
+	)
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		processed = model.ProcessedTransaction{
+			TransactionID: txn.ID,
+			LedgerWritten: !txn.MovesFunds(),
+			ProcessedAt:   time.Now().UTC(),
+		}
+
+		// a concurrent duplicate blocks here until the first one commits
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&processed)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return tx.First(&processed, "transaction_id = ?", txn.ID).Error
+		}
 
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-			First(&acc, "id = ?", accountID.String()).Error; err != nil {
+		// nested transaction so a rejected change can be rolled back to a savepoint
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			return applyBalanceChanges(tx, txn)
+		})
+		if apperrors.IsRejection(err) {
+			rejection = err
+			// nothing to write to the ledger for a rejected transaction
+			processed.LedgerWritten = true
+			if err := tx.Save(&processed).Error; err != nil {
+				return err
+			}
+			return setStatus(tx, txn, constants.StatusFailed, err.Error())
+		}
+		if err != nil {
 			return err
 		}
 
-		newBalance := acc.Balance + delta
-		if newBalance < 0 {
-			return errors.New("insufficient funds")
+		return setStatus(tx, txn, constants.StatusCompleted, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rejection != nil {
+		return nil, rejection
+	}
+	return &processed, nil
+}
+
+// applyBalanceChanges locks the accounts touched by txn, checks that they hold
+// the transaction's currency and applies the balance deltas.
+func applyBalanceChanges(tx *gorm.DB, txn *model.Transaction) error {
+	ids := []uuid.UUID{txn.AccountID}
+	if txn.Type == constants.Transfer {
+		if txn.CounterpartyID == txn.AccountID {
+			return apperrors.ErrSameAccountTransfer
+		}
+		ids = append(ids, txn.CounterpartyID)
+	}
+
+	accs, err := lockAccounts(tx, ids...)
+	if err != nil {
+		return err
+	}
+
+	// legacy messages carry no currency and are applied as-is, but even then a
+	// transfer never moves funds between currencies
+	if txn.Currency != "" {
+		for _, acc := range accs {
+			if acc.Currency != txn.Currency {
+				return apperrors.ErrCurrencyMismatch
+			}
+		}
+	}
+	if txn.Type == constants.Transfer && accs[txn.AccountID].Currency != accs[txn.CounterpartyID].Currency {
+		return apperrors.ErrCurrencyMismatch
+	}
+
+	switch txn.Type {
+	case constants.Deposit:
+		return credit(tx, accs[txn.AccountID], txn.Amount)
+	case constants.Withdrawal:
+		return debit(tx, accs[txn.AccountID], txn.Amount)
+	case constants.Transfer:
+		if err := debit(tx, accs[txn.AccountID], txn.Amount); err != nil {
+			return err
+		}
+		return credit(tx, accs[txn.CounterpartyID], txn.Amount)
+	case constants.Hold:
+		return placeHold(tx, accs[txn.AccountID], txn)
+	case constants.Capture:
+		return captureHold(tx, accs[txn.AccountID], txn)
+	case constants.Void:
+		return voidHold(tx, accs[txn.AccountID], txn)
+	default:
+		return apperrors.ErrInvalidTransactionType
+	}
+}
+
+func debit(tx *gorm.DB, acc *model.Account, amount int64) error {
+	if err := acc.CanDebit(); err != nil {
+		return err
+	}
+	return applyDelta(tx, acc, -amount)
+}
+
+func credit(tx *gorm.DB, acc *model.Account, amount int64) error {
+	if err := acc.CanCredit(); err != nil {
+		return err
+	}
+	return applyDelta(tx, acc, amount)
+}
+
+// UpdateStatus moves an account to status if its current status is one of from
+func (r *AccountRepo) UpdateStatus(ctx context.Context, id uuid.UUID, from []constants.AccountStatus, to constants.AccountStatus) (*model.Account, error) {
+	var acc *model.Account
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		accs, err := lockAccounts(tx, id)
+		if err != nil {
+			return err
 		}
+		acc = accs[id]
 
-		acc.Balance = newBalance
+		if !slices.Contains(from, acc.Status) {
+			return apperrors.ErrInvalidStatusChange
+		}
+
+		acc.Status = to
+		acc.UpdatedAt = time.Now().UTC()
+		return tx.Save(acc).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+// CloseAccount closes an account. A non-zero balance is paid out to payoutID
+// by a transfer applied in the same database transaction; the transfer is
+// recorded as processed and enqueued through newMessage so the consumer
+// writes its ledger entries. Pass uuid.Nil as payoutID to require a zero balance.
+func (r *AccountRepo) CloseAccount(
+	ctx context.Context,
+	id, payoutID uuid.UUID,
+	newMessage func(*model.Transaction) (*model.OutboxMessage, error),
+) (*model.Account, *model.Transaction, error) {
+	if payoutID == id {
+		return nil, nil, apperrors.ErrSameAccountTransfer
+	}
+
+	var (
+		acc    *model.Account
+		payout *model.Transaction
+	)
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids := []uuid.UUID{id}
+		if payoutID != uuid.Nil {
+			ids = append(ids, payoutID)
+		}
+
+		accs, err := lockAccounts(tx, ids...)
+		if err != nil {
+			return err
+		}
+		acc = accs[id]
+
+		if acc.Status == constants.AccountClosed {
+			return apperrors.ErrInvalidStatusChange
+		}
+		if acc.HeldBalance > 0 {
+			return apperrors.ErrActiveHolds
+		}
+		if acc.Balance < 0 || (acc.Balance > 0 && payoutID == uuid.Nil) {
+			return apperrors.ErrAccountNotEmpty
+		}
+
+		if acc.Balance > 0 {
+			payout = &model.Transaction{
+				ID:             uuid.New(),
+				AccountID:      id,
+				CounterpartyID: payoutID,
+				Type:           constants.Transfer,
+				Amount:         acc.Balance,
+				Currency:       acc.Currency,
+				Description:    "account closure payout",
+			}
+			if err := applyPayout(tx, acc, accs[payoutID], payout, newMessage); err != nil {
+				return err
+			}
+		}
+
+		acc.Status = constants.AccountClosed
 		acc.UpdatedAt = time.Now().UTC()
-		return tx.Save(&acc).Error
+		return tx.Save(acc).Error
 	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return acc, payout, nil
+}
+
+// applyPayout moves the closing balance of acc to dest and enqueues the payout
+// so the consumer records it in the ledger. A frozen account may still be
+// paid out on closure.
+func applyPayout(
+	tx *gorm.DB,
+	acc, dest *model.Account,
+	payout *model.Transaction,
+	newMessage func(*model.Transaction) (*model.OutboxMessage, error),
+) error {
+	if dest.Currency != acc.Currency {
+		return apperrors.ErrCurrencyMismatch
+	}
+	if err := applyDelta(tx, acc, -payout.Amount); err != nil {
+		return err
+	}
+	if err := credit(tx, dest, payout.Amount); err != nil {
+		return err
+	}
+	return recordApplied(tx, payout, newMessage)
+}
+
+// recordApplied records txn, whose balance changes were applied directly
+// rather than by the consumer, as completed and processed, and enqueues it
+// through newMessage. The consumer then finds it processed and only writes
+// its ledger entries.
+func recordApplied(
+	tx *gorm.DB,
+	txn *model.Transaction,
+	newMessage func(*model.Transaction) (*model.OutboxMessage, error),
+) error {
+	if err := tx.Create(&model.ProcessedTransaction{
+		TransactionID: txn.ID,
+		ProcessedAt:   time.Now().UTC(),
+	}).Error; err != nil {
+		return err
+	}
+	if err := setStatus(tx, txn, constants.StatusCompleted, ""); err != nil {
+		return err
+	}
+
+	msg, err := newMessage(txn)
+	if err != nil {
+		return err
+	}
+	return enqueueOutbox(tx, msg)
+}
+
+// SetOverdraftLimit changes how far the account may be overdrawn. Lowering the
+// limit below what is already drawn is allowed; it only blocks further debits.
+func (r *AccountRepo) SetOverdraftLimit(ctx context.Context, id uuid.UUID, limit int64) (*model.Account, error) {
+	var acc *model.Account
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		accs, err := lockAccounts(tx, id)
+		if err != nil {
+			return err
+		}
+		acc = accs[id]
+
+		if acc.Status == constants.AccountClosed {
+			return apperrors.ErrAccountClosed
+		}
+
+		acc.OverdraftLimit = limit
+		acc.UpdatedAt = time.Now().UTC()
+		return tx.Save(acc).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+// SetOwner changes which customer may use the account; an empty ownerID leaves
+// it to staff roles only.
+func (r *AccountRepo) SetOwner(ctx context.Context, id uuid.UUID, ownerID string) (*model.Account, error) {
+	var acc *model.Account
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		accs, err := lockAccounts(tx, id)
+		if err != nil {
+			return err
+		}
+		acc = accs[id]
+
+		if acc.Status == constants.AccountClosed {
+			return apperrors.ErrAccountClosed
+		}
+
+		acc.OwnerID = ownerID
+		acc.UpdatedAt = time.Now().UTC()
+		return tx.Save(acc).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+// GetHold returns the hold with the given ID, or nil if there is none.
+func (r *AccountRepo) GetHold(ctx context.Context, id uuid.UUID) (*model.Hold, error) {
+	var hold model.Hold
+	err := r.db.WithContext(ctx).First(&hold, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// ExpireHolds releases up to limit active holds that lapsed before now and
+// returns how many it released. Each hold is released in its own transaction,
+// locking the account before the hold as captures and voids do.
+func (r *AccountRepo) ExpireHolds(ctx context.Context, now time.Time, limit int) (int, error) {
+	var due []model.Hold
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at <= ?", constants.HoldActive, now).
+		Order("expires_at").
+		Limit(limit).
+		Find(&due).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, h := range due {
+		released := false
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			accs, err := lockAccounts(tx, h.AccountID)
+			if err != nil {
+				return err
+			}
+
+			var hold model.Hold
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				First(&hold, "id = ?", h.ID).Error; err != nil {
+				return err
+			}
+			// captured or voided since it was selected
+			if hold.Status != constants.HoldActive {
+				return nil
+			}
+
+			released = true
+			return releaseHold(tx, accs[h.AccountID], &hold, constants.HoldExpired)
+		})
+		if err != nil {
+			return expired, err
+		}
+		if released {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// MarkLedgerWritten records that the ledger entries of a processed transaction are stored
+func (r *AccountRepo) MarkLedgerWritten(ctx context.Context, transactionID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Model(&model.ProcessedTransaction{}).
+		Where("transaction_id = ?", transactionID).
+		Update("ledger_written", true).Error
+}
+
+// ListPendingLedgerAccounts returns the accounts touched by processed
+// transactions whose ledger entries are not written yet, so their balances
+// are ahead of the ledger.
+func (r *AccountRepo) ListPendingLedgerAccounts(ctx context.Context) ([]uuid.UUID, error) {
+	var rows []struct {
+		AccountID      uuid.UUID
+		CounterpartyID uuid.UUID
+	}
+	if err := r.db.WithContext(ctx).
+		Table("processed_transactions AS p").
+		Select("t.account_id, t.counterparty_id").
+		Joins("JOIN transactions AS t ON t.id = p.transaction_id").
+		Where("p.ledger_written = ?", false).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var ids []uuid.UUID
+	for _, row := range rows {
+		for _, id := range []uuid.UUID{row.AccountID, row.CounterpartyID} {
+			if id != uuid.Nil && !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// lockAccounts selects the given accounts FOR UPDATE. Rows are locked in ID
+// order so concurrent transactions touching the same accounts cannot deadlock.
+func lockAccounts(tx *gorm.DB, ids ...uuid.UUID) (map[uuid.UUID]*model.Account, error) {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = id.String()
+	}
+
+	var accs []model.Account
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", keys).
+		Order("id").
+		Find(&accs).Error; err != nil {
+		return nil, err
+	}
+	if len(accs) != len(ids) {
+		return nil, apperrors.ErrAccountNotFound
+	}
+
+	byID := make(map[uuid.UUID]*model.Account, len(accs))
+	for i := range accs {
+		byID[accs[i].ID] = &accs[i]
+	}
+	return byID, nil
+}
+
+// placeHold reserves txn.Amount of the available balance under a hold that
+// shares the transaction's ID.
+func placeHold(tx *gorm.DB, acc *model.Account, txn *model.Transaction) error {
+	if err := acc.CanDebit(); err != nil {
+		return err
+	}
+	if acc.AvailableBalance() < txn.Amount {
+		return apperrors.ErrInsufficientFunds
+	}
+
+	now := time.Now().UTC()
+	if txn.ExpiresAt == nil || !txn.ExpiresAt.After(now) {
+		return apperrors.ErrHoldExpired
+	}
+
+	if err := tx.Create(&model.Hold{
+		ID:          txn.ID,
+		AccountID:   acc.ID,
+		Amount:      txn.Amount,
+		Currency:    acc.Currency,
+		Status:      constants.HoldActive,
+		Description: txn.Description,
+		ExpiresAt:   *txn.ExpiresAt,
+	}).Error; err != nil {
+		return err
+	}
+
+	acc.HeldBalance += txn.Amount
+	acc.UpdatedAt = now
+	return tx.Save(acc).Error
+}
+
+// captureHold debits txn.Amount, at most the held amount, and releases the
+// whole hold; any uncaptured remainder becomes available again.
+func captureHold(tx *gorm.DB, acc *model.Account, txn *model.Transaction) error {
+	if err := acc.CanDebit(); err != nil {
+		return err
+	}
+
+	hold, err := lockHold(tx, txn)
+	if err != nil {
+		return err
+	}
+	if !hold.ExpiresAt.After(time.Now().UTC()) {
+		return apperrors.ErrHoldExpired
+	}
+	if txn.Amount > hold.Amount {
+		return apperrors.ErrCaptureExceedsHold
+	}
+
+	hold.Captured = txn.Amount
+	if err := releaseHold(tx, acc, hold, constants.HoldCaptured); err != nil {
+		return err
+	}
+	return applyDelta(tx, acc, -txn.Amount)
+}
+
+// voidHold releases a hold without moving funds. Frozen accounts may still be
+// voided against since it only increases what is available.
+func voidHold(tx *gorm.DB, acc *model.Account, txn *model.Transaction) error {
+	hold, err := lockHold(tx, txn)
+	if err != nil {
+		return err
+	}
+	return releaseHold(tx, acc, hold, constants.HoldVoided)
+}
+
+func lockHold(tx *gorm.DB, txn *model.Transaction) (*model.Hold, error) {
+	var hold model.Hold
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&hold, "id = ? AND account_id = ?", txn.HoldID, txn.AccountID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.ErrHoldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if hold.Status != constants.HoldActive {
+		return nil, apperrors.ErrHoldNotActive
+	}
+	return &hold, nil
+}
+
+func releaseHold(tx *gorm.DB, acc *model.Account, hold *model.Hold, status constants.HoldStatus) error {
+	now := time.Now().UTC()
+	hold.Status = status
+	hold.UpdatedAt = now
+	if err := tx.Save(hold).Error; err != nil {
+		return err
+	}
+
+	acc.HeldBalance -= hold.Amount
+	acc.UpdatedAt = now
+	return tx.Save(acc).Error
+}
+
+// applyDelta adds delta to a locked account row, rejecting a debit that would
+// leave less than what active holds reserve within the overdraft limit.
+// Credits are always accepted, so an account drawn beyond a lowered limit can
+// still be paid back into.
+func applyDelta(tx *gorm.DB, acc *model.Account, delta int64) error {
+	newBalance := acc.Balance + delta
+	// funds reserved by holds cannot be spent
+	if delta < 0 && newBalance+acc.OverdraftLimit < acc.HeldBalance {
+		return apperrors.ErrInsufficientFunds
+	}
+
+	acc.Balance = newBalance
+	acc.UpdatedAt = time.Now().UTC()
+	return tx.Save(acc).Error
 }
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	apperrors "github.com/imranzahoor/banking-ledger/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepo struct {
+	db *gorm.DB
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *model.APIKey) error
+	GetByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) (*model.APIKey, error)
+}
+
+func NewAPIKeyRepo(db *gorm.DB) *APIKeyRepo {
+	return &APIKeyRepo{db: db}
+}
+
+func (r *APIKeyRepo) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	if key.ID == uuid.Nil {
+		key.ID = uuid.New()
+	}
+	key.CreatedAt = time.Now().UTC()
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// GetByHash fetches the key with the given hash, or nil if there is none
+func (r *APIKeyRepo) GetByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).First(&key, "key_hash = ?", hash).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListAPIKeys returns every key, revoked ones included, newest first
+func (r *APIKeyRepo) ListAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := r.db.WithContext(ctx).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// RevokeAPIKey marks the key revoked. Revoking an already revoked key keeps
+// the original revocation time.
+func (r *APIKeyRepo) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&key, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperrors.ErrAPIKeyNotFound
+			}
+			return err
+		}
+		if !key.Active() {
+			return nil
+		}
+
+		now := time.Now().UTC()
+		key.RevokedAt = &now
+		return tx.Model(&key).Update("revoked_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"gorm.io/gorm"
+)
+
+type IdempotencyRepo struct {
+	db *gorm.DB
+}
+
+type IdempotencyRepository interface {
+	GetByKey(ctx context.Context, scope, key string) (*model.IdempotencyKey, error)
+}
+
+func NewIdempotencyRepo(db *gorm.DB) *IdempotencyRepo {
+	return &IdempotencyRepo{db: db}
+}
+
+// GetByKey fetches a key stored in scope, or nil if it does not exist
+func (r *IdempotencyRepo) GetByKey(ctx context.Context, scope, key string) (*model.IdempotencyKey, error) {
+	var rec model.IdempotencyKey
+	err := r.db.WithContext(ctx).First(&rec, "scope = ? AND key = ?", scope, key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
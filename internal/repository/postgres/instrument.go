@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"github.com/imranzahoor/banking-ledger/pkg/metrics"
+	"github.com/imranzahoor/banking-ledger/pkg/tracing"
+)
+
+const (
+	callStartKey = "instrument:start"
+	callSpanKey  = "instrument:span"
+)
+
+// Instrument times every statement GORM runs, and so every repository call,
+// in the db_call_duration_seconds histogram and a client span. A missing
+// record is not counted as a failure since the repositories expect it.
+func Instrument(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("instrument:before_create", startCall("create")),
+		cb.Create().After("gorm:create").Register("instrument:after_create", finishCall("create")),
+		cb.Query().Before("gorm:query").Register("instrument:before_query", startCall("query")),
+		cb.Query().After("gorm:query").Register("instrument:after_query", finishCall("query")),
+		cb.Update().Before("gorm:update").Register("instrument:before_update", startCall("update")),
+		cb.Update().After("gorm:update").Register("instrument:after_update", finishCall("update")),
+		cb.Delete().Before("gorm:delete").Register("instrument:before_delete", startCall("delete")),
+		cb.Delete().After("gorm:delete").Register("instrument:after_delete", finishCall("delete")),
+		cb.Row().Before("gorm:row").Register("instrument:before_row", startCall("row")),
+		cb.Row().After("gorm:row").Register("instrument:after_row", finishCall("row")),
+		cb.Raw().Before("gorm:raw").Register("instrument:before_raw", startCall("raw")),
+		cb.Raw().After("gorm:raw").Register("instrument:after_raw", finishCall("raw")),
+	)
+}
+
+func startCall(operation string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		ctx, span := tracing.Tracer().Start(tx.Statement.Context, "postgres "+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system.name", "postgresql"),
+				attribute.String("db.operation.name", operation),
+				attribute.String("db.collection.name", tx.Statement.Table),
+			))
+		tx.Statement.Context = ctx
+		tx.InstanceSet(callSpanKey, span)
+		tx.InstanceSet(callStartKey, time.Now())
+	}
+}
+
+func finishCall(operation string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		failed := tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound)
+
+		if v, ok := tx.InstanceGet(callSpanKey); ok {
+			span := v.(trace.Span)
+			span.SetAttributes(attribute.String("db.query.text", tx.Statement.SQL.String()))
+			if failed {
+				span.RecordError(tx.Error)
+				span.SetStatus(codes.Error, "query failed")
+			}
+			span.End()
+		}
+		if v, ok := tx.InstanceGet(callStartKey); ok {
+			metrics.ObserveDBCall("postgres", operation, tx.Statement.Table, time.Since(v.(time.Time)), failed)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepo struct {
+	db *gorm.DB
+}
+
+type OutboxRepository interface {
+	Enqueue(ctx context.Context, msg *model.OutboxMessage) error
+	PublishPending(ctx context.Context, limit int, publish func([]model.OutboxMessage) error, retryAfter func(attempts int) time.Duration) (int, error)
+}
+
+func NewOutboxRepo(db *gorm.DB) *OutboxRepo {
+	return &OutboxRepo{db: db}
+}
+
+// Enqueue stores a message to be published by the relay
+func (r *OutboxRepo) Enqueue(ctx context.Context, msg *model.OutboxMessage) error {
+	return enqueueOutbox(r.db.WithContext(ctx), msg)
+}
+
+// enqueueOutbox inserts msg using tx, so callers can enqueue as part of a
+// larger database transaction.
+func enqueueOutbox(tx *gorm.DB, msg *model.OutboxMessage) error {
+	if msg.ID == uuid.Nil {
+		msg.ID = uuid.New()
+	}
+
+	msg.CreatedAt = time.Now().UTC()
+	msg.NextAttemptAt = msg.CreatedAt
+
+	return tx.Create(msg).Error
+}
+
+// PublishPending locks up to limit due messages, skipping rows held by other
+// relays, and hands them to publish in creation order. On success the messages
+// are marked sent; on failure their attempt count is bumped and they are
+// rescheduled after retryAfter(attempts). Returns the number of messages sent.
+func (r *OutboxRepo) PublishPending(
+	ctx context.Context,
+	limit int,
+	publish func([]model.OutboxMessage) error,
+	retryAfter func(attempts int) time.Duration,
+) (int, error) {
+	sent := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var msgs []model.OutboxMessage
+
+		now := time.Now().UTC()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("sent_at IS NULL AND next_attempt_at <= ?", now).
+			Order("created_at").
+			Limit(limit).
+			Find(&msgs).Error; err != nil {
+			return err
+		}
+		if len(msgs) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(msgs))
+		for i, m := range msgs {
+			ids[i] = m.ID
+		}
+
+		if pubErr := publish(msgs); pubErr != nil {
+			for _, m := range msgs {
+				attempts := m.Attempts + 1
+				if err := tx.Model(&model.OutboxMessage{}).
+					Where("id = ?", m.ID).
+					Updates(map[string]interface{}{
+						"attempts":        attempts,
+						"last_error":      pubErr.Error(),
+						"next_attempt_at": now.Add(retryAfter(attempts)),
+					}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		sent = len(msgs)
+		return tx.Model(&model.OutboxMessage{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{"sent_at": now, "last_error": ""}).Error
+	})
+	return sent, err
+}
@@ -2,7 +2,7 @@ package postgres
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/imranzahoor/banking-ledger/internal/model"
 	"github.com/imranzahoor/banking-ledger/pkg/config"
@@ -24,12 +24,15 @@ func NewPostgresDB(cfg config.Config) (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := Instrument(db); err != nil {
+		return nil, err
+	}
 
-	err = db.AutoMigrate(&model.Account{}, &model.Transaction{})
+	err = db.AutoMigrate(&model.Account{}, &model.Transaction{}, &model.IdempotencyKey{}, &model.OutboxMessage{}, &model.ProcessedTransaction{}, &model.Hold{}, &model.Schedule{}, &model.ScheduleExecution{}, &model.APIKey{})
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println("Postgres connected and migrations applied.")
+	slog.Info("Postgres connected and migrations applied")
 	return db, nil
 }
@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	apperrors "github.com/imranzahoor/banking-ledger/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ScheduleRepo struct {
+	db *gorm.DB
+}
+
+type ScheduleRepository interface {
+	CreateSchedule(ctx context.Context, s *model.Schedule) error
+	GetSchedule(ctx context.Context, id uuid.UUID) (*model.Schedule, error)
+	ListDue(ctx context.Context, now time.Time, limit int) ([]model.Schedule, error)
+	UpdateStatus(ctx context.Context, id uuid.UUID, from []constants.ScheduleStatus, to constants.ScheduleStatus, nextRunAt *time.Time) (*model.Schedule, error)
+	RecordExecution(ctx context.Context, exec *model.ScheduleExecution, nextRunAt *time.Time) error
+	ListExecutions(ctx context.Context, scheduleID uuid.UUID, limit int) ([]model.ScheduleExecution, error)
+}
+
+func NewScheduleRepo(db *gorm.DB) *ScheduleRepo {
+	return &ScheduleRepo{db: db}
+}
+
+func (r *ScheduleRepo) CreateSchedule(ctx context.Context, s *model.Schedule) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return r.db.WithContext(ctx).Create(s).Error
+}
+
+// GetSchedule fetches a schedule, or nil if it does not exist
+func (r *ScheduleRepo) GetSchedule(ctx context.Context, id uuid.UUID) (*model.Schedule, error) {
+	var s model.Schedule
+	err := r.db.WithContext(ctx).First(&s, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListDue returns up to limit active schedules whose next run is at or before
+// now, oldest first.
+func (r *ScheduleRepo) ListDue(ctx context.Context, now time.Time, limit int) ([]model.Schedule, error) {
+	var due []model.Schedule
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_run_at <= ?", constants.ScheduleActive, now).
+		Order("next_run_at").
+		Limit(limit).
+		Find(&due).Error
+	return due, err
+}
+
+// UpdateStatus moves a schedule to status to if it is currently in one of the
+// from statuses, also setting its next run when nextRunAt is given.
+func (r *ScheduleRepo) UpdateStatus(
+	ctx context.Context,
+	id uuid.UUID,
+	from []constants.ScheduleStatus,
+	to constants.ScheduleStatus,
+	nextRunAt *time.Time,
+) (*model.Schedule, error) {
+	var s model.Schedule
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&s, "id = ?", id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrScheduleNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if !slices.Contains(from, s.Status) {
+			return apperrors.ErrInvalidStatusChange
+		}
+
+		s.Status = to
+		if nextRunAt != nil {
+			s.NextRunAt = nextRunAt
+		}
+		s.UpdatedAt = time.Now().UTC()
+		return tx.Save(&s).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// RecordExecution stores the outcome of the occurrence due at exec.DueAt and
+// advances the schedule to nextRunAt, completing it when there is none. An
+// occurrence already recorded by another scheduler is left untouched, and the
+// schedule only advances if it has not moved on since it was listed as due.
+func (r *ScheduleRepo) RecordExecution(ctx context.Context, exec *model.ScheduleExecution, nextRunAt *time.Time) error {
+	exec.CreatedAt = time.Now().UTC()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(exec).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]any{
+			"next_run_at": nextRunAt,
+			"last_run_at": exec.DueAt,
+			"updated_at":  exec.CreatedAt,
+		}
+		if nextRunAt == nil {
+			updates["status"] = constants.ScheduleCompleted
+		}
+
+		return tx.Model(&model.Schedule{}).
+			Where("id = ? AND status = ? AND next_run_at = ?", exec.ScheduleID, constants.ScheduleActive, exec.DueAt).
+			Updates(updates).Error
+	})
+}
+
+// ListExecutions returns the most recent executions of a schedule, newest first.
+func (r *ScheduleRepo) ListExecutions(ctx context.Context, scheduleID uuid.UUID, limit int) ([]model.ScheduleExecution, error) {
+	var execs []model.ScheduleExecution
+	err := r.db.WithContext(ctx).
+		Where("schedule_id = ?", scheduleID).
+		Order("due_at DESC").
+		Limit(limit).
+		Find(&execs).Error
+	return execs, err
+}
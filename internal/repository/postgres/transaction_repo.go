@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	apperrors "github.com/imranzahoor/banking-ledger/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TransactionRepo struct {
+	db *gorm.DB
+}
+
+type TransactionRepository interface {
+	CreatePending(ctx context.Context, txn *model.Transaction, msg *model.OutboxMessage) error
+	CreatePendingWithKey(ctx context.Context, txn *model.Transaction, msg *model.OutboxMessage, key *model.IdempotencyKey) (bool, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Transaction, error)
+	MarkFailed(ctx context.Context, txn *model.Transaction, reason string) error
+}
+
+func NewTransactionRepo(db *gorm.DB) *TransactionRepo {
+	return &TransactionRepo{db: db}
+}
+
+// CreatePending stores txn with pending status together with the outbox message
+// that will deliver it to the consumer, in one database transaction.
+func (r *TransactionRepo) CreatePending(ctx context.Context, txn *model.Transaction, msg *model.OutboxMessage) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createPending(tx, txn, msg)
+	})
+}
+
+// CreatePendingWithKey is CreatePending for a request carrying an
+// Idempotency-Key: the key is stored in the same database transaction, so it
+// only ever points at a transaction that exists. Returns false, storing
+// nothing, if another request claimed the key first.
+func (r *TransactionRepo) CreatePendingWithKey(
+	ctx context.Context,
+	txn *model.Transaction,
+	msg *model.OutboxMessage,
+	key *model.IdempotencyKey,
+) (bool, error) {
+	created := false
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		key.CreatedAt = time.Now().UTC()
+
+		// a concurrent request with the same key blocks here until the first commits
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		created = true
+		return createPending(tx, txn, msg)
+	})
+	if err != nil {
+		return false, err
+	}
+	return created, nil
+}
+
+func createPending(tx *gorm.DB, txn *model.Transaction, msg *model.OutboxMessage) error {
+	if txn.ID == uuid.Nil {
+		txn.ID = uuid.New()
+	}
+
+	txn.Status = constants.StatusPending
+	txn.CreatedAt = time.Now().UTC()
+	txn.UpdatedAt = txn.CreatedAt
+
+	if err := tx.Create(txn).Error; err != nil {
+		return err
+	}
+	return enqueueOutbox(tx, msg)
+}
+
+// GetByID fetches a transaction and its current status
+func (r *TransactionRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Transaction, error) {
+	var txn model.Transaction
+	err := r.db.WithContext(ctx).First(&txn, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &txn, nil
+}
+
+// MarkFailed records that txn could not be processed. Only a pending
+// transaction is marked: one whose balances were already applied keeps its
+// status, since only its ledger write is left to replay.
+func (r *TransactionRepo) MarkFailed(ctx context.Context, txn *model.Transaction, reason string) error {
+	return setStatus(r.db.WithContext(ctx), txn, constants.StatusFailed, reason, constants.StatusPending)
+}
+
+// setStatus upserts the status of txn so transactions enqueued before status
+// tracking existed are recorded as well. If from is given, an existing row is
+// only updated while its status is one of from.
+func setStatus(tx *gorm.DB, txn *model.Transaction, status constants.TransactionStatus, reason string, from ...constants.TransactionStatus) error {
+	row := *txn
+	row.Status = status
+	row.FailureReason = reason
+	row.UpdatedAt = time.Now().UTC()
+	if row.CreatedAt.IsZero() {
+		row.CreatedAt = row.UpdatedAt
+	}
+
+	upsert := clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "failure_reason", "updated_at"}),
+	}
+	if len(from) > 0 {
+		upsert.Where = clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "transactions.status IN ?", Vars: []any{from}},
+		}}
+	}
+	return tx.Clauses(upsert).Create(&row).Error
+}
@@ -5,23 +5,50 @@ import (
 	"errors"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/repository/mongo"
 	"github.com/imranzahoor/banking-ledger/internal/repository/postgres"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/currency"
+	apperrors "github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/pkg/metrics"
+	"github.com/imranzahoor/banking-ledger/pkg/statement"
 )
 
+// snapshotSettleDelay is how far in the past a balance must be before it is
+// stored as a snapshot. Ledger entries are stamped when they are written, so
+// older points in time can no longer change once in-flight writes complete.
+const snapshotSettleDelay = time.Minute
+
 type AccountServiceInterface interface {
-	CreateAccount(ctx context.Context, ownerName string, initialBalance int64) (*model.Account, error)
+	CreateAccount(ctx context.Context, ownerName, ownerID string, initialBalance int64, currencyCode string) (*model.Account, error)
 	GetAccountByID(ctx context.Context, accountID string) (*model.Account, error)
+	FreezeAccount(ctx context.Context, id uuid.UUID) (*model.Account, error)
+	UnfreezeAccount(ctx context.Context, id uuid.UUID) (*model.Account, error)
+	CloseAccount(ctx context.Context, id, payoutID uuid.UUID) (*model.Account, *model.Transaction, error)
+	SetOverdraftLimit(ctx context.Context, id uuid.UUID, limit int64) (*model.Account, error)
+	SetOwner(ctx context.Context, id uuid.UUID, ownerID string) (*model.Account, error)
+	GetBalanceAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (*model.BalanceSnapshot, error)
+	WriteStatement(ctx context.Context, id uuid.UUID, from, to time.Time, w statement.Writer) error
 }
 type AccountService struct {
 	accountRepo postgres.AccountRepository
+	ledgerRepo  mongo.LedgerRepository
+	topic       string
 }
 
-func NewAccountService(repo postgres.AccountRepository) *AccountService {
-	return &AccountService{accountRepo: repo}
+func NewAccountService(repo postgres.AccountRepository, lr mongo.LedgerRepository, cfg config.Config) *AccountService {
+	return &AccountService{accountRepo: repo, ledgerRepo: lr, topic: cfg.KafkaTopic}
 }
 
-func (s *AccountService) CreateAccount(ctx context.Context, ownerName string, initialBalance int64) (*model.Account, error) {
+// CreateAccount opens an account in the given ISO 4217 currency, defaulting to
+// currency.Default when none is given. ownerID is the subject of the customer
+// allowed to use the account, if any. A non-zero initial balance is journaled
+// by the consumer as a deposit from the cash account, so the ledger accounts
+// for every unit in the account.
+func (s *AccountService) CreateAccount(ctx context.Context, ownerName, ownerID string, initialBalance int64, currencyCode string) (*model.Account, error) {
 	if ownerName == "" {
 		return nil, errors.New("owner name required")
 	}
@@ -29,20 +56,175 @@ func (s *AccountService) CreateAccount(ctx context.Context, ownerName string, in
 		return nil, errors.New("initial balance cannot be negative")
 	}
 
+	currencyCode = currency.Normalize(currencyCode)
+	if currencyCode == "" {
+		currencyCode = currency.Default
+	}
+	if !currency.IsSupported(currencyCode) {
+		return nil, apperrors.ErrUnsupportedCurrency
+	}
+	if !model.ValidOwnerID(ownerID) {
+		return nil, apperrors.ErrInvalidOwnerID
+	}
+
 	acc := &model.Account{
 		OwnerName: ownerName,
+		OwnerID:   ownerID,
 		Balance:   initialBalance,
+		Currency:  currencyCode,
+		Status:    constants.AccountActive,
 		CreatedAt: time.Now().UTC(),
 		UpdatedAt: time.Now().UTC(),
 	}
 
-	err := s.accountRepo.CreateAccount(ctx, acc)
+	opening, err := s.accountRepo.CreateAccount(ctx, acc, s.newMessage(ctx))
 	if err != nil {
 		return nil, err
 	}
+	if opening != nil {
+		metrics.TransactionsEnqueued.WithLabelValues(string(opening.Type)).Inc()
+	}
 	return acc, nil
 }
 
+// newMessage returns the outbox message constructor for transactions the
+// account repository applies directly, carrying the request ID and trace
+// context of ctx.
+func (s *AccountService) newMessage(ctx context.Context) func(*model.Transaction) (*model.OutboxMessage, error) {
+	return func(txn *model.Transaction) (*model.OutboxMessage, error) {
+		return model.NewTransactionMessage(s.topic, txn, messageHeaders(ctx))
+	}
+}
+
 func (s *AccountService) GetAccountByID(ctx context.Context, accountID string) (*model.Account, error) {
 	return s.accountRepo.GetAccountByID(ctx, accountID)
 }
+
+// FreezeAccount blocks debits from an active account; credits are still accepted.
+func (s *AccountService) FreezeAccount(ctx context.Context, id uuid.UUID) (*model.Account, error) {
+	return s.accountRepo.UpdateStatus(ctx, id, []constants.AccountStatus{constants.AccountActive}, constants.AccountFrozen)
+}
+
+// UnfreezeAccount returns a frozen account to active.
+func (s *AccountService) UnfreezeAccount(ctx context.Context, id uuid.UUID) (*model.Account, error) {
+	return s.accountRepo.UpdateStatus(ctx, id, []constants.AccountStatus{constants.AccountFrozen}, constants.AccountActive)
+}
+
+// CloseAccount permanently closes an account. Any remaining balance is
+// transferred to payoutID, which may be uuid.Nil only when the balance is zero;
+// the payout transfer is returned so callers can report it.
+func (s *AccountService) CloseAccount(ctx context.Context, id, payoutID uuid.UUID) (*model.Account, *model.Transaction, error) {
+	acc, payout, err := s.accountRepo.CloseAccount(ctx, id, payoutID, s.newMessage(ctx))
+	if err == nil && payout != nil {
+		metrics.TransactionsEnqueued.WithLabelValues(string(payout.Type)).Inc()
+	}
+	return acc, payout, err
+}
+
+// SetOverdraftLimit approves an overdraft of up to limit minor units; zero
+// removes it.
+func (s *AccountService) SetOverdraftLimit(ctx context.Context, id uuid.UUID, limit int64) (*model.Account, error) {
+	if limit < 0 {
+		return nil, apperrors.ErrInvalidOverdraftLimit
+	}
+	return s.accountRepo.SetOverdraftLimit(ctx, id, limit)
+}
+
+// SetOwner hands the account to the customer whose principal is ownerID, or
+// to no customer when it is empty.
+func (s *AccountService) SetOwner(ctx context.Context, id uuid.UUID, ownerID string) (*model.Account, error) {
+	if !model.ValidOwnerID(ownerID) {
+		return nil, apperrors.ErrInvalidOwnerID
+	}
+	return s.accountRepo.SetOwner(ctx, id, ownerID)
+}
+
+// GetBalanceAsOf computes the account's balance at asOf from the ledger. The
+// result is stored as a snapshot when asOf is settled, so the next query for a
+// later time starts from it.
+func (s *AccountService) GetBalanceAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (*model.BalanceSnapshot, error) {
+	acc, err := s.accountRepo.GetAccountByID(ctx, id.String())
+	if err != nil {
+		return nil, err
+	}
+	if acc == nil {
+		return nil, apperrors.ErrAccountNotFound
+	}
+	return s.balanceAsOf(ctx, acc, asOf)
+}
+
+func (s *AccountService) balanceAsOf(ctx context.Context, acc *model.Account, asOf time.Time) (*model.BalanceSnapshot, error) {
+	snap, err := s.ledgerRepo.GetBalanceAsOf(ctx, acc.ID, asOf)
+	if err != nil {
+		return nil, err
+	}
+	snap.Currency = acc.Currency
+
+	if snap.Entries > 0 && snap.AsOf.Before(time.Now().Add(-snapshotSettleDelay)) {
+		// best effort: a missing snapshot only makes later queries sum more entries
+		_ = s.ledgerRepo.SaveBalanceSnapshot(ctx, snap)
+	}
+	return snap, nil
+}
+
+// WriteStatement writes the account's statement for the period after from up
+// to and including to. Opening and closing balances come from the ledger and
+// entries are streamed to w as they are read, with a running balance. Nothing
+// is written when the account or period is invalid.
+func (s *AccountService) WriteStatement(ctx context.Context, id uuid.UUID, from, to time.Time, w statement.Writer) error {
+	if !from.Before(to) {
+		return apperrors.ErrInvalidStatementPeriod
+	}
+
+	acc, err := s.accountRepo.GetAccountByID(ctx, id.String())
+	if err != nil {
+		return err
+	}
+	if acc == nil {
+		return apperrors.ErrAccountNotFound
+	}
+
+	opening, err := s.balanceAsOf(ctx, acc, from)
+	if err != nil {
+		return err
+	}
+	closing, err := s.balanceAsOf(ctx, acc, to)
+	if err != nil {
+		return err
+	}
+
+	err = w.Begin(statement.Header{
+		AccountID:   id.String(),
+		OwnerName:   acc.OwnerName,
+		Currency:    acc.Currency,
+		From:        opening.AsOf,
+		To:          closing.AsOf,
+		Opening:     opening.Balance,
+		Closing:     closing.Balance,
+		GeneratedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	balance := opening.Balance
+	err = s.ledgerRepo.StreamTransactionsByAccountID(ctx, id, from, to, func(txn *model.Transaction) error {
+		balance += txn.SignedAmount()
+		e := statement.Entry{
+			ID:          txn.ID.String(),
+			BookedAt:    txn.CreatedAt,
+			Type:        string(txn.Type),
+			Description: txn.Description,
+			Amount:      txn.SignedAmount(),
+			Balance:     balance,
+		}
+		if txn.CounterpartyID != uuid.Nil {
+			e.CounterpartyID = txn.CounterpartyID.String()
+		}
+		return w.Entry(e)
+	})
+	if err != nil {
+		return err
+	}
+	return w.End()
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/repository/postgres"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	apperrors "github.com/imranzahoor/banking-ledger/pkg/errors"
+)
+
+const (
+	// apiKeyPrefix marks issued keys so they are recognisable in logs and
+	// secret scanners.
+	apiKeyPrefix = "bl_"
+	apiKeyBytes  = 32
+	// apiKeyShownPrefix is how much of a key is kept in clear to tell keys apart.
+	apiKeyShownPrefix = len(apiKeyPrefix) + 8
+	// placeholderAdminKey is the sample admin key from the docs, which must
+	// never guard a running service.
+	placeholderAdminKey = "change-me"
+)
+
+// Authentication methods recorded on an Identity.
+const (
+	AuthMethodAPIKey   = "api_key"
+	AuthMethodJWT      = "jwt"
+	AuthMethodAdminKey = "admin_key"
+)
+
+type AuthServiceInterface interface {
+	CreateAPIKey(ctx context.Context, name string, role constants.Role) (*model.APIKey, string, error)
+	ListAPIKeys(ctx context.Context) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) (*model.APIKey, error)
+	AuthenticateAPIKey(ctx context.Context, key string) (*model.Identity, error)
+	AuthenticateToken(token string) (*model.Identity, error)
+}
+
+// AuthService issues API keys and verifies API keys and JWTs. JWTs are
+// accepted when an HMAC secret or an RSA public key is configured.
+type AuthService struct {
+	keyRepo      postgres.APIKeyRepository
+	adminKeyHash string
+	hmacSecret   []byte
+	rsaKey       *rsa.PublicKey
+	parser       *jwt.Parser
+}
+
+// NewAuthService fails when authentication is enabled without a real admin
+// key, so a deployment cannot start with an empty or sample bootstrap key.
+func NewAuthService(kr postgres.APIKeyRepository, cfg config.Config) (*AuthService, error) {
+	if cfg.AuthEnabled && (cfg.AdminAPIKey == "" || cfg.AdminAPIKey == placeholderAdminKey) {
+		return nil, fmt.Errorf("ADMIN_API_KEY must be set to a secret other than %q when authentication is enabled", placeholderAdminKey)
+	}
+
+	s := &AuthService{keyRepo: kr, hmacSecret: []byte(cfg.JWTHMACSecret)}
+	if cfg.AdminAPIKey != "" {
+		s.adminKeyHash = hashAPIKey(cfg.AdminAPIKey)
+	}
+
+	var methods []string
+	if len(s.hmacSecret) > 0 {
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+	if cfg.JWTRSAPublicKeyFile != "" {
+		pem, err := os.ReadFile(cfg.JWTRSAPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading JWT public key: %w", err)
+		}
+		if s.rsaKey, err = jwt.ParseRSAPublicKeyFromPEM(pem); err != nil {
+			return nil, fmt.Errorf("parsing JWT public key: %w", err)
+		}
+		methods = append(methods, "RS256", "RS384", "RS512")
+	}
+
+	if len(methods) == 0 {
+		// no keys configured: JWTs are not accepted
+		return s, nil
+	}
+	opts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+	if cfg.JWTIssuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	if cfg.JWTAudience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.JWTAudience))
+	}
+	s.parser = jwt.NewParser(opts...)
+	return s, nil
+}
+
+// CreateAPIKey issues a new key acting with role. The key is returned only
+// here; afterwards just its hash and prefix are known.
+func (s *AuthService) CreateAPIKey(ctx context.Context, name string, role constants.Role) (*model.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", apperrors.ErrInvalidInput
+	}
+	if !role.IsValid() {
+		return nil, "", apperrors.ErrInvalidRole
+	}
+
+	buf := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", err
+	}
+	secret := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	key := &model.APIKey{
+		Name:    name,
+		Role:    role,
+		Prefix:  secret[:apiKeyShownPrefix],
+		KeyHash: hashAPIKey(secret),
+	}
+	if err := s.keyRepo.CreateAPIKey(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, secret, nil
+}
+
+func (s *AuthService) ListAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	return s.keyRepo.ListAPIKeys(ctx)
+}
+
+// RevokeAPIKey stops a key from authenticating; requests already in flight
+// are not affected.
+func (s *AuthService) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*model.APIKey, error) {
+	return s.keyRepo.RevokeAPIKey(ctx, id)
+}
+
+// AuthenticateAPIKey resolves the caller presenting key, which is either the
+// configured admin key or an active issued key.
+func (s *AuthService) AuthenticateAPIKey(ctx context.Context, key string) (*model.Identity, error) {
+	hash := hashAPIKey(key)
+	if s.adminKeyHash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(s.adminKeyHash)) == 1 {
+		return &model.Identity{Subject: "admin", Method: AuthMethodAdminKey, Role: constants.RoleAdmin}, nil
+	}
+
+	rec, err := s.keyRepo.GetByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil || !rec.Active() {
+		return nil, apperrors.ErrUnauthenticated
+	}
+	return &model.Identity{
+		Subject:   rec.Name,
+		Method:    AuthMethodAPIKey,
+		Principal: model.APIKeyPrincipal + rec.Name,
+		Role:      rec.Role,
+		KeyID:     rec.ID,
+	}, nil
+}
+
+// tokenClaims are the JWT claims read by AuthenticateToken.
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Role constants.Role `json:"role"`
+}
+
+// AuthenticateToken verifies a signed JWT, which must carry a subject and an
+// expiry, and the configured issuer and audience if any. The role claim
+// defaults to customer.
+func (s *AuthService) AuthenticateToken(token string) (*model.Identity, error) {
+	if s.parser == nil {
+		return nil, apperrors.ErrUnauthenticated
+	}
+
+	var claims tokenClaims
+	_, err := s.parser.ParseWithClaims(token, &claims, s.verificationKey)
+	if err != nil || claims.Subject == "" {
+		return nil, apperrors.ErrUnauthenticated
+	}
+	if claims.Role == "" {
+		claims.Role = constants.RoleCustomer
+	}
+	if !claims.Role.IsValid() {
+		return nil, apperrors.ErrUnauthenticated
+	}
+	return &model.Identity{
+		Subject:   claims.Subject,
+		Method:    AuthMethodJWT,
+		Principal: model.JWTPrincipal + claims.Subject,
+		Role:      claims.Role,
+	}, nil
+}
+
+func (s *AuthService) verificationKey(t *jwt.Token) (interface{}, error) {
+	switch t.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return s.hmacSecret, nil
+	case *jwt.SigningMethodRSA:
+		return s.rsaKey, nil
+	default:
+		return nil, apperrors.ErrUnauthenticated
+	}
+}
+
+// hashAPIKey returns the hex SHA-256 of key. Keys are random, so a fast hash
+// is enough and lets them be looked up by hash.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/imranzahoor/banking-ledger/internal/repository/postgres"
+)
+
+// holdExpiryBatchSize bounds how many holds one sweep releases before
+// checking for more.
+const holdExpiryBatchSize = 100
+
+// HoldExpirer releases holds that lapsed without being captured or voided,
+// returning their amount to the account's available balance.
+type HoldExpirer struct {
+	accountRepo postgres.AccountRepository
+	interval    time.Duration
+}
+
+func NewHoldExpirer(ar postgres.AccountRepository, interval time.Duration) *HoldExpirer {
+	return &HoldExpirer{accountRepo: ar, interval: interval}
+}
+
+// Run sweeps for lapsed holds every interval until ctx is cancelled.
+func (e *HoldExpirer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		expired, err := e.accountRepo.ExpireHolds(ctx, time.Now().UTC(), holdExpiryBatchSize)
+		if err != nil {
+			slog.ErrorContext(ctx, "expiring holds", "error", err)
+		}
+		if expired == holdExpiryBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
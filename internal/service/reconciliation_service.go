@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/repository/mongo"
+	"github.com/imranzahoor/banking-ledger/internal/repository/postgres"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	apperrors "github.com/imranzahoor/banking-ledger/pkg/errors"
+)
+
+// reconcileBatchSize is how many accounts are read from Postgres at a time.
+const reconcileBatchSize = 500
+
+type ReconciliationServiceInterface interface {
+	Run(ctx context.Context, trigger constants.ReconciliationTrigger, repair bool) (*model.ReconciliationReport, error)
+	Start(ctx context.Context, trigger constants.ReconciliationTrigger, repair bool) (*model.ReconciliationReport, error)
+	GetReport(ctx context.Context, id uuid.UUID) (*model.ReconciliationReport, error)
+	ListReports(ctx context.Context, limit int64) ([]model.ReconciliationReport, error)
+}
+
+// ReconciliationService compares Postgres balances with the balances the Mongo
+// ledger adds up to. Only one run per instance executes at a time.
+type ReconciliationService struct {
+	accountRepo postgres.AccountRepository
+	ledgerRepo  mongo.LedgerRepository
+	reportRepo  mongo.ReconciliationRepository
+	gracePeriod time.Duration
+	running     sync.Mutex
+}
+
+func NewReconciliationService(
+	ar postgres.AccountRepository,
+	lr mongo.LedgerRepository,
+	rr mongo.ReconciliationRepository,
+	cfg config.Config,
+) *ReconciliationService {
+	return &ReconciliationService{
+		accountRepo: ar,
+		ledgerRepo:  lr,
+		reportRepo:  rr,
+		gracePeriod: cfg.ReconciliationGracePeriod,
+	}
+}
+
+// Run reconciles every account and returns the finished report. With repair,
+// each discrepancy is corrected with an adjustment entry in the ledger.
+func (s *ReconciliationService) Run(ctx context.Context, trigger constants.ReconciliationTrigger, repair bool) (*model.ReconciliationReport, error) {
+	report, err := s.begin(ctx, trigger, repair)
+	if err != nil {
+		return nil, err
+	}
+	defer s.running.Unlock()
+
+	s.reconcile(ctx, report)
+	return report, nil
+}
+
+// Start begins a run in the background and returns its report as saved at the
+// start; poll GetReport for the outcome.
+func (s *ReconciliationService) Start(ctx context.Context, trigger constants.ReconciliationTrigger, repair bool) (*model.ReconciliationReport, error) {
+	report, err := s.begin(ctx, trigger, repair)
+	if err != nil {
+		return nil, err
+	}
+
+	started := *report
+	go func() {
+		defer s.running.Unlock()
+		s.reconcile(context.WithoutCancel(ctx), report)
+	}()
+	return &started, nil
+}
+
+func (s *ReconciliationService) GetReport(ctx context.Context, id uuid.UUID) (*model.ReconciliationReport, error) {
+	return s.reportRepo.GetReport(ctx, id)
+}
+
+func (s *ReconciliationService) ListReports(ctx context.Context, limit int64) ([]model.ReconciliationReport, error) {
+	return s.reportRepo.ListReports(ctx, limit)
+}
+
+// begin takes the run lock and saves a running report. The caller releases
+// the lock once the run finishes.
+func (s *ReconciliationService) begin(ctx context.Context, trigger constants.ReconciliationTrigger, repair bool) (*model.ReconciliationReport, error) {
+	if !s.running.TryLock() {
+		return nil, apperrors.ErrReconciliationRunning
+	}
+
+	report := &model.ReconciliationReport{
+		ID:        uuid.New(),
+		Trigger:   trigger,
+		Repair:    repair,
+		Status:    constants.ReconciliationRunning,
+		StartedAt: time.Now().UTC(),
+	}
+	if err := s.reportRepo.SaveReport(ctx, report); err != nil {
+		s.running.Unlock()
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *ReconciliationService) reconcile(ctx context.Context, report *model.ReconciliationReport) {
+	err := s.checkAccounts(ctx, report)
+
+	now := time.Now().UTC()
+	report.FinishedAt = &now
+	report.Status = constants.ReconciliationCompleted
+	if err != nil {
+		report.Status = constants.ReconciliationFailed
+		report.Error = err.Error()
+	}
+
+	if err := s.reportRepo.SaveReport(ctx, report); err != nil {
+		slog.ErrorContext(ctx, "saving reconciliation report", "report_id", report.ID, "error", err)
+	}
+}
+
+func (s *ReconciliationService) checkAccounts(ctx context.Context, report *model.ReconciliationReport) error {
+	// accounts changed after this may have ledger entries still being written
+	settled := report.StartedAt.Add(-s.gracePeriod)
+
+	// read before the accounts, so a transaction processed in between shows
+	// up as a recent change instead
+	pending, err := s.accountRepo.ListPendingLedgerAccounts(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.eachAccount(ctx, func(acc *model.Account) error {
+		if acc.UpdatedAt.After(settled) {
+			report.AccountsSkipped++
+			return nil
+		}
+		// however old, a repair would count the unwritten entries twice
+		if slices.Contains(pending, acc.ID) {
+			report.PendingLedger = append(report.PendingLedger, acc.ID)
+			return nil
+		}
+		report.AccountsChecked++
+		return s.check(ctx, report, acc)
+	})
+}
+
+func (s *ReconciliationService) eachAccount(ctx context.Context, fn func(*model.Account) error) error {
+	after := uuid.Nil
+	for {
+		accs, err := s.accountRepo.ListAccounts(ctx, after, reconcileBatchSize)
+		if err != nil {
+			return err
+		}
+		for i := range accs {
+			if err := fn(&accs[i]); err != nil {
+				return err
+			}
+		}
+		if len(accs) < reconcileBatchSize {
+			return nil
+		}
+		after = accs[len(accs)-1].ID
+	}
+}
+
+func (s *ReconciliationService) check(ctx context.Context, report *model.ReconciliationReport, acc *model.Account) error {
+	ledger, err := s.ledgerRepo.GetBalanceAsOf(ctx, acc.ID, report.StartedAt)
+	if err != nil {
+		return err
+	}
+
+	diff := acc.Balance - ledger.Balance
+	if diff == 0 {
+		return nil
+	}
+
+	d := model.Discrepancy{
+		AccountID:      acc.ID,
+		Currency:       acc.Currency,
+		AccountBalance: acc.Balance,
+		LedgerBalance:  ledger.Balance,
+		Difference:     diff,
+	}
+	if report.Repair {
+		d.CorrectionID, err = s.correct(ctx, acc, diff)
+		if err != nil {
+			d.RepairError = err.Error()
+		}
+	}
+	report.Discrepancies = append(report.Discrepancies, d)
+	return nil
+}
+
+// correct writes an adjustment against the clearing account that brings the
+// account's ledger in line with its balance.
+func (s *ReconciliationService) correct(ctx context.Context, acc *model.Account, diff int64) (uuid.UUID, error) {
+	adj := &model.Transaction{
+		ID:             uuid.New(),
+		AccountID:      acc.ID,
+		CounterpartyID: constants.ClearingAccountID,
+		Type:           constants.Adjustment,
+		Direction:      constants.Credit,
+		Amount:         diff,
+		Currency:       acc.Currency,
+		Description:    "reconciliation correction",
+	}
+	if diff < 0 {
+		adj.Direction = constants.Debit
+		adj.Amount = -diff
+	}
+
+	if err := s.ledgerRepo.InsertJournalEntry(ctx, adj.JournalEntry()); err != nil {
+		return uuid.Nil, err
+	}
+	if err := s.ledgerRepo.InsertTransactions(ctx, adj.LedgerEntries()); err != nil {
+		return uuid.Nil, err
+	}
+	return adj.ID, nil
+}
+
+// Reconciler runs reconciliation periodically in the background.
+type Reconciler struct {
+	reconciliations ReconciliationServiceInterface
+	interval        time.Duration
+	repair          bool
+}
+
+func NewReconciler(rs ReconciliationServiceInterface, interval time.Duration, repair bool) *Reconciler {
+	return &Reconciler{reconciliations: rs, interval: interval, repair: repair}
+}
+
+// Run reconciles every interval until ctx is cancelled.
+func (r *Reconciler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		report, err := r.reconciliations.Run(ctx, constants.TriggerScheduled, r.repair)
+		if err != nil {
+			slog.ErrorContext(ctx, "reconciliation run failed", "error", err)
+			continue
+		}
+		slog.InfoContext(ctx, "reconciliation run finished", "report_id", report.ID, "status", report.Status,
+			"accounts_checked", report.AccountsChecked, "discrepancies", len(report.Discrepancies))
+	}
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/repository/postgres"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	apperrors "github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/pkg/logger"
+)
+
+// scheduleBatchSize bounds how many due schedules one run executes before
+// checking for more.
+const scheduleBatchSize = 100
+
+type ScheduleServiceInterface interface {
+	CreateSchedule(ctx context.Context, s *model.Schedule) error
+	GetSchedule(ctx context.Context, id uuid.UUID) (*model.Schedule, error)
+	PauseSchedule(ctx context.Context, id uuid.UUID) (*model.Schedule, error)
+	ResumeSchedule(ctx context.Context, id uuid.UUID) (*model.Schedule, error)
+	CancelSchedule(ctx context.Context, id uuid.UUID) (*model.Schedule, error)
+	GetExecutions(ctx context.Context, id uuid.UUID, limit int) ([]model.ScheduleExecution, error)
+	RunDue(ctx context.Context, now time.Time) (int, error)
+}
+
+type ScheduleService struct {
+	scheduleRepo postgres.ScheduleRepository
+	transactions TransactionServiceInterface
+}
+
+func NewScheduleService(sr postgres.ScheduleRepository, ts TransactionServiceInterface) *ScheduleService {
+	return &ScheduleService{scheduleRepo: sr, transactions: ts}
+}
+
+// CreateSchedule validates s and its accounts and stores it as active with its
+// first run. Exactly one of RunAt, which must be in the future, and Cron is set.
+func (s *ScheduleService) CreateSchedule(ctx context.Context, sched *model.Schedule) error {
+	now := time.Now().UTC()
+	if (sched.RunAt == nil) == (sched.Cron == "") {
+		return apperrors.ErrInvalidSchedule
+	}
+	if sched.RunAt != nil && !sched.RunAt.After(now) {
+		return apperrors.ErrInvalidSchedule
+	}
+	if sched.Type == constants.Transfer && sched.CounterpartyID == sched.AccountID {
+		return apperrors.ErrSameAccountTransfer
+	}
+
+	next, err := sched.NextRun(now)
+	if err != nil {
+		return fmt.Errorf("%w: %v", apperrors.ErrInvalidSchedule, err)
+	}
+
+	txn := sched.Transaction()
+	if err := s.transactions.ValidateAccounts(ctx, txn); err != nil {
+		return err
+	}
+
+	sched.Currency = txn.Currency
+	sched.Status = constants.ScheduleActive
+	sched.NextRunAt = next
+	return s.scheduleRepo.CreateSchedule(ctx, sched)
+}
+
+func (s *ScheduleService) GetSchedule(ctx context.Context, id uuid.UUID) (*model.Schedule, error) {
+	sched, err := s.scheduleRepo.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sched == nil {
+		return nil, apperrors.ErrScheduleNotFound
+	}
+	return sched, nil
+}
+
+func (s *ScheduleService) PauseSchedule(ctx context.Context, id uuid.UUID) (*model.Schedule, error) {
+	return s.scheduleRepo.UpdateStatus(ctx, id, []constants.ScheduleStatus{constants.ScheduleActive}, constants.SchedulePaused, nil)
+}
+
+// ResumeSchedule reactivates a paused schedule. A recurring schedule resumes
+// at its next occurrence, so occurrences missed while paused do not run; a
+// one-off schedule whose time has passed runs straight away.
+func (s *ScheduleService) ResumeSchedule(ctx context.Context, id uuid.UUID) (*model.Schedule, error) {
+	sched, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	next := sched.NextRunAt
+	if sched.Cron != "" {
+		next, err = sched.NextRun(time.Now().UTC())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.scheduleRepo.UpdateStatus(ctx, id, []constants.ScheduleStatus{constants.SchedulePaused}, constants.ScheduleActive, next)
+}
+
+func (s *ScheduleService) CancelSchedule(ctx context.Context, id uuid.UUID) (*model.Schedule, error) {
+	return s.scheduleRepo.UpdateStatus(ctx, id,
+		[]constants.ScheduleStatus{constants.ScheduleActive, constants.SchedulePaused},
+		constants.ScheduleCancelled, nil)
+}
+
+func (s *ScheduleService) GetExecutions(ctx context.Context, id uuid.UUID, limit int) ([]model.ScheduleExecution, error) {
+	if _, err := s.GetSchedule(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.scheduleRepo.ListExecutions(ctx, id, limit)
+}
+
+// RunDue executes a batch of schedules due at now and returns how many it
+// executed. A schedule that fails for an infrastructure reason stays due and is
+// retried on the next run.
+func (s *ScheduleService) RunDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.scheduleRepo.ListDue(ctx, now, scheduleBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	executed := 0
+	for i := range due {
+		// each occurrence gets its own request ID to follow it to the consumer
+		ctx := logger.WithRequestID(ctx, uuid.NewString())
+		if err := s.execute(ctx, &due[i], now); err != nil {
+			slog.ErrorContext(ctx, "executing schedule", "schedule_id", due[i].ID, "error", err)
+			continue
+		}
+		executed++
+	}
+	return executed, nil
+}
+
+// execute enqueues the occurrence of sched that is due and advances it to the
+// first occurrence after now, so occurrences missed during an outage collapse
+// into one. The occurrence is enqueued under an idempotency key, which keeps a
+// retry after a crash, or a second scheduler instance, from enqueueing it twice.
+func (s *ScheduleService) execute(ctx context.Context, sched *model.Schedule, now time.Time) error {
+	next, err := sched.NextRun(now)
+	if err != nil {
+		return err
+	}
+
+	exec := &model.ScheduleExecution{ScheduleID: sched.ID, DueAt: *sched.NextRunAt}
+	txn := sched.Transaction()
+
+	err = s.transactions.ValidateAccounts(ctx, txn)
+	if apperrors.IsRejection(err) {
+		exec.Outcome = constants.ExecutionFailed
+		exec.Reason = err.Error()
+		return s.scheduleRepo.RecordExecution(ctx, exec, next)
+	}
+	if err != nil {
+		return err
+	}
+
+	key, err := NewIdempotencyKey(occurrenceKeyScope, occurrenceKey(exec), txn)
+	if err != nil {
+		return err
+	}
+	replayed, err := s.transactions.ReplayIdempotencyKey(ctx, key, txn)
+	if err != nil {
+		return err
+	}
+	if replayed {
+		exec.Outcome = constants.ExecutionEnqueued
+		exec.TransactionID = txn.ID
+		return s.scheduleRepo.RecordExecution(ctx, exec, next)
+	}
+
+	if sched.SkipInsufficientFunds && (txn.Type == constants.Withdrawal || txn.Type == constants.Transfer) {
+		ok, err := s.transactions.HasSufficientFunds(ctx, txn.AccountID.String(), txn.Amount)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			exec.Outcome = constants.ExecutionSkipped
+			exec.Reason = apperrors.ErrInsufficientFunds.Error()
+			return s.scheduleRepo.RecordExecution(ctx, exec, next)
+		}
+	}
+
+	// another scheduler instance may enqueue the occurrence concurrently; the
+	// key makes the loser replay its transaction ID instead
+	if _, err := s.transactions.EnqueueIdempotent(ctx, key, txn); err != nil {
+		return err
+	}
+
+	exec.Outcome = constants.ExecutionEnqueued
+	exec.TransactionID = txn.ID
+	return s.scheduleRepo.RecordExecution(ctx, exec, next)
+}
+
+// occurrenceKeyScope holds the scheduler's own idempotency keys, apart from
+// those of API callers.
+const occurrenceKeyScope = "scheduler"
+
+func occurrenceKey(exec *model.ScheduleExecution) string {
+	return "schedule:" + exec.ScheduleID.String() + ":" + exec.DueAt.UTC().Format(time.RFC3339Nano)
+}
+
+// Scheduler runs due schedules in the background.
+type Scheduler struct {
+	schedules ScheduleServiceInterface
+	interval  time.Duration
+	now       func() time.Time
+}
+
+func NewScheduler(ss ScheduleServiceInterface, interval time.Duration) *Scheduler {
+	return NewSchedulerWithClock(ss, interval, time.Now)
+}
+
+// NewSchedulerWithClock builds a scheduler that asks now, rather than the wall
+// clock, what is due on each check.
+func NewSchedulerWithClock(ss ScheduleServiceInterface, interval time.Duration, now func() time.Time) *Scheduler {
+	return &Scheduler{schedules: ss, interval: interval, now: now}
+}
+
+// Run checks for due schedules every interval until ctx is cancelled.
+func (sc *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(sc.interval)
+	defer ticker.Stop()
+
+	for {
+		executed, err := sc.schedules.RunDue(ctx, sc.now().UTC())
+		if err != nil {
+			slog.ErrorContext(ctx, "running due schedules", "error", err)
+		}
+		if executed == scheduleBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
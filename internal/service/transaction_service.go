@@ -2,72 +2,376 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/imranzahoor/banking-ledger/internal/model"
 	"github.com/imranzahoor/banking-ledger/internal/repository/mongo"
 	"github.com/imranzahoor/banking-ledger/internal/repository/postgres"
 	"github.com/imranzahoor/banking-ledger/pkg/config"
-	"github.com/segmentio/kafka-go"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/currency"
+	apperrors "github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/pkg/logger"
+	"github.com/imranzahoor/banking-ledger/pkg/metrics"
+	"github.com/imranzahoor/banking-ledger/pkg/tracing"
 )
 
 type TransactionService struct {
-	accountRepo postgres.AccountRepository
-	ledgerRepo  mongo.LedgerRepository
-	kafkaWriter *kafka.Writer
+	accountRepo     postgres.AccountRepository
+	ledgerRepo      mongo.LedgerRepository
+	idempotencyRepo postgres.IdempotencyRepository
+	transactionRepo postgres.TransactionRepository
+	topic           string
+	holdTTL         time.Duration
 }
 
 type TransactionServiceInterface interface {
 	EnqueueTransaction(ctx context.Context, txn *model.Transaction) error
-	GetTransactions(accountID uuid.UUID, limit, offset int64) ([]model.Transaction, error)
+	GetTransaction(ctx context.Context, id uuid.UUID) (*model.Transaction, error)
+	WaitForCompletion(ctx context.Context, id uuid.UUID, timeout time.Duration) (*model.Transaction, *model.Account, error)
+	GetTransactions(accountID uuid.UUID, filter model.TransactionFilter, limit, offset int64) ([]model.Transaction, error)
+	GetTransactionsPage(ctx context.Context, accountID uuid.UUID, filter model.TransactionFilter, cursor string, limit int64) ([]model.Transaction, string, error)
 	HasSufficientFunds(ctx context.Context, accountID string, amount int64) (bool, error)
+	ValidateAccounts(ctx context.Context, txn *model.Transaction) error
+	NewHold(accountID uuid.UUID, amount int64, currencyCode, description string, expiresIn time.Duration) *model.Transaction
+	GetHold(ctx context.Context, id uuid.UUID) (*model.Hold, error)
+	SettleHold(ctx context.Context, holdID uuid.UUID, typ constants.TransactionType, amount int64) (*model.Transaction, error)
+	GetJournalEntry(ctx context.Context, transactionID uuid.UUID) (*model.JournalEntry, error)
+	GetTrialBalance(ctx context.Context) ([]model.TrialBalance, error)
+	ReplayIdempotencyKey(ctx context.Context, key *model.IdempotencyKey, txn *model.Transaction) (bool, error)
+	EnqueueIdempotent(ctx context.Context, key *model.IdempotencyKey, txn *model.Transaction) (bool, error)
 }
 
 func NewTransactionService(
 	ar postgres.AccountRepository,
 	lr mongo.LedgerRepository,
+	ir postgres.IdempotencyRepository,
+	tr postgres.TransactionRepository,
 	cfg config.Config,
 ) *TransactionService {
-	w := &kafka.Writer{
-		Addr:     kafka.TCP(cfg.KafkaBrokers...),
-		Topic:    cfg.KafkaTopic,
-		Balancer: &kafka.LeastBytes{},
-	}
-
 	return &TransactionService{
-		accountRepo: ar,
-		ledgerRepo:  lr,
-		kafkaWriter: w,
+		accountRepo:     ar,
+		ledgerRepo:      lr,
+		idempotencyRepo: ir,
+		transactionRepo: tr,
+		topic:           cfg.KafkaTopic,
+		holdTTL:         cfg.HoldTTL,
 	}
 }
 
+// EnqueueTransaction durably records txn as pending together with an outbox
+// message; the outbox relay publishes it to Kafka, so a broker outage only
+// delays processing. The message carries the request ID and trace context of
+// ctx, so the consumer logs under the same ID and continues the trace.
 func (s *TransactionService) EnqueueTransaction(ctx context.Context, txn *model.Transaction) error {
+	msg, err := s.prepareEnqueue(ctx, txn)
+	if err != nil {
+		return err
+	}
+	if err := s.transactionRepo.CreatePending(ctx, txn, msg); err != nil {
+		return err
+	}
+	s.enqueued(ctx, txn)
+	return nil
+}
+
+// EnqueueIdempotent enqueues txn like EnqueueTransaction and stores key with
+// it in the same database transaction. If a concurrent request committed the
+// key first, nothing is enqueued and the outcome is that of
+// ReplayIdempotencyKey.
+func (s *TransactionService) EnqueueIdempotent(ctx context.Context, key *model.IdempotencyKey, txn *model.Transaction) (bool, error) {
+	msg, err := s.prepareEnqueue(ctx, txn)
+	if err != nil {
+		return false, err
+	}
+	key.TransactionID = txn.ID
+	created, err := s.transactionRepo.CreatePendingWithKey(ctx, txn, msg, key)
+	if err != nil {
+		return false, err
+	}
+	if !created {
+		return s.ReplayIdempotencyKey(ctx, key, txn)
+	}
+	s.enqueued(ctx, txn)
+	return false, nil
+}
+
+// prepareEnqueue assigns txn its ID and pending status and builds the outbox
+// message that delivers it.
+func (s *TransactionService) prepareEnqueue(ctx context.Context, txn *model.Transaction) (*model.OutboxMessage, error) {
 	if txn.ID == uuid.Nil {
 		txn.ID = uuid.New()
 	}
+	txn.Status = constants.StatusPending
+	if txn.Type == constants.Hold {
+		// a hold is identified by the transaction that placed it
+		txn.HoldID = txn.ID
+	}
+
+	return model.NewTransactionMessage(s.topic, txn, messageHeaders(ctx))
+}
+
+func (s *TransactionService) enqueued(ctx context.Context, txn *model.Transaction) {
+	metrics.TransactionsEnqueued.WithLabelValues(string(txn.Type)).Inc()
+	slog.InfoContext(ctx, "transaction enqueued", "transaction_id", txn.ID, "type", txn.Type, "account_id", txn.AccountID)
+}
+
+// messageHeaders returns the Kafka headers for a message produced under ctx.
+func messageHeaders(ctx context.Context) map[string]string {
+	return tracing.InjectHeaders(ctx, logger.MessageHeaders(ctx))
+}
+
+// GetTransaction fetches a transaction with its processing status
+func (s *TransactionService) GetTransaction(ctx context.Context, id uuid.UUID) (*model.Transaction, error) {
+	return s.transactionRepo.GetByID(ctx, id)
+}
+
+// statusPollInterval is how often WaitForCompletion re-reads the status.
+const statusPollInterval = 100 * time.Millisecond
+
+// WaitForCompletion polls the status of a transaction until the consumer has
+// completed or failed it, or timeout elapses. The last seen transaction is
+// returned; it is still pending on timeout. For completed transactions the
+// debited or credited account is returned with its balance after processing.
+func (s *TransactionService) WaitForCompletion(ctx context.Context, id uuid.UUID, timeout time.Duration) (*model.Transaction, *model.Account, error) {
+	pollCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(statusPollInterval)
+	defer ticker.Stop()
+
+	var last *model.Transaction
+	for {
+		txn, err := s.transactionRepo.GetByID(pollCtx, id)
+		switch {
+		case pollCtx.Err() != nil:
+			return last, nil, nil
+		case errors.Is(err, apperrors.ErrTransactionNotFound):
+		case err != nil:
+			return nil, nil, err
+		case txn.Status == constants.StatusCompleted:
+			account, err := s.accountRepo.GetAccountByID(ctx, txn.AccountID.String())
+			if err != nil {
+				return nil, nil, err
+			}
+			return txn, account, nil
+		case txn.Status == constants.StatusFailed:
+			return txn, nil, nil
+		default:
+			last = txn
+		}
+
+		select {
+		case <-pollCtx.Done():
+			return last, nil, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *TransactionService) GetTransactions(accountID uuid.UUID, filter model.TransactionFilter, limit, offset int64) ([]model.Transaction, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	return s.ledgerRepo.GetTransactionsByAccountID(context.Background(), accountID, filter, limit, offset)
+}
+
+// GetTransactionsPage returns a page of the account's history matching filter,
+// newest first, and the cursor of the following page, empty when there is
+// none. A cursor is only meaningful with the filter it was issued for.
+func (s *TransactionService) GetTransactionsPage(ctx context.Context, accountID uuid.UUID, filter model.TransactionFilter, cursor string, limit int64) ([]model.Transaction, string, error) {
+	if limit <= 0 {
+		return nil, "", apperrors.ErrInvalidLimit
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, "", err
+	}
+	return s.ledgerRepo.GetTransactionsPage(ctx, accountID, filter, cursor, limit)
+}
+
+// HasSufficientFunds reports whether the account's available balance, including
+// its overdraft limit and net of active holds, covers amount.
+func (s *TransactionService) HasSufficientFunds(ctx context.Context, accountID string, amount int64) (bool, error) {
+	account, err := s.accountRepo.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return false, err
+	}
+	if account == nil {
+		return false, apperrors.ErrAccountNotFound
+	}
+	return account.AvailableBalance() >= amount, nil
+}
 
-	data, err := json.Marshal(txn)
+// ValidateAccounts checks txn against the accounts it touches before it is
+// enqueued: both sides must exist, accept the movement given their status and
+// hold the same currency. A missing currency defaults to the source account's.
+// The consumer enforces the same rules when it applies the transaction.
+func (s *TransactionService) ValidateAccounts(ctx context.Context, txn *model.Transaction) error {
+	account, err := s.accountRepo.GetAccountByID(ctx, txn.AccountID.String())
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return apperrors.ErrAccountNotFound
+	}
+
+	if txn.Type == constants.Deposit || txn.Type == constants.Void {
+		err = account.CanCredit()
+	} else {
+		err = account.CanDebit()
+	}
 	if err != nil {
 		return err
 	}
 
-	msg := kafka.Message{
-		Key:   []byte(txn.ID.String()),
-		Value: data,
+	code := currency.Normalize(txn.Currency)
+	if code == "" {
+		code = account.Currency
+	}
+	if code != account.Currency {
+		return apperrors.ErrCurrencyMismatch
+	}
+	txn.Currency = code
+
+	if txn.Type != constants.Transfer {
+		return nil
 	}
 
-	return s.kafkaWriter.WriteMessages(ctx, msg)
+	dest, err := s.accountRepo.GetAccountByID(ctx, txn.CounterpartyID.String())
+	if err != nil {
+		return err
+	}
+	if dest == nil {
+		return apperrors.ErrAccountNotFound
+	}
+	if err := dest.CanCredit(); err != nil {
+		return err
+	}
+	if dest.Currency != code {
+		return apperrors.ErrCurrencyMismatch
+	}
+	return nil
 }
 
-func (s *TransactionService) GetTransactions(accountID uuid.UUID, limit, offset int64) ([]model.Transaction, error) {
-	return s.ledgerRepo.GetTransactionsByAccountID(context.Background(), accountID, limit, offset)
+// NewHold builds the transaction that places a hold on accountID. The hold
+// lapses after expiresIn, or after the configured default when that is zero.
+func (s *TransactionService) NewHold(accountID uuid.UUID, amount int64, currencyCode, description string, expiresIn time.Duration) *model.Transaction {
+	if expiresIn <= 0 {
+		expiresIn = s.holdTTL
+	}
+	expiresAt := time.Now().UTC().Add(expiresIn)
+
+	return &model.Transaction{
+		AccountID:   accountID,
+		Type:        constants.Hold,
+		Amount:      amount,
+		Currency:    currencyCode,
+		Description: description,
+		ExpiresAt:   &expiresAt,
+	}
 }
 
-func (s *TransactionService) HasSufficientFunds(ctx context.Context, accountID string, amount int64) (bool, error) {
-	account, err := s.accountRepo.GetAccountByID(ctx, accountID)
+func (s *TransactionService) GetHold(ctx context.Context, id uuid.UUID) (*model.Hold, error) {
+	hold, err := s.accountRepo.GetHold(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil {
+		return nil, apperrors.ErrHoldNotFound
+	}
+	return hold, nil
+}
+
+// SettleHold builds the capture or void transaction for an active hold. A
+// capture of zero takes the full held amount; a void always releases all of it.
+func (s *TransactionService) SettleHold(ctx context.Context, holdID uuid.UUID, typ constants.TransactionType, amount int64) (*model.Transaction, error) {
+	hold, err := s.GetHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if hold.Status != constants.HoldActive {
+		return nil, apperrors.ErrHoldNotActive
+	}
+
+	switch typ {
+	case constants.Capture:
+		if amount == 0 {
+			amount = hold.Amount
+		}
+		if amount > hold.Amount {
+			return nil, apperrors.ErrCaptureExceedsHold
+		}
+	case constants.Void:
+		amount = hold.Amount
+	default:
+		return nil, apperrors.ErrInvalidTransactionType
+	}
+
+	return &model.Transaction{
+		AccountID: hold.AccountID,
+		HoldID:    hold.ID,
+		Type:      typ,
+		Amount:    amount,
+		Currency:  hold.Currency,
+	}, nil
+}
+
+func (s *TransactionService) GetJournalEntry(ctx context.Context, transactionID uuid.UUID) (*model.JournalEntry, error) {
+	return s.ledgerRepo.GetJournalEntry(ctx, transactionID)
+}
+
+func (s *TransactionService) GetTrialBalance(ctx context.Context) ([]model.TrialBalance, error) {
+	return s.ledgerRepo.GetTrialBalance(ctx)
+}
+
+// NewIdempotencyKey builds the key a caller in scope sent txn under. The
+// fingerprint covers txn as it is now, so build the key before validation
+// fills in defaults such as the account's currency: a retry must match it
+// without having been validated.
+func NewIdempotencyKey(scope, key string, txn *model.Transaction) (*model.IdempotencyKey, error) {
+	fingerprint, err := requestFingerprint(txn)
 	if err != nil {
+		return nil, err
+	}
+	return &model.IdempotencyKey{Scope: scope, Key: key, Fingerprint: fingerprint}, nil
+}
+
+// ReplayIdempotencyKey reports whether key was already used to enqueue a
+// transaction. For an identical request txn.ID is set to the accepted
+// transaction ID and true is returned; reusing the key for a different request
+// returns ErrDuplicateRequest. A key is only stored together with its
+// transaction, so a replayed ID always exists.
+func (s *TransactionService) ReplayIdempotencyKey(ctx context.Context, key *model.IdempotencyKey, txn *model.Transaction) (bool, error) {
+	existing, err := s.idempotencyRepo.GetByKey(ctx, key.Scope, key.Key)
+	if err != nil || existing == nil {
 		return false, err
 	}
-	return account.Balance >= amount, nil
+	if existing.Fingerprint != key.Fingerprint {
+		return false, apperrors.ErrDuplicateRequest
+	}
+
+	txn.ID = existing.TransactionID
+	return true, nil
+}
+
+// requestFingerprint hashes the client-supplied fields of txn.
+func requestFingerprint(txn *model.Transaction) (string, error) {
+	req := *txn
+	req.ID = uuid.Nil
+	req.CreatedAt = time.Time{}
+	// derived from the time of the request rather than supplied by the client
+	req.ExpiresAt = nil
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
 }
@@ -1,15 +1,20 @@
 package config
 
 import (
-	"log"
+	"log/slog"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
 	Port         string
+	MetricsPort  string // serves /metrics apart from the API
+	LogLevel     string
+	ServiceName  string
 	PostgresHost string
 	PostgresPort string
 	PostgresUser string
@@ -20,16 +25,71 @@ type Config struct {
 	KafkaBrokers []string
 	KafkaTopic   string
 	KafkaGroupID string
+
+	KafkaDLQTopic           string
+	ConsumerMaxRetries      int
+	ConsumerRetryBackoff    time.Duration
+	ConsumerMaxRetryBackoff time.Duration
+
+	OutboxPollInterval time.Duration
+	OutboxBatchSize    int
+
+	HoldTTL            time.Duration
+	HoldExpiryInterval time.Duration
+
+	SchedulerInterval time.Duration
+
+	ReadinessTimeout time.Duration // per dependency check in /readyz
+
+	ReconciliationInterval    time.Duration // zero disables periodic runs
+	ReconciliationGracePeriod time.Duration
+	ReconciliationAutoRepair  bool
+
+	AuthEnabled         bool
+	AdminAPIKey         string // bootstrap key allowed to manage API keys
+	JWTHMACSecret       string
+	JWTRSAPublicKeyFile string // PEM-encoded public key for RS256/384/512 tokens
+	JWTIssuer           string
+	JWTAudience         string
+
+	TracingExporter string // "none", "otlp" or "stdout"
+
+	IPRateLimit      RateLimit // every /api/v1 route, per client IP, checked before authentication
+	RateLimit        RateLimit // every /api/v1 route, per caller
+	PostingRateLimit RateLimit // posting transactions and holds, per caller
+	AccountRateLimit RateLimit // posting transactions and holds, per source account
+}
+
+// RateLimit allows bursts of up to Requests, refilled evenly over Per. The
+// zero value disables the limit.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// Enabled reports whether the limit applies.
+func (l RateLimit) Enabled() bool {
+	return l.Requests > 0 && l.Per > 0
+}
+
+func (l RateLimit) String() string {
+	if !l.Enabled() {
+		return "off"
+	}
+	return strconv.Itoa(l.Requests) + "/" + l.Per.String()
 }
 
 func Load() Config {
 
 	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found or error loading .env file; using default/fallback values.")
+		slog.Info("no .env file loaded; using environment and defaults")
 	}
 
 	return Config{
 		Port:         getEnv("PORT", "8080"),
+		MetricsPort:  getEnv("METRICS_PORT", "9090"),
+		LogLevel:     getEnv("LOG_LEVEL", "info"),
+		ServiceName:  getEnv("OTEL_SERVICE_NAME", "banking-ledger"),
 		PostgresHost: getEnv("POSTGRES_HOST", "localhost"),
 		PostgresPort: getEnv("POSTGRES_PORT", "5432"),
 		PostgresUser: getEnv("POSTGRES_USER", "postgres"),
@@ -40,6 +100,39 @@ func Load() Config {
 		KafkaBrokers: splitAndTrim(getEnv("KAFKA_BROKERS", "localhost:9092")),
 		KafkaTopic:   getEnv("KAFKA_TOPIC", "transactions"),
 		KafkaGroupID: getEnv("KAFKA_GROUP_ID", "transaction-consumer-group"),
+
+		KafkaDLQTopic:           getEnv("KAFKA_DLQ_TOPIC", "transactions.dlq"),
+		ConsumerMaxRetries:      getEnvInt("CONSUMER_MAX_RETRIES", 5),
+		ConsumerRetryBackoff:    getEnvDuration("CONSUMER_RETRY_BACKOFF", 500*time.Millisecond),
+		ConsumerMaxRetryBackoff: getEnvDuration("CONSUMER_MAX_RETRY_BACKOFF", 30*time.Second),
+
+		OutboxPollInterval: getEnvPositiveDuration("OUTBOX_POLL_INTERVAL", 500*time.Millisecond),
+		OutboxBatchSize:    getEnvPositiveInt("OUTBOX_BATCH_SIZE", 100),
+
+		HoldTTL:            getEnvPositiveDuration("HOLD_TTL", 7*24*time.Hour),
+		HoldExpiryInterval: getEnvPositiveDuration("HOLD_EXPIRY_INTERVAL", time.Minute),
+
+		SchedulerInterval: getEnvPositiveDuration("SCHEDULER_INTERVAL", 30*time.Second),
+
+		ReadinessTimeout: getEnvPositiveDuration("READINESS_TIMEOUT", 2*time.Second),
+
+		ReconciliationInterval:    getEnvDuration("RECONCILIATION_INTERVAL", 24*time.Hour),
+		ReconciliationGracePeriod: getEnvDuration("RECONCILIATION_GRACE_PERIOD", 5*time.Minute),
+		ReconciliationAutoRepair:  getEnvBool("RECONCILIATION_AUTO_REPAIR", false),
+
+		AuthEnabled:         getEnvBool("AUTH_ENABLED", true),
+		AdminAPIKey:         getEnv("ADMIN_API_KEY", ""),
+		JWTHMACSecret:       getEnv("JWT_HMAC_SECRET", ""),
+		JWTRSAPublicKeyFile: getEnv("JWT_RSA_PUBLIC_KEY_FILE", ""),
+		JWTIssuer:           getEnv("JWT_ISSUER", ""),
+		JWTAudience:         getEnv("JWT_AUDIENCE", ""),
+
+		TracingExporter: getEnv("TRACING_EXPORTER", "none"),
+
+		IPRateLimit:      getEnvRateLimit("RATE_LIMIT_IP", RateLimit{Requests: 2400, Per: time.Minute}),
+		RateLimit:        getEnvRateLimit("RATE_LIMIT", RateLimit{Requests: 1200, Per: time.Minute}),
+		PostingRateLimit: getEnvRateLimit("RATE_LIMIT_POSTING", RateLimit{Requests: 120, Per: time.Minute}),
+		AccountRateLimit: getEnvRateLimit("RATE_LIMIT_ACCOUNT", RateLimit{Requests: 60, Per: time.Minute}),
 	}
 }
 
@@ -49,6 +142,81 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	if val, ok := os.LookupEnv(key); ok {
+		n, err := strconv.Atoi(val)
+		if err == nil {
+			return n
+		}
+		slog.Warn("invalid setting; using default", "key", key, "value", val, "default", fallback)
+	}
+	return fallback
+}
+
+// getEnvPositiveInt is getEnvInt for settings where zero or a negative value
+// makes no sense, such as batch sizes.
+func getEnvPositiveInt(key string, fallback int) int {
+	n := getEnvInt(key, fallback)
+	if n <= 0 {
+		slog.Warn("invalid setting; using default", "key", key, "value", n, "default", fallback)
+		return fallback
+	}
+	return n
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if val, ok := os.LookupEnv(key); ok {
+		b, err := strconv.ParseBool(val)
+		if err == nil {
+			return b
+		}
+		slog.Warn("invalid setting; using default", "key", key, "value", val, "default", fallback)
+	}
+	return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if val, ok := os.LookupEnv(key); ok {
+		d, err := time.ParseDuration(val)
+		if err == nil {
+			return d
+		}
+		slog.Warn("invalid setting; using default", "key", key, "value", val, "default", fallback.String())
+	}
+	return fallback
+}
+
+// getEnvPositiveDuration is getEnvDuration for intervals, timeouts and
+// lifetimes, which must be positive: time.NewTicker panics on anything else,
+// and a hold placed with no lifetime has already expired.
+func getEnvPositiveDuration(key string, fallback time.Duration) time.Duration {
+	d := getEnvDuration(key, fallback)
+	if d <= 0 {
+		slog.Warn("invalid setting; using default", "key", key, "value", d.String(), "default", fallback.String())
+		return fallback
+	}
+	return d
+}
+
+// getEnvRateLimit parses limits written as "<requests>/<duration>", such as
+// "60/1m"; "off" or "0" disables the limit.
+func getEnvRateLimit(key string, fallback RateLimit) RateLimit {
+	if val, ok := os.LookupEnv(key); ok {
+		if val == "off" || val == "0" {
+			return RateLimit{}
+		}
+		requests, per, found := strings.Cut(val, "/")
+		n, err := strconv.Atoi(requests)
+		d, derr := time.ParseDuration(per)
+		if found && err == nil && derr == nil && n > 0 && d > 0 {
+			return RateLimit{Requests: n, Per: d}
+		}
+		slog.Warn("invalid setting; using default", "key", key, "value", val, "default", fallback.String())
+	}
+	return fallback
+}
+
 func splitAndTrim(s string) []string {
 	parts := strings.Split(s, ",")
 	var trimmed []string
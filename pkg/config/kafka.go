@@ -1,7 +1,16 @@
 package config
 
+import "time"
+
 type KafkaConfig struct {
 	Brokers []string
 	Topic   string
 	GroupID string
+
+	DLQTopic        string
+	MaxRetries      int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+
+	StatsInterval time.Duration // how often the consumer samples reader stats; zero means every 5s
 }
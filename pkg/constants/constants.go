@@ -1,9 +1,126 @@
 package constants
 
-// TransactionType is a deposit or withdrawal
+import "github.com/google/uuid"
+
+// TransactionType is a deposit, withdrawal or transfer, or one of the hold
+// operations used for two-phase payments
 type TransactionType string
 
 const (
 	Deposit    TransactionType = "deposit"
 	Withdrawal TransactionType = "withdrawal"
+	Transfer   TransactionType = "transfer"
+	Hold       TransactionType = "hold"    // reserves funds without moving them
+	Capture    TransactionType = "capture" // takes all or part of a hold
+	Void       TransactionType = "void"    // releases a hold
+	// Adjustment corrects the ledger against the clearing account; it is
+	// written by reconciliation and never changes a balance.
+	Adjustment TransactionType = "adjustment"
+)
+
+// TransactionStatus tracks a transaction from acceptance to its final outcome
+type TransactionStatus string
+
+const (
+	StatusPending   TransactionStatus = "pending"
+	StatusCompleted TransactionStatus = "completed"
+	StatusFailed    TransactionStatus = "failed"
+)
+
+// AccountStatus is the lifecycle state of an account
+type AccountStatus string
+
+const (
+	AccountActive AccountStatus = "active"
+	AccountFrozen AccountStatus = "frozen" // rejects debits
+	AccountClosed AccountStatus = "closed" // rejects everything
+)
+
+// HoldStatus is the state of a funds reservation
+type HoldStatus string
+
+const (
+	HoldActive   HoldStatus = "active"
+	HoldCaptured HoldStatus = "captured"
+	HoldVoided   HoldStatus = "voided"
+	HoldExpired  HoldStatus = "expired"
+)
+
+// ScheduleStatus is the lifecycle state of a scheduled transaction
+type ScheduleStatus string
+
+const (
+	ScheduleActive    ScheduleStatus = "active"
+	SchedulePaused    ScheduleStatus = "paused"
+	ScheduleCancelled ScheduleStatus = "cancelled"
+	ScheduleCompleted ScheduleStatus = "completed" // one-off schedule that has run
+)
+
+// ExecutionOutcome records what happened when a schedule fell due
+type ExecutionOutcome string
+
+const (
+	ExecutionEnqueued ExecutionOutcome = "enqueued" // transaction handed to the consumer
+	ExecutionSkipped  ExecutionOutcome = "skipped"  // insufficient funds and the schedule skips
+	ExecutionFailed   ExecutionOutcome = "failed"   // rejected before it could be enqueued
+)
+
+// ReconciliationStatus tracks a reconciliation run
+type ReconciliationStatus string
+
+const (
+	ReconciliationRunning   ReconciliationStatus = "running"
+	ReconciliationCompleted ReconciliationStatus = "completed"
+	ReconciliationFailed    ReconciliationStatus = "failed"
+)
+
+// ReconciliationTrigger records what started a reconciliation run
+type ReconciliationTrigger string
+
+const (
+	TriggerScheduled ReconciliationTrigger = "scheduled"
+	TriggerManual    ReconciliationTrigger = "manual"
+)
+
+// ConsumerState is the lifecycle state of the transaction consumer
+type ConsumerState string
+
+const (
+	ConsumerStarting ConsumerState = "starting" // waiting to join the consumer group
+	ConsumerRunning  ConsumerState = "running"  // joined the group or fetched a message
+	ConsumerStopped  ConsumerState = "stopped"  // Run has returned
+)
+
+// Role determines what an authenticated caller is allowed to do
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"    // manages accounts, keys and the ledger
+	RoleTeller   Role = "teller"   // posts deposits and withdrawals on any account
+	RoleAuditor  Role = "auditor"  // reads everything, changes nothing
+	RoleCustomer Role = "customer" // reads and transfers from their own accounts
+)
+
+// IsValid reports whether r is one of the defined roles
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleAdmin, RoleTeller, RoleAuditor, RoleCustomer:
+		return true
+	}
+	return false
+}
+
+// EntryDirection is the side of a ledger entry from the account's point of view
+type EntryDirection string
+
+const (
+	Debit  EntryDirection = "debit"
+	Credit EntryDirection = "credit"
+)
+
+// System ledger accounts that customer deposits and withdrawals are posted
+// against so every journal entry balances.
+var (
+	CashAccountID     = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	ClearingAccountID = uuid.MustParse("00000000-0000-0000-0000-000000000002")
 )
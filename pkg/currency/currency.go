@@ -0,0 +1,59 @@
+package currency
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Default is used for accounts created without an explicit currency.
+const Default = "USD"
+
+// minorUnits maps supported ISO 4217 codes to their number of decimal places.
+var minorUnits = map[string]int{
+	"AED": 2, "AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CNY": 2,
+	"CZK": 2, "DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2,
+	"INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "MXN": 2,
+	"NOK": 2, "NZD": 2, "OMR": 3, "PKR": 2, "PLN": 2, "SAR": 2, "SEK": 2,
+	"SGD": 2, "TND": 3, "TRY": 2, "USD": 2, "ZAR": 2,
+}
+
+// Normalize upper-cases and trims a currency code.
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// IsSupported reports whether code is a supported ISO 4217 currency.
+func IsSupported(code string) bool {
+	_, ok := minorUnits[code]
+	return ok
+}
+
+// MinorUnits returns the number of decimal places of code, e.g. 2 for USD and
+// 0 for JPY. Unknown codes default to 2.
+func MinorUnits(code string) int {
+	if n, ok := minorUnits[code]; ok {
+		return n
+	}
+	return 2
+}
+
+// Format renders an amount in minor units as a decimal string, e.g.
+// Format(123456, "USD") == "1234.56" and Format(500, "JPY") == "500".
+func Format(amount int64, code string) string {
+	exp := MinorUnits(code)
+	if exp == 0 {
+		return fmt.Sprintf("%d", amount)
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	scale := int64(1)
+	for i := 0; i < exp; i++ {
+		scale *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, exp, amount%scale)
+}
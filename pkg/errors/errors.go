@@ -13,7 +13,62 @@ var (
 	ErrInvalidTransactionType = errors.New("invalid transaction type")
 	ErrInvalidLimit           = errors.New("invalid limit")
 	ErrInvalidOffset          = errors.New("invalid offset")
+	ErrInvalidCursor          = errors.New("invalid cursor")
 	ErrParsingID              = errors.New("failed to parse ID")
 	ErrFetchingTransactions   = errors.New("failed to retrieve transactions")
+	ErrSameAccountTransfer    = errors.New("source and destination accounts must differ")
+	ErrUnbalancedEntry        = errors.New("journal entry debits and credits do not balance")
+	ErrJournalEntryNotFound   = errors.New("journal entry not found")
+	ErrInvalidIdempotencyKey  = errors.New("idempotency key must be 1-255 characters")
+	ErrTransactionNotFound    = errors.New("transaction not found")
+	ErrInvalidWait            = errors.New("wait must be a non-negative duration")
+	ErrUnsupportedCurrency    = errors.New("unsupported currency")
+	ErrCurrencyMismatch       = errors.New("transaction currency does not match account currency")
+	ErrAccountFrozen          = errors.New("account is frozen")
+	ErrAccountClosed          = errors.New("account is closed")
+	ErrAccountNotEmpty        = errors.New("account balance must be zero or a payout account given")
+	ErrInvalidStatusChange    = errors.New("account status does not allow this change")
+	ErrActiveHolds            = errors.New("account has active holds")
+	ErrHoldNotFound           = errors.New("hold not found")
+	ErrHoldNotActive          = errors.New("hold is no longer active")
+	ErrHoldExpired            = errors.New("hold has expired")
+	ErrCaptureExceedsHold     = errors.New("capture amount exceeds held amount")
+	ErrInvalidOverdraftLimit  = errors.New("overdraft limit cannot be negative")
+	ErrInvalidOwnerID         = errors.New(`owner_id must be "key:" followed by an API key name or "jwt:" followed by a token subject`)
+	ErrScheduleNotFound       = errors.New("schedule not found")
+	ErrInvalidSchedule        = errors.New("schedule needs exactly one of run_at in the future or a valid cron expression")
+	ErrInvalidAsOf            = errors.New("as_of must be an RFC 3339 timestamp")
+	ErrReconciliationRunning  = errors.New("a reconciliation is already running")
+	ErrReportNotFound         = errors.New("reconciliation report not found")
+	ErrUnauthenticated        = errors.New("missing or invalid credentials")
+	ErrForbidden              = errors.New("not allowed")
+	ErrInvalidRole            = errors.New("role must be one of admin, teller, auditor, customer")
+	ErrAPIKeyNotFound         = errors.New("API key not found")
+	ErrRateLimited            = errors.New("rate limit exceeded")
 	ErrFake                   = errors.New("fake error")
+
+	// Transaction history filter errors
+	ErrInvalidDateRange         = errors.New("from and to must be RFC 3339 timestamps or dates, with from before to")
+	ErrInvalidAmountRange       = errors.New("min_amount and max_amount must be non-negative integers in minor units, with min_amount not above max_amount")
+	ErrInvalidDescriptionFilter = errors.New("description filter must be at most 100 characters")
+
+	// Statement errors
+	ErrInvalidStatementPeriod     = ErrInvalidDateRange // statements take the same from and to as history
+	ErrUnsupportedStatementFormat = errors.New("format must be one of csv, ofx, camt053")
 )
+
+// IsRejection reports whether err is a business rule violation that retrying
+// cannot fix, as opposed to an infrastructure failure.
+func IsRejection(err error) bool {
+	return errors.Is(err, ErrInsufficientFunds) ||
+		errors.Is(err, ErrAccountNotFound) ||
+		errors.Is(err, ErrSameAccountTransfer) ||
+		errors.Is(err, ErrInvalidTransactionType) ||
+		errors.Is(err, ErrCurrencyMismatch) ||
+		errors.Is(err, ErrAccountFrozen) ||
+		errors.Is(err, ErrAccountClosed) ||
+		errors.Is(err, ErrHoldNotFound) ||
+		errors.Is(err, ErrHoldNotActive) ||
+		errors.Is(err, ErrHoldExpired) ||
+		errors.Is(err, ErrCaptureExceedsHold)
+}
@@ -0,0 +1,15 @@
+package queue
+
+import "time"
+
+// backoff returns base doubled for every attempt after the first, capped at max.
+func backoff(base, max time.Duration, attempt int) time.Duration {
+	d := base
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
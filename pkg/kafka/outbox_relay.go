@@ -0,0 +1,131 @@
+package queue
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"time"
+
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/repository/postgres"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+	"github.com/imranzahoor/banking-ledger/pkg/tracing"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	outboxBaseBackoff = time.Second
+	outboxMaxBackoff  = 5 * time.Minute
+)
+
+// OutboxRelay publishes messages recorded in the Postgres outbox to Kafka.
+type OutboxRelay struct {
+	kafkaWriter  MessageWriter
+	outboxRepo   postgres.OutboxRepository
+	pollInterval time.Duration
+	batchSize    int
+}
+
+func NewOutboxRelay(cfg config.KafkaConfig, or postgres.OutboxRepository, pollInterval time.Duration, batchSize int) *OutboxRelay {
+	w := &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}
+	return NewOutboxRelayWithWriter(w, or, pollInterval, batchSize)
+}
+
+// NewOutboxRelayWithWriter builds a relay that publishes through w, which Run
+// closes when it returns.
+func NewOutboxRelayWithWriter(w MessageWriter, or postgres.OutboxRepository, pollInterval time.Duration, batchSize int) *OutboxRelay {
+	return &OutboxRelay{
+		kafkaWriter:  w,
+		outboxRepo:   or,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+	}
+}
+
+// Run polls the outbox until ctx is cancelled. Full batches are drained
+// without waiting for the next tick.
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	defer r.kafkaWriter.Close()
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		sent, err := r.outboxRepo.PublishPending(ctx, r.batchSize, r.publish(ctx), outboxBackoff)
+		if err != nil {
+			slog.ErrorContext(ctx, "outbox relay", "error", err)
+		}
+		if sent == r.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// publish writes a batch of outbox messages. Each gets a producer span that
+// continues the trace it was enqueued under and is passed on in its headers.
+func (r *OutboxRelay) publish(ctx context.Context) func([]model.OutboxMessage) error {
+	return func(msgs []model.OutboxMessage) error {
+		kmsgs := make([]kafka.Message, len(msgs))
+		spans := make([]trace.Span, len(msgs))
+		for i, m := range msgs {
+			msgCtx, span := tracing.Tracer().Start(tracing.ExtractHeaders(ctx, m.Headers), m.Topic+" publish",
+				trace.WithSpanKind(trace.SpanKindProducer),
+				trace.WithAttributes(
+					attribute.String("messaging.system", "kafka"),
+					attribute.String("messaging.destination.name", m.Topic),
+					attribute.String("messaging.kafka.message.key", m.Key),
+				))
+			spans[i] = span
+
+			headers := maps.Clone(m.Headers)
+			kmsgs[i] = kafka.Message{
+				Topic:   m.Topic,
+				Key:     []byte(m.Key),
+				Value:   m.Payload,
+				Headers: messageHeaders(tracing.InjectHeaders(msgCtx, headers)),
+			}
+		}
+
+		err := r.kafkaWriter.WriteMessages(ctx, kmsgs...)
+		for _, span := range spans {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "publishing failed")
+			}
+			span.End()
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "outbox relay: publishing failed", "messages", len(msgs), "error", err)
+			return err
+		}
+		return nil
+	}
+}
+
+func messageHeaders(headers map[string]string) []kafka.Header {
+	if len(headers) == 0 {
+		return nil
+	}
+	kh := make([]kafka.Header, 0, len(headers))
+	for k, v := range headers {
+		kh = append(kh, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	return kh
+}
+
+func outboxBackoff(attempts int) time.Duration {
+	return backoff(outboxBaseBackoff, outboxMaxBackoff, attempts)
+}
@@ -0,0 +1,42 @@
+package queue
+
+import (
+	"context"
+	"errors"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Ping succeeds once any of brokers answers a metadata request before ctx is
+// done, which is all the producers and the consumer need to make progress.
+func Ping(ctx context.Context, brokers []string) error {
+	if len(brokers) == 0 {
+		return errors.New("no Kafka brokers configured")
+	}
+
+	var errs []error
+	for _, broker := range brokers {
+		err := pingBroker(ctx, broker)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func pingBroker(ctx context.Context, broker string) error {
+	conn, err := kafka.DialContext(ctx, "tcp", broker)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	_, err = conn.Brokers()
+	return err
+}
@@ -4,23 +4,63 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/imranzahoor/banking-ledger/internal/model"
 	"github.com/imranzahoor/banking-ledger/internal/repository/mongo"
 	"github.com/imranzahoor/banking-ledger/internal/repository/postgres"
 	"github.com/imranzahoor/banking-ledger/pkg/config"
 	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	apperrors "github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/pkg/logger"
+	"github.com/imranzahoor/banking-ledger/pkg/metrics"
+	"github.com/imranzahoor/banking-ledger/pkg/tracing"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultStatsInterval is how often reader stats are sampled when the config
+// does not say.
+const defaultStatsInterval = 5 * time.Second
+
+// Headers attached to messages published to the dead-letter topic.
+const (
+	headerDLQError     = "dlq-error"
+	headerDLQTopic     = "dlq-original-topic"
+	headerDLQPartition = "dlq-original-partition"
+	headerDLQOffset    = "dlq-original-offset"
+	headerDLQAttempts  = "dlq-attempts"
+	headerDLQFailedAt  = "dlq-failed-at"
 )
 
 type TransactionConsumer struct {
-	kafkaReader *kafka.Reader
-	accountRepo *postgres.AccountRepo
-	ledgerRepo  *mongo.LedgerRepo
+	kafkaReader     MessageReader
+	dlqWriter       MessageWriter
+	accountRepo     postgres.AccountRepository
+	ledgerRepo      mongo.LedgerRepository
+	transactionRepo postgres.TransactionRepository
+
+	topic           string
+	maxRetries      int
+	retryBackoff    time.Duration
+	maxRetryBackoff time.Duration
+	statsInterval   time.Duration
+
+	state atomic.Value // constants.ConsumerState
 }
 
-func NewTransactionConsumer(cfg config.KafkaConfig, ar *postgres.AccountRepo, lr *mongo.LedgerRepo) *TransactionConsumer {
+func NewTransactionConsumer(
+	cfg config.KafkaConfig,
+	ar postgres.AccountRepository,
+	lr mongo.LedgerRepository,
+	tr postgres.TransactionRepository,
+) *TransactionConsumer {
 	r := kafka.NewReader(kafka.ReaderConfig{
 		Brokers:  cfg.Brokers,
 		GroupID:  cfg.GroupID,
@@ -28,54 +68,288 @@ func NewTransactionConsumer(cfg config.KafkaConfig, ar *postgres.AccountRepo, lr
 		MinBytes: 1e3,  // 1KB
 		MaxBytes: 10e6, // 10MB
 	})
-	return &TransactionConsumer{
-		kafkaReader: r,
-		accountRepo: ar,
-		ledgerRepo:  lr,
+	w := &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.DLQTopic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}
+	return NewTransactionConsumerWithClients(cfg, r, w, ar, lr, tr)
+}
+
+// NewTransactionConsumerWithClients builds a consumer that reads cfg.Topic
+// through r and dead-letters through w. Run closes both when it returns.
+func NewTransactionConsumerWithClients(
+	cfg config.KafkaConfig,
+	r MessageReader,
+	w MessageWriter,
+	ar postgres.AccountRepository,
+	lr mongo.LedgerRepository,
+	tr postgres.TransactionRepository,
+) *TransactionConsumer {
+	statsInterval := cfg.StatsInterval
+	if statsInterval <= 0 {
+		statsInterval = defaultStatsInterval
 	}
+	c := &TransactionConsumer{
+		kafkaReader:     r,
+		dlqWriter:       w,
+		accountRepo:     ar,
+		ledgerRepo:      lr,
+		transactionRepo: tr,
+		topic:           cfg.Topic,
+		maxRetries:      cfg.MaxRetries,
+		retryBackoff:    cfg.RetryBackoff,
+		maxRetryBackoff: cfg.MaxRetryBackoff,
+		statsInterval:   statsInterval,
+	}
+	c.state.Store(constants.ConsumerStarting)
+	return c
+}
+
+// State reports whether Run is still joining the consumer group, consuming, or
+// has returned.
+func (c *TransactionConsumer) State() constants.ConsumerState {
+	return c.state.Load().(constants.ConsumerState)
+}
+
+// markRunning records that the reader has joined its group. It never revives
+// a stopped consumer.
+func (c *TransactionConsumer) markRunning() {
+	c.state.CompareAndSwap(constants.ConsumerStarting, constants.ConsumerRunning)
 }
 
+// Run consumes transactions until ctx is cancelled or the reader fails, and
+// returns the reader's error. A message's offset is only committed once it has
+// been processed, rejected or dead-lettered, so a crash mid-way leads to
+// redelivery rather than loss.
 func (c *TransactionConsumer) Run(ctx context.Context) error {
+	defer c.kafkaReader.Close()
+	defer c.dlqWriter.Close()
+	defer c.state.Store(constants.ConsumerStopped)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go c.reportLag(ctx)
+
 	for {
-		m, err := c.kafkaReader.ReadMessage(ctx)
+		m, err := c.kafkaReader.FetchMessage(ctx)
 		if err != nil {
 			return err
 		}
+		c.markRunning()
 
-		var txn model.Transaction
-		if err := json.Unmarshal(m.Value, &txn); err != nil {
-			log.Printf("invalid transaction payload: %v", err)
-			continue
+		if err := c.handleMessage(ctx, m); err != nil {
+			return err
 		}
 
-		// Process transaction (reuse ProcessTransaction logic)
-		err = c.processTransaction(ctx, &txn)
-		if err != nil {
-			log.Printf("failed to process transaction ID %s: %v", txn.ID, err)
+		if err := c.kafkaReader.CommitMessages(ctx, m); err != nil {
+			return err
 		}
 	}
 }
 
-func (c *TransactionConsumer) processTransaction(ctx context.Context, txn *model.Transaction) error {
-	var delta int64
-	switch txn.Type {
-	case constants.Deposit:
-		delta = txn.Amount
-	case constants.Withdrawal:
-		delta = -txn.Amount
-	default:
-		return errors.New("invalid transaction type")
+// handleMessage processes m, retrying transient failures with exponential
+// backoff. Malformed messages and messages that still fail after maxRetries
+// are published to the dead-letter topic. It only returns an error if ctx is
+// cancelled or the dead-letter topic cannot be reached before m is dealt with.
+// Everything is logged under the request ID the message was published with, in
+// a span that continues its trace.
+func (c *TransactionConsumer) handleMessage(ctx context.Context, m kafka.Message) error {
+	headers := headerMap(m)
+	ctx = logger.WithRequestID(ctx, headers[logger.MessageHeader])
+	ctx, span := tracing.Tracer().Start(tracing.ExtractHeaders(ctx, headers), m.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", m.Topic),
+			attribute.Int("messaging.destination.partition.id", m.Partition),
+			attribute.Int64("messaging.kafka.offset", m.Offset),
+			attribute.String("messaging.kafka.message.key", string(m.Key)),
+		))
+	defer span.End()
+
+	var txn model.Transaction
+	if err := json.Unmarshal(m.Value, &txn); err != nil {
+		slog.ErrorContext(ctx, "invalid transaction payload", "offset", m.Offset, "error", err)
+		metrics.TransactionsFailed.WithLabelValues("unknown", "invalid_payload").Inc()
+		span.SetStatus(codes.Error, "invalid payload")
+		return c.deadLetter(ctx, m, err, 0)
 	}
+	txnLog := slog.With("transaction_id", txn.ID, "type", txn.Type)
+	span.SetAttributes(attribute.String("transaction.id", txn.ID.String()), attribute.String("transaction.type", string(txn.Type)))
+
+	for attempt := 1; ; attempt++ {
+		err := c.ProcessTransaction(ctx, &txn)
+		if err == nil {
+			metrics.TransactionsProcessed.WithLabelValues(string(txn.Type)).Inc()
+			txnLog.InfoContext(ctx, "transaction processed", "attempts", attempt)
+			return nil
+		}
+		if apperrors.IsRejection(err) {
+			metrics.TransactionsFailed.WithLabelValues(string(txn.Type), rejectionReason(err)).Inc()
+			txnLog.WarnContext(ctx, "transaction rejected", "error", err)
+			// a rejection is an outcome, not a failure of the consumer
+			span.SetAttributes(attribute.String("transaction.rejection", rejectionReason(err)))
+			return nil
+		}
+		if attempt > c.maxRetries {
+			txnLog.ErrorContext(ctx, "transaction failed", "attempts", attempt, "error", err)
+			metrics.TransactionsFailed.WithLabelValues(string(txn.Type), "retries_exhausted").Inc()
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "retries exhausted")
+			// a no-op if the balances were applied and only the ledger write
+			// failed; replaying the dead letter then completes the ledger
+			if markErr := c.transactionRepo.MarkFailed(ctx, &txn, err.Error()); markErr != nil {
+				txnLog.ErrorContext(ctx, "marking transaction failed", "error", markErr)
+			}
+			return c.deadLetter(ctx, m, err, attempt)
+		}
 
-	if err := c.accountRepo.UpdateBalance(ctx, txn.AccountID, delta); err != nil {
+		metrics.TransactionRetries.Inc()
+		delay := backoff(c.retryBackoff, c.maxRetryBackoff, attempt)
+		txnLog.WarnContext(ctx, "processing transaction, retrying", "attempt", attempt, "retry_in", delay, "error", err)
+		span.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", attempt), attribute.String("error", err.Error())))
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// deadLetter publishes the original message with error metadata to the
+// dead-letter topic, retrying as often as processing is retried. If every
+// attempt fails the error is returned, which stops the consumer with m
+// uncommitted: it is redelivered after a restart rather than dropped.
+func (c *TransactionConsumer) deadLetter(ctx context.Context, m kafka.Message, cause error, attempts int) error {
+	dlq := kafka.Message{
+		Key:   m.Key,
+		Value: m.Value,
+		Headers: append(append([]kafka.Header(nil), m.Headers...),
+			kafka.Header{Key: headerDLQError, Value: []byte(cause.Error())},
+			kafka.Header{Key: headerDLQTopic, Value: []byte(m.Topic)},
+			kafka.Header{Key: headerDLQPartition, Value: []byte(strconv.Itoa(m.Partition))},
+			kafka.Header{Key: headerDLQOffset, Value: []byte(strconv.FormatInt(m.Offset, 10))},
+			kafka.Header{Key: headerDLQAttempts, Value: []byte(strconv.Itoa(attempts))},
+			kafka.Header{Key: headerDLQFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339Nano))},
+		),
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := c.dlqWriter.WriteMessages(ctx, dlq)
+		if err == nil {
+			metrics.DeadLetterWrites.WithLabelValues("ok").Inc()
+			return nil
+		}
+
+		metrics.DeadLetterWrites.WithLabelValues("error").Inc()
+		slog.ErrorContext(ctx, "publishing to dead-letter topic", "offset", m.Offset, "attempt", attempt, "error", err)
+		if attempt > c.maxRetries {
+			return fmt.Errorf("publishing offset %d to dead-letter topic: %w", m.Offset, err)
+		}
+		if err := sleep(ctx, backoff(c.retryBackoff, c.maxRetryBackoff, attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+// rejectionReasons label rejected transactions in transactions_failed_total.
+var rejectionReasons = []struct {
+	err    error
+	reason string
+}{
+	{apperrors.ErrInsufficientFunds, "insufficient_funds"},
+	{apperrors.ErrAccountNotFound, "account_not_found"},
+	{apperrors.ErrSameAccountTransfer, "same_account_transfer"},
+	{apperrors.ErrInvalidTransactionType, "invalid_transaction_type"},
+	{apperrors.ErrCurrencyMismatch, "currency_mismatch"},
+	{apperrors.ErrAccountFrozen, "account_frozen"},
+	{apperrors.ErrAccountClosed, "account_closed"},
+	{apperrors.ErrHoldNotFound, "hold_not_found"},
+	{apperrors.ErrHoldNotActive, "hold_not_active"},
+	{apperrors.ErrHoldExpired, "hold_expired"},
+	{apperrors.ErrCaptureExceedsHold, "capture_exceeds_hold"},
+}
+
+func rejectionReason(err error) string {
+	for _, r := range rejectionReasons {
+		if errors.Is(err, r.err) {
+			return r.reason
+		}
+	}
+	return "rejected"
+}
+
+// reportLag publishes the reader's lag until ctx is cancelled. The first
+// rebalance it sees means the reader has joined its group, which marks the
+// consumer running even while the topic is idle.
+func (c *TransactionConsumer) reportLag(ctx context.Context) {
+	ticker := time.NewTicker(c.statsInterval)
+	defer ticker.Stop()
+
+	lag := metrics.ConsumerLag.WithLabelValues(c.topic)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Stats resets its counters, so this is the reader's only caller
+			stats := c.kafkaReader.Stats()
+			if stats.Rebalances > 0 {
+				c.markRunning()
+			}
+			lag.Set(float64(stats.Lag))
+		}
+	}
+}
+
+// headerMap returns the message headers by key; the last value of a repeated
+// header wins.
+func headerMap(m kafka.Message) map[string]string {
+	headers := make(map[string]string, len(m.Headers))
+	for _, h := range m.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	return headers
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// ProcessTransaction applies txn exactly once. Balances and the processed
+// marker are committed together in Postgres; the ledger entries are then
+// written idempotently, so a redelivered transaction only completes whatever
+// step a previous attempt did not finish.
+func (c *TransactionConsumer) ProcessTransaction(ctx context.Context, txn *model.Transaction) error {
+	processed, err := c.accountRepo.ApplyTransaction(ctx, txn)
+	if err != nil {
 		return err
 	}
+	if processed.LedgerWritten {
+		return nil
+	}
 
-	if err := c.ledgerRepo.InsertTransaction(ctx, txn); err != nil {
-		// rollback balance update
-		_ = c.accountRepo.UpdateBalance(ctx, txn.AccountID, -delta)
+	if err := c.recordLedger(ctx, txn); err != nil {
 		return err
 	}
+	return c.accountRepo.MarkLedgerWritten(ctx, txn.ID)
+}
 
-	return nil
+// recordLedger writes the balanced journal entry for the transaction followed by
+// the per-account ledger entries used for transaction history. Both writes are
+// keyed by transaction ID, so a retry after a partial failure completes the
+// ledger without duplicating what the failed attempt stored.
+func (c *TransactionConsumer) recordLedger(ctx context.Context, txn *model.Transaction) error {
+	if err := c.ledgerRepo.InsertJournalEntry(ctx, txn.JournalEntry()); err != nil {
+		return err
+	}
+	return c.ledgerRepo.InsertTransactions(ctx, txn.LedgerEntries())
 }
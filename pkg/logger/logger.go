@@ -0,0 +1,80 @@
+// Package logger sets up structured JSON logging and carries the request ID
+// that correlates log lines from the HTTP request through the consumer.
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// RequestIDHeader is the HTTP header a request ID is taken from and echoed in.
+	RequestIDHeader = "X-Request-ID"
+	// MessageHeader is the Kafka message header that carries the request ID.
+	MessageHeader = "request-id"
+)
+
+type requestIDKey struct{}
+
+// New returns a JSON logger writing to w at level ("debug", "info", "warn" or
+// "error", default info) that adds the request ID of the context to every
+// record logged with one.
+func New(w io.Writer, level string) *slog.Logger {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		lvl = slog.LevelInfo
+	}
+	return slog.New(contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{Level: lvl})})
+}
+
+// WithRequestID returns a copy of ctx carrying id; an empty id leaves ctx as is.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// MessageHeaders returns the headers to publish with a message produced while
+// handling ctx, so the consumer can log under the same request ID.
+func MessageHeaders(ctx context.Context) map[string]string {
+	id := RequestID(ctx)
+	if id == "" {
+		return nil
+	}
+	return map[string]string{MessageHeader: id}
+}
+
+// contextHandler adds the request ID and, when tracing, the trace and span
+// IDs from the record's context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
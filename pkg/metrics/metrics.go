@@ -0,0 +1,80 @@
+// Package metrics defines the Prometheus metrics served at /metrics on the
+// metrics port. They are registered with the default registry, next to the Go
+// runtime and process collectors.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "banking_ledger"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to handle HTTP requests, by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	TransactionsEnqueued = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_enqueued_total",
+		Help:      "Transactions recorded as pending for the consumer, by type.",
+	}, []string{"type"})
+
+	TransactionsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_processed_total",
+		Help:      "Transactions the consumer applied, by type.",
+	}, []string{"type"})
+
+	TransactionsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_failed_total",
+		Help:      "Transactions the consumer rejected or gave up on, by type and reason.",
+	}, []string{"type", "reason"})
+
+	TransactionRetries = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transaction_retries_total",
+		Help:      "Times the consumer retried a transaction after a transient failure.",
+	})
+
+	DeadLetterWrites = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dead_letter_writes_total",
+		Help:      "Attempts to publish a message to the dead-letter topic, by outcome.",
+	}, []string{"outcome"})
+
+	ConsumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "kafka_consumer_lag",
+		Help:      "Messages the consumer group is behind the end of the topic, as last reported by the reader.",
+	}, []string{"topic"})
+
+	DBCallDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_call_duration_seconds",
+		Help:      "Time taken by Postgres and MongoDB calls, by store, operation, table or collection and outcome.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"store", "operation", "table", "outcome"})
+)
+
+// ObserveDBCall records a database call that took d.
+func ObserveDBCall(store, operation, table string, d time.Duration, failed bool) {
+	outcome := "ok"
+	if failed {
+		outcome = "error"
+	}
+	DBCallDuration.WithLabelValues(store, operation, table, outcome).Observe(d.Seconds())
+}
@@ -0,0 +1,132 @@
+package statement
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/imranzahoor/banking-ledger/pkg/currency"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// camt053Writer writes an ISO 20022 BankToCustomerStatement. The schema puts
+// both balances ahead of the entries, which is why Header carries the closing
+// balance up front.
+type camt053Writer struct {
+	x      *xmlWriter
+	header Header
+}
+
+func newCamt053Writer(w io.Writer) *camt053Writer {
+	return &camt053Writer{x: newXMLWriter(w)}
+}
+
+func (c *camt053Writer) Begin(h Header) error {
+	c.header = h
+	x := c.x
+	// one statement per message, so both share an ID
+	id := compactID(h.AccountID)[:16] + h.GeneratedAt.UTC().Format("20060102150405")
+
+	x.raw(xml.Header)
+	x.start("Document", xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: camt053Namespace})
+	x.start("BkToCstmrStmt")
+	x.start("GrpHdr")
+	x.elem("MsgId", id)
+	x.elem("CreDtTm", camtTime(h.GeneratedAt))
+	x.end("GrpHdr")
+
+	x.start("Stmt")
+	x.elem("Id", id)
+	x.elem("CreDtTm", camtTime(h.GeneratedAt))
+	x.start("FrToDt")
+	x.elem("FrDtTm", camtTime(h.From))
+	x.elem("ToDtTm", camtTime(h.To))
+	x.end("FrToDt")
+
+	x.start("Acct")
+	x.start("Id")
+	x.start("Othr")
+	x.elem("Id", compactID(h.AccountID))
+	x.end("Othr")
+	x.end("Id")
+	x.elem("Ccy", h.Currency)
+	if h.OwnerName != "" {
+		x.start("Ownr")
+		x.elem("Nm", h.OwnerName)
+		x.end("Ownr")
+	}
+	x.end("Acct")
+
+	c.balance("OPBD", h.Opening, h.From)
+	c.balance("CLBD", h.Closing, h.To)
+	return x.err
+}
+
+func (c *camt053Writer) Entry(e Entry) error {
+	x := c.x
+	x.start("Ntry")
+	c.amount(e.Amount)
+	x.elem("Sts", "BOOK")
+	x.start("BookgDt")
+	x.elem("DtTm", camtTime(e.BookedAt))
+	x.end("BookgDt")
+	x.start("ValDt")
+	x.elem("DtTm", camtTime(e.BookedAt))
+	x.end("ValDt")
+	x.elem("AcctSvcrRef", compactID(e.ID))
+	x.start("BkTxCd")
+	x.start("Prtry")
+	x.elem("Cd", e.Type)
+	x.end("Prtry")
+	x.end("BkTxCd")
+	if e.Description != "" {
+		x.start("NtryDtls")
+		x.start("TxDtls")
+		x.start("RmtInf")
+		x.elem("Ustrd", e.Description)
+		x.end("RmtInf")
+		x.end("TxDtls")
+		x.end("NtryDtls")
+	}
+	x.end("Ntry")
+	return x.err
+}
+
+func (c *camt053Writer) End() error {
+	c.x.end("Stmt")
+	c.x.end("BkToCstmrStmt")
+	c.x.end("Document")
+	c.x.raw("\n")
+	return c.x.err
+}
+
+func (c *camt053Writer) balance(code string, amount int64, at time.Time) {
+	x := c.x
+	x.start("Bal")
+	x.start("Tp")
+	x.start("CdOrPrtry")
+	x.elem("Cd", code)
+	x.end("CdOrPrtry")
+	x.end("Tp")
+	c.amount(amount)
+	x.start("Dt")
+	x.elem("DtTm", camtTime(at))
+	x.end("Dt")
+	x.end("Bal")
+}
+
+// amount writes Amt and CdtDbtInd; camt amounts are unsigned.
+func (c *camt053Writer) amount(amount int64) {
+	indicator := "CRDT"
+	if amount < 0 {
+		indicator, amount = "DBIT", -amount
+	}
+	c.x.elem("Amt", currency.Format(amount, c.header.Currency),
+		xml.Attr{Name: xml.Name{Local: "Ccy"}, Value: c.header.Currency})
+	c.x.elem("CdtDbtInd", indicator)
+}
+
+func camtTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
@@ -0,0 +1,74 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/imranzahoor/banking-ledger/pkg/currency"
+)
+
+var csvColumns = []string{
+	"booked_at", "transaction_id", "type", "description",
+	"counterparty_account_id", "amount", "balance", "currency",
+}
+
+// csvWriter writes one row per entry, framed by opening and closing balance
+// rows so the file adds up on its own.
+type csvWriter struct {
+	w      *csv.Writer
+	header Header
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Begin(h Header) error {
+	c.header = h
+	if err := c.w.Write(csvColumns); err != nil {
+		return err
+	}
+	return c.balanceRow("opening_balance", h.From, h.Opening)
+}
+
+func (c *csvWriter) Entry(e Entry) error {
+	return c.w.Write([]string{
+		e.BookedAt.UTC().Format(time.RFC3339Nano),
+		textCell(e.ID),
+		textCell(e.Type),
+		textCell(e.Description),
+		textCell(e.CounterpartyID),
+		currency.Format(e.Amount, c.header.Currency),
+		currency.Format(e.Balance, c.header.Currency),
+		c.header.Currency,
+	})
+}
+
+func (c *csvWriter) End() error {
+	if err := c.balanceRow("closing_balance", c.header.To, c.header.Closing); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) balanceRow(kind string, at time.Time, balance int64) error {
+	return c.w.Write([]string{
+		at.UTC().Format(time.RFC3339Nano), "", kind, "", "", "",
+		currency.Format(balance, c.header.Currency),
+		c.header.Currency,
+	})
+}
+
+// textCell keeps spreadsheets from evaluating a text cell as a formula by
+// prefixing it with a quote when it starts with a formula character.
+// Descriptions come from API callers, so they cannot be trusted. Amounts are
+// left alone: a leading minus there is a sign.
+func textCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,116 @@
+package statement
+
+import (
+	"io"
+	"time"
+
+	"github.com/imranzahoor/banking-ledger/pkg/currency"
+)
+
+const (
+	ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n" +
+		`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+
+	// ofxBankID fills BANKID, which the ledger has no routing number for.
+	ofxBankID = "000000000"
+)
+
+// ofxWriter writes an OFX 2.2 bank statement response. OFX has no opening
+// balance element, so it is reported in BALLIST next to the ledger balance.
+type ofxWriter struct {
+	x      *xmlWriter
+	header Header
+}
+
+func newOFXWriter(w io.Writer) *ofxWriter {
+	return &ofxWriter{x: newXMLWriter(w)}
+}
+
+func (o *ofxWriter) Begin(h Header) error {
+	o.header = h
+	x := o.x
+	x.raw(ofxHeader)
+
+	x.start("OFX")
+	x.start("SIGNONMSGSRSV1")
+	x.start("SONRS")
+	o.status()
+	x.elem("DTSERVER", ofxTime(h.GeneratedAt))
+	x.elem("LANGUAGE", "ENG")
+	x.end("SONRS")
+	x.end("SIGNONMSGSRSV1")
+
+	x.start("BANKMSGSRSV1")
+	x.start("STMTTRNRS")
+	x.elem("TRNUID", "0")
+	o.status()
+	x.start("STMTRS")
+	x.elem("CURDEF", h.Currency)
+	x.start("BANKACCTFROM")
+	x.elem("BANKID", ofxBankID)
+	x.elem("ACCTID", compactID(h.AccountID))
+	x.elem("ACCTTYPE", "CHECKING")
+	x.end("BANKACCTFROM")
+	x.start("BANKTRANLIST")
+	x.elem("DTSTART", ofxTime(h.From))
+	x.elem("DTEND", ofxTime(h.To))
+	return x.err
+}
+
+func (o *ofxWriter) Entry(e Entry) error {
+	x := o.x
+	trnType := "CREDIT"
+	if e.Amount < 0 {
+		trnType = "DEBIT"
+	}
+
+	x.start("STMTTRN")
+	x.elem("TRNTYPE", trnType)
+	x.elem("DTPOSTED", ofxTime(e.BookedAt))
+	x.elem("TRNAMT", currency.Format(e.Amount, o.header.Currency))
+	x.elem("FITID", e.ID)
+	x.elem("NAME", e.Type)
+	if e.Description != "" {
+		x.elem("MEMO", e.Description)
+	}
+	x.end("STMTTRN")
+	return x.err
+}
+
+func (o *ofxWriter) End() error {
+	x, h := o.x, o.header
+	x.end("BANKTRANLIST")
+
+	x.start("LEDGERBAL")
+	x.elem("BALAMT", currency.Format(h.Closing, h.Currency))
+	x.elem("DTASOF", ofxTime(h.To))
+	x.end("LEDGERBAL")
+
+	x.start("BALLIST")
+	x.start("BAL")
+	x.elem("NAME", "Opening balance")
+	x.elem("DESC", "Ledger balance at the start of the period")
+	x.elem("BALTYPE", "DOLLAR")
+	x.elem("VALUE", currency.Format(h.Opening, h.Currency))
+	x.elem("DTASOF", ofxTime(h.From))
+	x.end("BAL")
+	x.end("BALLIST")
+
+	x.end("STMTRS")
+	x.end("STMTTRNRS")
+	x.end("BANKMSGSRSV1")
+	x.end("OFX")
+	x.raw("\n")
+	return x.err
+}
+
+func (o *ofxWriter) status() {
+	o.x.start("STATUS")
+	o.x.elem("CODE", "0")
+	o.x.elem("SEVERITY", "INFO")
+	o.x.end("STATUS")
+}
+
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
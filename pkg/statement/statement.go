@@ -0,0 +1,101 @@
+// Package statement renders account statements. Writers stream: the header is
+// written first, then one line per ledger entry, so a statement never has to
+// be held in memory.
+package statement
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	apperrors "github.com/imranzahoor/banking-ledger/pkg/errors"
+)
+
+// Format is a statement file format.
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatOFX     Format = "ofx"     // OFX 2.2
+	FormatCamt053 Format = "camt053" // ISO 20022 camt.053.001.02
+)
+
+// ParseFormat resolves a format name, case-insensitively.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case FormatCSV, FormatOFX, FormatCamt053:
+		return f, nil
+	default:
+		return "", apperrors.ErrUnsupportedStatementFormat
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatOFX:
+		return "application/x-ofx"
+	case FormatCamt053:
+		return "application/xml"
+	default:
+		return "text/csv"
+	}
+}
+
+// Extension is the file extension used for downloads, without the dot.
+func (f Format) Extension() string {
+	if f == FormatCamt053 {
+		return "xml"
+	}
+	return string(f)
+}
+
+// Header describes the account and period a statement covers. Amounts are in
+// minor units of Currency.
+type Header struct {
+	AccountID   string
+	OwnerName   string
+	Currency    string
+	From        time.Time
+	To          time.Time
+	Opening     int64 // balance at From
+	Closing     int64 // balance at To
+	GeneratedAt time.Time
+}
+
+// Entry is one ledger entry on the statement. Amount is signed: credits are
+// positive and debits negative.
+type Entry struct {
+	ID             string
+	BookedAt       time.Time
+	Type           string
+	Description    string
+	CounterpartyID string
+	Amount         int64
+	Balance        int64 // running balance after the entry
+}
+
+// Writer writes a statement: Begin once, Entry for each ledger entry in booking
+// order, then End, which flushes any buffered output.
+type Writer interface {
+	Begin(h Header) error
+	Entry(e Entry) error
+	End() error
+}
+
+// NewWriter returns a Writer producing format f on w.
+func NewWriter(f Format, w io.Writer) Writer {
+	switch f {
+	case FormatOFX:
+		return newOFXWriter(w)
+	case FormatCamt053:
+		return newCamt053Writer(w)
+	default:
+		return newCSVWriter(w)
+	}
+}
+
+// compactID strips the hyphens from a UUID; ISO 20022 and OFX identifiers are
+// limited to 32-35 characters.
+func compactID(id string) string {
+	return strings.ReplaceAll(id, "-", "")
+}
@@ -0,0 +1,51 @@
+package statement
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// xmlWriter emits XML token by token. The first error sticks and every later
+// call is a no-op, so writers check it once per statement line.
+type xmlWriter struct {
+	w   io.Writer
+	enc *xml.Encoder
+	err error
+}
+
+func newXMLWriter(w io.Writer) *xmlWriter {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return &xmlWriter{w: w, enc: enc}
+}
+
+// raw writes s verbatim, e.g. an XML declaration or processing instruction.
+func (x *xmlWriter) raw(s string) {
+	if x.err != nil {
+		return
+	}
+	if x.err = x.enc.Flush(); x.err == nil {
+		_, x.err = io.WriteString(x.w, s)
+	}
+}
+
+func (x *xmlWriter) start(name string, attrs ...xml.Attr) {
+	if x.err == nil {
+		x.err = x.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: name}, Attr: attrs})
+	}
+}
+
+func (x *xmlWriter) end(name string) {
+	if x.err == nil {
+		x.err = x.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}})
+	}
+}
+
+// elem writes <name attrs>value</name>.
+func (x *xmlWriter) elem(name, value string, attrs ...xml.Attr) {
+	x.start(name, attrs...)
+	if x.err == nil {
+		x.err = x.enc.EncodeToken(xml.CharData(value))
+	}
+	x.end(name)
+}
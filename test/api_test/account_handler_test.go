@@ -0,0 +1,77 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/api"
+	"github.com/imranzahoor/banking-ledger/internal/middleware"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/service"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/test/mocks"
+)
+
+var _ = Describe("AccountHandler.CloseAccount", func() {
+	var (
+		mockCtrl *gomock.Controller
+		mockRepo *mocks.MockAccountRepository
+		router   *gin.Engine
+		id       uuid.UUID
+	)
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockRepo = mocks.NewMockAccountRepository(mockCtrl)
+
+		handler := api.NewAccountHandler(service.NewAccountService(mockRepo, mocks.NewMockLedgerRepository(mockCtrl), config.Config{}))
+		router = gin.New()
+		handler.RegisterRoutes(router.Group("/api/v1", middleware.Anonymous()))
+		id = uuid.New()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	closeAccount := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/accounts/"+id.String()+"/close", nil))
+		return w
+	}
+
+	It("should close an empty account", func() {
+		closed := &model.Account{ID: id, Currency: "USD", Status: constants.AccountClosed}
+		mockRepo.EXPECT().CloseAccount(gomock.Any(), id, uuid.Nil, gomock.Any()).Return(closed, nil, nil)
+
+		w := closeAccount()
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).NotTo(ContainSubstring("payout"))
+	})
+
+	DescribeTable("refusing to close",
+		func(err error, status int) {
+			mockRepo.EXPECT().CloseAccount(gomock.Any(), id, uuid.Nil, gomock.Any()).Return(nil, nil, err)
+
+			w := closeAccount()
+			Expect(w.Code).To(Equal(status))
+			var body map[string]string
+			Expect(json.Unmarshal(w.Body.Bytes(), &body)).To(Succeed())
+			Expect(body["error"]).To(Equal(err.Error()))
+		},
+		Entry("an unknown account", errors.ErrAccountNotFound, http.StatusNotFound),
+		Entry("an account already closed", errors.ErrAccountClosed, http.StatusConflict),
+		Entry("an account with money left and nowhere to pay it", errors.ErrAccountNotEmpty, http.StatusConflict),
+		Entry("an account with active holds", errors.ErrActiveHolds, http.StatusConflict),
+	)
+})
@@ -0,0 +1,239 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/api"
+	"github.com/imranzahoor/banking-ledger/internal/middleware"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/service"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/test/mocks"
+)
+
+var _ = Describe("Authorization", func() {
+	const secret = "test-secret"
+
+	var (
+		mockCtrl    *gomock.Controller
+		mockRepo    *mocks.MockAccountRepository
+		mockLedger  *mocks.MockLedgerRepository
+		mockTxnRepo *mocks.MockTransactionRepository
+		router      *gin.Engine
+		own, other  *model.Account
+	)
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockRepo = mocks.NewMockAccountRepository(mockCtrl)
+		mockLedger = mocks.NewMockLedgerRepository(mockCtrl)
+		mockTxnRepo = mocks.NewMockTransactionRepository(mockCtrl)
+
+		cfg := config.Config{AuthEnabled: true, AdminAPIKey: "bootstrap-admin-key", JWTHMACSecret: secret}
+		authSvc, err := service.NewAuthService(mocks.NewMockAPIKeyRepository(mockCtrl), cfg)
+		Expect(err).To(BeNil())
+		accountSvc := service.NewAccountService(mockRepo, mockLedger, cfg)
+		transactionSvc := service.NewTransactionService(mockRepo, mockLedger, mocks.NewMockIdempotencyRepository(mockCtrl), mockTxnRepo, cfg)
+
+		router = gin.New()
+		group := router.Group("/api/v1", middleware.Authenticate(authSvc))
+		api.NewAccountHandler(accountSvc).RegisterRoutes(group)
+		api.NewTransactionHandler(transactionSvc, accountSvc, cfg).RegisterRoutes(group)
+
+		own = &model.Account{ID: uuid.New(), OwnerName: "Alice", OwnerID: "jwt:user-42", Balance: 1000, Currency: "USD", Status: constants.AccountActive}
+		other = &model.Account{ID: uuid.New(), OwnerName: "Bob", OwnerID: "jwt:user-7", Balance: 1000, Currency: "USD", Status: constants.AccountActive}
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), own.ID.String()).Return(own, nil).AnyTimes()
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), other.ID.String()).Return(other, nil).AnyTimes()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	// send makes a request as user-42 acting with role, or without
+	// credentials when role is empty.
+	send := func(role constants.Role, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if role != "" {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"sub":  "user-42",
+				"role": string(role),
+				"exp":  time.Now().Add(time.Hour).Unix(),
+			}).SignedString([]byte(secret))
+			Expect(err).To(BeNil())
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	It("should answer 401 without credentials", func() {
+		w := send("", http.MethodGet, "/api/v1/accounts/"+own.ID.String(), "")
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		Expect(w.Header().Get("WWW-Authenticate")).NotTo(BeEmpty())
+	})
+
+	It("should answer 401 for a role it does not know", func() {
+		Expect(send("superuser", http.MethodGet, "/api/v1/accounts/"+own.ID.String(), "").Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	DescribeTable("managing accounts",
+		func(role constants.Role, allowed bool) {
+			if allowed {
+				mockRepo.EXPECT().SetOwner(gomock.Any(), other.ID, "jwt:user-9").Return(other, nil)
+			}
+			w := send(role, http.MethodPut, "/api/v1/accounts/"+other.ID.String()+"/owner", `{"owner_id": "jwt:user-9"}`)
+			if allowed {
+				Expect(w.Code).To(Equal(http.StatusOK))
+			} else {
+				Expect(w.Code).To(Equal(http.StatusForbidden))
+			}
+		},
+		Entry("admin", constants.RoleAdmin, true),
+		Entry("teller", constants.RoleTeller, false),
+		Entry("auditor", constants.RoleAuditor, false),
+		Entry("customer", constants.RoleCustomer, false),
+	)
+
+	It("should reject an owner that names no kind of caller", func() {
+		w := send(constants.RoleAdmin, http.MethodPut, "/api/v1/accounts/"+other.ID.String()+"/owner", `{"owner_id": "user-9"}`)
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should not let an API key act for the token subject it is named after", func() {
+		mockKeys := mocks.NewMockAPIKeyRepository(mockCtrl)
+		cfg := config.Config{AuthEnabled: true, AdminAPIKey: "bootstrap-admin-key", JWTHMACSecret: secret}
+		authSvc, err := service.NewAuthService(mockKeys, cfg)
+		Expect(err).To(BeNil())
+		router = gin.New()
+		api.NewAccountHandler(service.NewAccountService(mockRepo, mockLedger, cfg)).
+			RegisterRoutes(router.Group("/api/v1", middleware.Authenticate(authSvc)))
+
+		// a customer key named like the owner's token subject
+		mockKeys.EXPECT().
+			GetByHash(gomock.Any(), gomock.Any()).
+			Return(&model.APIKey{ID: uuid.New(), Name: "user-42", Role: constants.RoleCustomer}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/accounts/"+own.ID.String(), nil)
+		req.Header.Set(middleware.APIKeyHeader, "bl_0123456789abcdef")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusForbidden))
+	})
+
+	DescribeTable("reading the ledger",
+		func(role constants.Role, allowed bool) {
+			if allowed {
+				mockLedger.EXPECT().GetTrialBalance(gomock.Any()).Return([]model.TrialBalance{}, nil)
+			}
+			w := send(role, http.MethodGet, "/api/v1/ledger/trial-balance", "")
+			if allowed {
+				Expect(w.Code).To(Equal(http.StatusOK))
+			} else {
+				Expect(w.Code).To(Equal(http.StatusForbidden))
+			}
+		},
+		Entry("admin", constants.RoleAdmin, true),
+		Entry("teller", constants.RoleTeller, false),
+		Entry("auditor", constants.RoleAuditor, true),
+		Entry("customer", constants.RoleCustomer, false),
+	)
+
+	DescribeTable("reading an account",
+		func(role constants.Role, owned bool, status int) {
+			acc := other
+			if owned {
+				acc = own
+			}
+			Expect(send(role, http.MethodGet, "/api/v1/accounts/"+acc.ID.String(), "").Code).To(Equal(status))
+		},
+		Entry("admin", constants.RoleAdmin, false, http.StatusOK),
+		Entry("teller", constants.RoleTeller, false, http.StatusOK),
+		Entry("auditor", constants.RoleAuditor, false, http.StatusOK),
+		Entry("customer owning it", constants.RoleCustomer, true, http.StatusOK),
+		Entry("customer not owning it", constants.RoleCustomer, false, http.StatusForbidden),
+	)
+
+	DescribeTable("posting a transaction",
+		func(role constants.Role, typ constants.TransactionType, owned, allowed bool) {
+			from, to := other, own
+			if owned {
+				from, to = own, other
+			}
+			if allowed {
+				mockTxnRepo.EXPECT().CreatePending(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			}
+			w := send(role, http.MethodPost, "/api/v1/transactions", `{"account_id": "`+from.ID.String()+
+				`", "to_account_id": "`+to.ID.String()+`", "amount": 100, "type": "`+string(typ)+`"}`)
+			if allowed {
+				Expect(w.Code).To(Equal(http.StatusAccepted))
+			} else {
+				Expect(w.Code).To(Equal(http.StatusForbidden))
+			}
+		},
+		Entry("admin transferring", constants.RoleAdmin, constants.Transfer, false, true),
+		Entry("teller depositing", constants.RoleTeller, constants.Deposit, false, true),
+		Entry("teller transferring", constants.RoleTeller, constants.Transfer, false, false),
+		Entry("auditor depositing", constants.RoleAuditor, constants.Deposit, false, false),
+		Entry("customer transferring from their account", constants.RoleCustomer, constants.Transfer, true, true),
+		Entry("customer transferring from another's account", constants.RoleCustomer, constants.Transfer, false, false),
+		Entry("customer withdrawing", constants.RoleCustomer, constants.Withdrawal, true, false),
+	)
+
+	Describe("looking up a transaction", func() {
+		lookUp := func(role constants.Role, txn *model.Transaction) int {
+			mockTxnRepo.EXPECT().GetByID(gomock.Any(), txn.ID).Return(txn, nil)
+			return send(role, http.MethodGet, "/api/v1/transactions/"+txn.ID.String(), "").Code
+		}
+
+		transfer := func(from, to *model.Account) *model.Transaction {
+			return &model.Transaction{
+				ID:             uuid.New(),
+				AccountID:      from.ID,
+				CounterpartyID: to.ID,
+				Type:           constants.Transfer,
+				Amount:         100,
+				Currency:       "USD",
+				Status:         constants.StatusCompleted,
+			}
+		}
+
+		It("should let a customer see a transfer from their account", func() {
+			Expect(lookUp(constants.RoleCustomer, transfer(own, other))).To(Equal(http.StatusOK))
+		})
+
+		It("should let a customer see a transfer into their account", func() {
+			Expect(lookUp(constants.RoleCustomer, transfer(other, own))).To(Equal(http.StatusOK))
+		})
+
+		It("should hide transactions between other customers' accounts", func() {
+			stranger := &model.Account{ID: uuid.New(), OwnerID: "jwt:user-9", Currency: "USD"}
+			mockRepo.EXPECT().GetAccountByID(gomock.Any(), stranger.ID.String()).Return(stranger, nil)
+			Expect(lookUp(constants.RoleCustomer, transfer(other, stranger))).To(Equal(http.StatusForbidden))
+		})
+
+		It("should hide another customer's deposit", func() {
+			deposit := &model.Transaction{ID: uuid.New(), AccountID: other.ID, Type: constants.Deposit, Amount: 100, Currency: "USD"}
+			Expect(lookUp(constants.RoleCustomer, deposit)).To(Equal(http.StatusForbidden))
+		})
+
+		It("should let staff see any transaction", func() {
+			Expect(lookUp(constants.RoleTeller, transfer(other, other))).To(Equal(http.StatusOK))
+			Expect(lookUp(constants.RoleAuditor, transfer(other, other))).To(Equal(http.StatusOK))
+		})
+	})
+})
@@ -0,0 +1,110 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/gin-gonic/gin"
+	"github.com/imranzahoor/banking-ledger/internal/api"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+)
+
+var _ = Describe("HealthHandler", func() {
+	var (
+		consumerState constants.ConsumerState
+		mongoErr      error
+		kafkaDelay    time.Duration
+		router        *gin.Engine
+	)
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		consumerState = constants.ConsumerRunning
+		mongoErr = nil
+		kafkaDelay = 0
+
+		handler := api.NewHealthHandler(50*time.Millisecond, func() constants.ConsumerState { return consumerState },
+			api.HealthCheck{Name: "postgres", Ping: func(context.Context) error { return nil }},
+			api.HealthCheck{Name: "mongo", Ping: func(context.Context) error { return mongoErr }},
+			api.HealthCheck{Name: "kafka", Ping: func(ctx context.Context) error {
+				select {
+				case <-time.After(kafkaDelay):
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}},
+		)
+		router = gin.New()
+		handler.RegisterRoutes(router)
+	})
+
+	get := func(path string) (int, map[string]any) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var body map[string]any
+		Expect(json.Unmarshal(w.Body.Bytes(), &body)).To(Succeed())
+		return w.Code, body
+	}
+
+	check := func(body map[string]any, name string) map[string]any {
+		return body["checks"].(map[string]any)[name].(map[string]any)
+	}
+
+	It("should report the process alive regardless of dependencies", func() {
+		mongoErr = errors.ErrFake
+		code, body := get("/healthz")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(HaveKeyWithValue("status", "ok"))
+	})
+
+	It("should be ready when every dependency is up and the consumer runs", func() {
+		code, body := get("/readyz")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(HaveKeyWithValue("status", "ready"))
+		Expect(body).To(HaveKeyWithValue("consumer", "running"))
+		for _, name := range []string{"postgres", "mongo", "kafka"} {
+			Expect(check(body, name)).To(HaveKeyWithValue("status", "up"))
+		}
+	})
+
+	It("should report a failing dependency", func() {
+		mongoErr = errors.ErrFake
+		code, body := get("/readyz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body).To(HaveKeyWithValue("status", "not_ready"))
+		Expect(check(body, "mongo")).To(HaveKeyWithValue("status", "down"))
+		Expect(check(body, "mongo")).NotTo(HaveKey("error"))
+		Expect(check(body, "postgres")).To(HaveKeyWithValue("status", "up"))
+	})
+
+	It("should give up on a dependency that does not answer in time", func() {
+		kafkaDelay = time.Second
+		start := time.Now()
+		code, body := get("/readyz")
+		Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(check(body, "kafka")).To(HaveKeyWithValue("status", "down"))
+	})
+
+	It("should not be ready until the consumer runs", func() {
+		consumerState = constants.ConsumerStarting
+		code, body := get("/readyz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body).To(HaveKeyWithValue("consumer", "starting"))
+	})
+
+	It("should not be ready once the consumer has stopped", func() {
+		consumerState = constants.ConsumerStopped
+		code, body := get("/readyz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body).To(HaveKeyWithValue("consumer", "stopped"))
+	})
+})
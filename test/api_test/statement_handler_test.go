@@ -0,0 +1,180 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/api"
+	"github.com/imranzahoor/banking-ledger/internal/middleware"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/service"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/test/mocks"
+)
+
+var _ = Describe("AccountHandler.GetStatement", func() {
+	var (
+		mockCtrl   *gomock.Controller
+		mockRepo   *mocks.MockAccountRepository
+		mockLedger *mocks.MockLedgerRepository
+		router     *gin.Engine
+		account    *model.Account
+		from, to   time.Time
+		period     string
+	)
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockRepo = mocks.NewMockAccountRepository(mockCtrl)
+		mockLedger = mocks.NewMockLedgerRepository(mockCtrl)
+
+		handler := api.NewAccountHandler(service.NewAccountService(mockRepo, mockLedger, config.Config{}))
+		router = gin.New()
+		handler.RegisterRoutes(router.Group("/api/v1", middleware.Anonymous()))
+
+		account = &model.Account{ID: uuid.New(), OwnerName: "Alice", Currency: "USD", Status: constants.AccountActive}
+		from = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+		to = time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+		period = fmt.Sprintf("from=%s&to=%s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/accounts/"+account.ID.String()+"/statement?"+query, nil))
+		return w
+	}
+
+	errorOf := func(w *httptest.ResponseRecorder) string {
+		var body map[string]string
+		Expect(json.Unmarshal(w.Body.Bytes(), &body)).To(Succeed())
+		return body["error"]
+	}
+
+	// expectStatement streams n deposits of 1.00, calling during after each
+	// one; a non-nil error from during cuts the statement short.
+	expectStatement := func(n int, during func(i int) error) {
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), account.ID.String()).Return(account, nil)
+		mockLedger.EXPECT().
+			GetBalanceAsOf(gomock.Any(), account.ID, from).
+			Return(&model.BalanceSnapshot{AccountID: account.ID, AsOf: from}, nil)
+		mockLedger.EXPECT().
+			GetBalanceAsOf(gomock.Any(), account.ID, to).
+			Return(&model.BalanceSnapshot{AccountID: account.ID, Balance: int64(n) * 100, AsOf: to}, nil)
+		mockLedger.EXPECT().
+			StreamTransactionsByAccountID(gomock.Any(), account.ID, from, to, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ uuid.UUID, _, _ time.Time, fn func(*model.Transaction) error) error {
+				for i := 0; i < n; i++ {
+					err := fn(&model.Transaction{
+						ID: uuid.New(), Type: constants.Deposit, Direction: constants.Credit,
+						Amount: 100, CreatedAt: from.Add(time.Duration(i) * time.Minute),
+					})
+					if err != nil {
+						return err
+					}
+					if err := during(i); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+	}
+
+	It("should stream the statement as a download while entries are read", func() {
+		var w *httptest.ResponseRecorder
+		flushedEarly := false
+		expectStatement(500, func(i int) error {
+			if i == 499 {
+				// the CSV writer has had to flush its buffer by now
+				flushedEarly = w.Body.Len() > 0
+			}
+			return nil
+		})
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/accounts/"+account.ID.String()+"/statement?"+period, nil))
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(flushedEarly).To(BeTrue())
+		Expect(w.Header().Get("Content-Type")).To(Equal("text/csv"))
+		Expect(w.Header().Get("Content-Disposition")).To(Equal(
+			fmt.Sprintf(`attachment; filename="statement-%s-20260301-20260401.csv"`, account.ID)))
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		Expect(lines).To(HaveLen(503))
+		Expect(lines[502]).To(ContainSubstring("closing_balance,,,,500.00,USD"))
+	})
+
+	It("should cut the statement short when the ledger fails mid-stream", func() {
+		expectStatement(3, func(i int) error {
+			if i == 1 {
+				return errors.ErrFake
+			}
+			return nil
+		})
+
+		w := get(period + "&format=ofx")
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/x-ofx"))
+		Expect(w.Body.String()).To(HavePrefix("<?xml"))
+		Expect(w.Body.String()).NotTo(ContainSubstring("</OFX>"))
+		Expect(w.Body.String()).NotTo(ContainSubstring(errors.ErrFake.Error()))
+	})
+
+	It("should answer with a JSON error when the ledger fails before anything is sent", func() {
+		expectStatement(3, func(i int) error {
+			if i == 1 {
+				return errors.ErrFake
+			}
+			return nil
+		})
+
+		w := get(period)
+
+		Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		Expect(errorOf(w)).To(Equal(errors.ErrFake.Error()))
+	})
+
+	DescribeTable("should reject a bad period",
+		func(query string) {
+			w := get(query)
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			Expect(errorOf(w)).To(Equal(errors.ErrInvalidStatementPeriod.Error()))
+		},
+		Entry("from after to", "from=2026-04-02&to=2026-04-01"),
+		Entry("from equal to to", "from=2026-04-01T00:00:00Z&to=2026-04-01T00:00:00Z"),
+		Entry("unparseable from", "from=yesterday&to=2026-04-01"),
+		Entry("unparseable to", "to=04/01/2026"),
+	)
+
+	It("should reject an unsupported format", func() {
+		w := get(period + "&format=pdf")
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+		Expect(errorOf(w)).To(Equal(errors.ErrUnsupportedStatementFormat.Error()))
+	})
+
+	It("should answer 404 for an unknown account", func() {
+		mockRepo.EXPECT().GetAccountByID(gomock.Any(), account.ID.String()).Return(nil, nil)
+
+		w := get(period)
+		Expect(w.Code).To(Equal(http.StatusNotFound))
+		Expect(errorOf(w)).To(Equal(errors.ErrAccountNotFound.Error()))
+	})
+})
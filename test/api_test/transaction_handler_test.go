@@ -0,0 +1,256 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/api"
+	"github.com/imranzahoor/banking-ledger/internal/middleware"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/service"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/test/mocks"
+)
+
+var _ = Describe("TransactionHandler.CreateTransaction", func() {
+	const secret = "test-secret"
+
+	var (
+		mockCtrl    *gomock.Controller
+		mockRepo    *mocks.MockAccountRepository
+		mockIdem    *mocks.MockIdempotencyRepository
+		mockTxnRepo *mocks.MockTransactionRepository
+		router      *gin.Engine
+		account     *model.Account
+	)
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockRepo = mocks.NewMockAccountRepository(mockCtrl)
+		mockIdem = mocks.NewMockIdempotencyRepository(mockCtrl)
+		mockTxnRepo = mocks.NewMockTransactionRepository(mockCtrl)
+		mockLedger := mocks.NewMockLedgerRepository(mockCtrl)
+
+		cfg := config.Config{AuthEnabled: true, AdminAPIKey: "bootstrap-admin-key", JWTHMACSecret: secret}
+		authSvc, err := service.NewAuthService(mocks.NewMockAPIKeyRepository(mockCtrl), cfg)
+		Expect(err).To(BeNil())
+		accountSvc := service.NewAccountService(mockRepo, mockLedger, cfg)
+		transactionSvc := service.NewTransactionService(mockRepo, mockLedger, mockIdem, mockTxnRepo, cfg)
+
+		router = gin.New()
+		api.NewTransactionHandler(transactionSvc, accountSvc, cfg).RegisterRoutes(router.Group("/api/v1", middleware.Authenticate(authSvc)))
+
+		account = &model.Account{ID: uuid.New(), OwnerName: "Alice", Balance: 1000, Currency: "USD", Status: constants.AccountActive}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	// deposit has the teller named subject deposit 1.00 into the account,
+	// sending headers with the request.
+	deposit := func(subject string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions",
+			strings.NewReader(`{"account_id": "`+account.ID.String()+`", "amount": 100, "type": "deposit"}`))
+		req.Header.Set("Content-Type", "application/json")
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub":  subject,
+			"role": string(constants.RoleTeller),
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		}).SignedString([]byte(secret))
+		Expect(err).To(BeNil())
+		req.Header.Set("Authorization", "Bearer "+token)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	bodyOf := func(w *httptest.ResponseRecorder) map[string]any {
+		var body map[string]any
+		Expect(json.Unmarshal(w.Body.Bytes(), &body)).To(Succeed())
+		return body
+	}
+
+	Describe("Idempotency-Key", func() {
+		var stored *model.IdempotencyKey
+
+		BeforeEach(func() {
+			// what teller-1 sent under key-1 before
+			var err error
+			stored, err = service.NewIdempotencyKey("jwt:teller-1", "key-1", &model.Transaction{
+				AccountID: account.ID,
+				Type:      constants.Deposit,
+				Amount:    100,
+			})
+			Expect(err).To(BeNil())
+			stored.TransactionID = uuid.New()
+		})
+
+		It("should replay a retry without checking the account again", func() {
+			// the account was closed after the original was accepted; looking it
+			// up again would fail the retry
+			mockIdem.EXPECT().GetByKey(gomock.Any(), "jwt:teller-1", "key-1").Return(stored, nil)
+
+			w := deposit("teller-1", map[string]string{"Idempotency-Key": "key-1"})
+			Expect(w.Code).To(Equal(http.StatusAccepted))
+			Expect(bodyOf(w)).To(HaveKeyWithValue("transaction_id", stored.TransactionID.String()))
+		})
+
+		It("should not replay another caller's key", func() {
+			mockIdem.EXPECT().GetByKey(gomock.Any(), "jwt:teller-2", "key-1").Return(nil, nil)
+			mockRepo.EXPECT().GetAccountByID(gomock.Any(), account.ID.String()).Return(account, nil)
+			var claimed *model.IdempotencyKey
+			mockTxnRepo.EXPECT().
+				CreatePendingWithKey(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ *model.Transaction, _ *model.OutboxMessage, key *model.IdempotencyKey) (bool, error) {
+					claimed = key
+					return true, nil
+				})
+
+			w := deposit("teller-2", map[string]string{"Idempotency-Key": "key-1"})
+			Expect(w.Code).To(Equal(http.StatusAccepted))
+			Expect(bodyOf(w)["transaction_id"]).NotTo(Equal(stored.TransactionID.String()))
+			Expect(claimed.Scope).To(Equal("jwt:teller-2"))
+			Expect(claimed.Fingerprint).To(Equal(stored.Fingerprint))
+		})
+
+		It("should still validate a request under a new key", func() {
+			closed := *account
+			closed.Status = constants.AccountClosed
+			mockIdem.EXPECT().GetByKey(gomock.Any(), "jwt:teller-1", "key-2").Return(nil, nil)
+			mockRepo.EXPECT().GetAccountByID(gomock.Any(), account.ID.String()).Return(&closed, nil)
+
+			Expect(deposit("teller-1", map[string]string{"Idempotency-Key": "key-2"}).Code).To(Equal(http.StatusConflict))
+		})
+	})
+
+	Describe("wait mode", func() {
+		var (
+			mockTxnSvc *mocks.MockTransactionServiceInterface
+			waitRouter *gin.Engine
+		)
+
+		BeforeEach(func() {
+			mockTxnSvc = mocks.NewMockTransactionServiceInterface(mockCtrl)
+			accountSvc := service.NewAccountService(mockRepo, mocks.NewMockLedgerRepository(mockCtrl), config.Config{})
+
+			waitRouter = gin.New()
+			api.NewTransactionHandler(mockTxnSvc, accountSvc, config.Config{}).RegisterRoutes(waitRouter.Group("/api/v1", middleware.Anonymous()))
+		})
+
+		post := func(query, prefer string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions"+query,
+				strings.NewReader(`{"account_id": "`+account.ID.String()+`", "amount": 100, "type": "deposit"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if prefer != "" {
+				req.Header.Set("Prefer", prefer)
+			}
+			w := httptest.NewRecorder()
+			waitRouter.ServeHTTP(w, req)
+			return w
+		}
+
+		// expectEnqueue accepts the deposit as txnID.
+		expectEnqueue := func(txnID uuid.UUID) {
+			mockTxnSvc.EXPECT().ValidateAccounts(gomock.Any(), gomock.Any()).Return(nil)
+			mockTxnSvc.EXPECT().
+				EnqueueTransaction(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, txn *model.Transaction) error {
+					txn.ID = txnID
+					return nil
+				})
+		}
+
+		DescribeTable("reading how long to wait",
+			func(query, prefer string, wait time.Duration) {
+				txnID := uuid.New()
+				expectEnqueue(txnID)
+				mockTxnSvc.EXPECT().WaitForCompletion(gomock.Any(), txnID, wait).Return(nil, nil, nil)
+
+				Expect(post(query, prefer).Code).To(Equal(http.StatusAccepted))
+			},
+			Entry("a duration in the query", "?wait=1500ms", "", 1500*time.Millisecond),
+			Entry("seconds in the query", "?wait=5", "", 5*time.Second),
+			Entry("a Prefer header", "", "wait=5", 5*time.Second),
+			Entry("a Prefer header among other preferences", "", "respond-async, Wait = 10", 10*time.Second),
+			Entry("the query over a Prefer header", "?wait=2", "wait=10", 2*time.Second),
+			Entry("more than the cap", "?wait=10m", "", 30*time.Second),
+		)
+
+		It("should not wait unless asked to", func() {
+			expectEnqueue(uuid.New())
+
+			Expect(post("", "respond-async").Code).To(Equal(http.StatusAccepted))
+		})
+
+		DescribeTable("rejecting a wait it cannot read",
+			func(query, prefer string) {
+				w := post(query, prefer)
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+				Expect(bodyOf(w)).To(HaveKeyWithValue("error", errors.ErrInvalidWait.Error()))
+			},
+			Entry("a negative duration", "?wait=-1s", ""),
+			Entry("negative seconds", "?wait=-5", ""),
+			Entry("a malformed query", "?wait=soon", ""),
+			Entry("a negative Prefer header", "", "wait=-3"),
+			Entry("a malformed Prefer header", "", "wait=abc"),
+		)
+
+		DescribeTable("answering with the outcome",
+			func(final *model.Transaction, status int, body map[string]any) {
+				txnID := uuid.New()
+				expectEnqueue(txnID)
+				var acc *model.Account
+				if final != nil {
+					final.ID = txnID
+					if final.Status == constants.StatusCompleted {
+						acc = &model.Account{ID: account.ID, Balance: 1100, Currency: "USD", Status: constants.AccountActive}
+					}
+				}
+				mockTxnSvc.EXPECT().WaitForCompletion(gomock.Any(), txnID, 5*time.Second).Return(final, acc, nil)
+
+				w := post("?wait=5", "")
+				Expect(w.Code).To(Equal(status))
+				got := bodyOf(w)
+				Expect(got).To(HaveKeyWithValue("transaction_id", txnID.String()))
+				for k, v := range body {
+					Expect(got).To(HaveKeyWithValue(k, v))
+				}
+			},
+			Entry("completed", &model.Transaction{Status: constants.StatusCompleted}, http.StatusCreated,
+				map[string]any{"status": "completed", "balance": 1100.0, "currency": "USD", "balance_decimal": "11.00"}),
+			Entry("failed", &model.Transaction{Status: constants.StatusFailed, FailureReason: "insufficient funds"}, http.StatusUnprocessableEntity,
+				map[string]any{"status": "failed", "error": "insufficient funds"}),
+			Entry("still pending at the timeout", &model.Transaction{Status: constants.StatusPending}, http.StatusAccepted,
+				map[string]any{"message": "transaction accepted"}),
+			Entry("not yet stored at the timeout", nil, http.StatusAccepted,
+				map[string]any{"message": "transaction accepted"}),
+		)
+
+		It("should answer 500 when waiting fails", func() {
+			txnID := uuid.New()
+			expectEnqueue(txnID)
+			mockTxnSvc.EXPECT().WaitForCompletion(gomock.Any(), txnID, 5*time.Second).Return(nil, nil, stderrors.New("connection reset"))
+
+			Expect(post("?wait=5", "").Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
@@ -0,0 +1,140 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/imranzahoor/banking-ledger/internal/middleware"
+	"github.com/imranzahoor/banking-ledger/internal/service"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/test/mocks"
+)
+
+var _ = Describe("Authenticate", func() {
+	const secret = "test-secret"
+
+	var (
+		mockCtrl    *gomock.Controller
+		mockKeyRepo *mocks.MockAPIKeyRepository
+		router      *gin.Engine
+	)
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockKeyRepo = mocks.NewMockAPIKeyRepository(mockCtrl)
+
+		authSvc, err := service.NewAuthService(mockKeyRepo, config.Config{
+			AuthEnabled:   true,
+			AdminAPIKey:   "bootstrap-admin-key",
+			JWTHMACSecret: secret,
+			JWTIssuer:     "https://auth.example.com",
+			JWTAudience:   "banking-ledger",
+		})
+		Expect(err).To(BeNil())
+
+		router = gin.New()
+		router.GET("/whoami", middleware.Authenticate(authSvc), func(c *gin.Context) {
+			c.String(http.StatusOK, middleware.IdentityFrom(c).Subject)
+		})
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	get := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	bearer := func(claims jwt.RegisteredClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		Expect(err).To(BeNil())
+		return "Bearer " + token
+	}
+
+	validClaims := func() jwt.RegisteredClaims {
+		return jwt.RegisteredClaims{
+			Subject:   "user-42",
+			Issuer:    "https://auth.example.com",
+			Audience:  jwt.ClaimStrings{"banking-ledger"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}
+	}
+
+	expectUnauthenticated := func(w *httptest.ResponseRecorder) {
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		Expect(w.Header().Get("WWW-Authenticate")).To(ContainSubstring("Bearer"))
+		Expect(w.Body.String()).To(ContainSubstring(errors.ErrUnauthenticated.Error()))
+	}
+
+	It("should attach the identity of a valid API key", func() {
+		w := get(middleware.APIKeyHeader, "bootstrap-admin-key")
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(Equal("admin"))
+	})
+
+	It("should attach the identity of a valid JWT", func() {
+		w := get("Authorization", bearer(validClaims()))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(Equal("user-42"))
+	})
+
+	It("should reject a request without credentials", func() {
+		expectUnauthenticated(get("", ""))
+	})
+
+	It("should reject an unknown API key", func() {
+		mockKeyRepo.EXPECT().GetByHash(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+		expectUnauthenticated(get(middleware.APIKeyHeader, "bl_unknown"))
+	})
+
+	It("should reject a malformed Authorization header", func() {
+		expectUnauthenticated(get("Authorization", "Basic dXNlcjpwYXNz"))
+		expectUnauthenticated(get("Authorization", "Bearer "))
+	})
+
+	It("should reject an expired JWT", func() {
+		claims := validClaims()
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+
+		expectUnauthenticated(get("Authorization", bearer(claims)))
+	})
+
+	It("should reject a JWT for another audience", func() {
+		claims := validClaims()
+		claims.Audience = jwt.ClaimStrings{"another-service"}
+
+		expectUnauthenticated(get("Authorization", bearer(claims)))
+	})
+
+	It("should reject a JWT from another issuer", func() {
+		claims := validClaims()
+		claims.Issuer = "https://evil.example.com"
+
+		expectUnauthenticated(get("Authorization", bearer(claims)))
+	})
+
+	It("should answer 500 without details when the key lookup fails", func() {
+		mockKeyRepo.EXPECT().GetByHash(gomock.Any(), gomock.Any()).Return(nil, errors.ErrFake)
+
+		w := get(middleware.APIKeyHeader, "bl_some-key")
+		Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		Expect(w.Body.String()).NotTo(ContainSubstring(errors.ErrFake.Error()))
+	})
+})
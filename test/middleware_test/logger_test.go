@@ -0,0 +1,77 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/gin-gonic/gin"
+	"github.com/imranzahoor/banking-ledger/internal/middleware"
+	"github.com/imranzahoor/banking-ledger/pkg/logger"
+)
+
+var _ = Describe("RequestID and Logger", func() {
+	var (
+		router   *gin.Engine
+		out      *bytes.Buffer
+		previous *slog.Logger
+		seen     string
+	)
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		out = &bytes.Buffer{}
+		previous = slog.Default()
+		slog.SetDefault(logger.New(out, "info"))
+
+		router = gin.New()
+		router.Use(middleware.RequestID(), middleware.Logger())
+		router.GET("/accounts/:id", func(c *gin.Context) {
+			seen = logger.RequestID(c.Request.Context())
+			c.Status(http.StatusNotFound)
+		})
+	})
+
+	AfterEach(func() {
+		slog.SetDefault(previous)
+	})
+
+	get := func(requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/accounts/42", nil)
+		if requestID != "" {
+			req.Header.Set(logger.RequestIDHeader, requestID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	It("should keep the client's request ID and log the request under it", func() {
+		w := get("req-123")
+		Expect(w.Header().Get(logger.RequestIDHeader)).To(Equal("req-123"))
+		Expect(seen).To(Equal("req-123"))
+
+		var line map[string]any
+		Expect(json.Unmarshal(out.Bytes(), &line)).To(Succeed())
+		Expect(line).To(HaveKeyWithValue("msg", "request"))
+		Expect(line).To(HaveKeyWithValue("level", "WARN"))
+		Expect(line).To(HaveKeyWithValue("request_id", "req-123"))
+		Expect(line).To(HaveKeyWithValue("route", "/accounts/:id"))
+		Expect(line).To(HaveKeyWithValue("status", BeNumerically("==", http.StatusNotFound)))
+	})
+
+	It("should generate a request ID when none or a malformed one is sent", func() {
+		w := get("")
+		Expect(w.Header().Get(logger.RequestIDHeader)).To(HaveLen(36))
+		Expect(seen).To(Equal(w.Header().Get(logger.RequestIDHeader)))
+
+		w = get("bad id\n" + strings.Repeat("x", 200))
+		Expect(w.Header().Get(logger.RequestIDHeader)).To(HaveLen(36))
+	})
+})
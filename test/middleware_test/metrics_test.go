@@ -0,0 +1,50 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/gin-gonic/gin"
+	"github.com/imranzahoor/banking-ledger/internal/middleware"
+	"github.com/imranzahoor/banking-ledger/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("Metrics", func() {
+	var router *gin.Engine
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		router = gin.New()
+		router.Use(middleware.Metrics())
+		router.GET("/accounts/:id", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+	})
+
+	get := func(path string) {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	It("should count requests by route template and status", func() {
+		ok := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/accounts/:id", "200")
+		before := testutil.ToFloat64(ok)
+
+		get("/accounts/1")
+		get("/accounts/2")
+
+		Expect(testutil.ToFloat64(ok) - before).To(Equal(2.0))
+	})
+
+	It("should group requests to unknown routes", func() {
+		unmatched := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "unmatched", "404")
+		before := testutil.ToFloat64(unmatched)
+
+		get("/nowhere")
+
+		Expect(testutil.ToFloat64(unmatched) - before).To(Equal(1.0))
+	})
+})
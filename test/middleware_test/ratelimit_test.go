@@ -0,0 +1,144 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/gin-gonic/gin"
+	"github.com/imranzahoor/banking-ledger/internal/middleware"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+)
+
+var _ = Describe("RateLimit", func() {
+	var router *gin.Engine
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		router = gin.New()
+	})
+
+	post := func(remoteAddr, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/transactions", strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	Context("per caller", func() {
+		BeforeEach(func() {
+			limit := config.RateLimit{Requests: 2, Per: time.Minute}
+			router.POST("/transactions", middleware.RateLimit(limit, middleware.ByCaller), func(c *gin.Context) {
+				c.Status(http.StatusAccepted)
+			})
+		})
+
+		It("should allow a burst and then answer 429 with Retry-After", func() {
+			first := post("10.0.0.1:1234", "{}")
+			Expect(first.Code).To(Equal(http.StatusAccepted))
+			Expect(first.Header().Get("X-RateLimit-Limit")).To(Equal("2"))
+			Expect(first.Header().Get("X-RateLimit-Remaining")).To(Equal("1"))
+			Expect(first.Header().Get("X-RateLimit-Reset")).To(Equal("30"))
+
+			Expect(post("10.0.0.1:1234", "{}").Code).To(Equal(http.StatusAccepted))
+
+			limited := post("10.0.0.1:1234", "{}")
+			Expect(limited.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(limited.Header().Get("Retry-After")).To(Equal("30"))
+			Expect(limited.Header().Get("X-RateLimit-Remaining")).To(Equal("0"))
+			Expect(limited.Body.String()).To(ContainSubstring("rate limit exceeded"))
+		})
+
+		It("should give each client its own bucket", func() {
+			post("10.0.0.1:1234", "{}")
+			post("10.0.0.1:1234", "{}")
+			Expect(post("10.0.0.1:1234", "{}").Code).To(Equal(http.StatusTooManyRequests))
+			Expect(post("10.0.0.2:1234", "{}").Code).To(Equal(http.StatusAccepted))
+		})
+	})
+
+	Context("per account", func() {
+		var bodies []string
+
+		BeforeEach(func() {
+			bodies = nil
+			limit := config.RateLimit{Requests: 1, Per: time.Minute}
+			router.POST("/transactions", middleware.RateLimit(limit, middleware.ByAccount), func(c *gin.Context) {
+				body, _ := io.ReadAll(c.Request.Body)
+				bodies = append(bodies, string(body))
+				c.Status(http.StatusAccepted)
+			})
+		})
+
+		It("should limit by account_id and leave the body for the handler", func() {
+			alice := `{"account_id": "4f8a7c3e-2b1d-4e6f-9a0b-1c2d3e4f5a6b"}`
+			bob := `{"account_id": "9e8d7c6b-5a4f-4e3d-8c2b-1a0f9e8d7c6b"}`
+			Expect(post("10.0.0.1:1234", alice).Code).To(Equal(http.StatusAccepted))
+			Expect(post("10.0.0.2:1234", alice).Code).To(Equal(http.StatusTooManyRequests))
+			Expect(post("10.0.0.1:1234", bob).Code).To(Equal(http.StatusAccepted))
+			Expect(bodies).To(Equal([]string{alice, bob}))
+		})
+
+		It("should share one bucket across spellings of an account ID", func() {
+			Expect(post("10.0.0.1:1234", `{"account_id": "4f8a7c3e-2b1d-4e6f-9a0b-1c2d3e4f5a6b"}`).Code).To(Equal(http.StatusAccepted))
+			Expect(post("10.0.0.1:1234", `{"account_id": "4F8A7C3E-2B1D-4E6F-9A0B-1C2D3E4F5A6B"}`).Code).To(Equal(http.StatusTooManyRequests))
+			Expect(post("10.0.0.1:1234", `{"account_id": "{4f8a7c3e-2b1d-4e6f-9a0b-1c2d3e4f5a6b}"}`).Code).To(Equal(http.StatusTooManyRequests))
+		})
+
+		It("should not limit requests without a valid account", func() {
+			Expect(post("10.0.0.1:1234", "{}").Code).To(Equal(http.StatusAccepted))
+			Expect(post("10.0.0.1:1234", "not json").Code).To(Equal(http.StatusAccepted))
+			Expect(post("10.0.0.1:1234", `{"account_id": "alice"}`).Code).To(Equal(http.StatusAccepted))
+			Expect(post("10.0.0.1:1234", `{"account_id": "alice"}`).Code).To(Equal(http.StatusAccepted))
+		})
+
+		It("should not buffer an oversized body", func() {
+			huge := `{"account_id": "4f8a7c3e-2b1d-4e6f-9a0b-1c2d3e4f5a6b", "description": "` + strings.Repeat("x", 1<<20) + `"}`
+			Expect(post("10.0.0.1:1234", huge).Code).To(Equal(http.StatusAccepted))
+			Expect(len(bodies[0])).To(BeNumerically("<", 1<<20))
+		})
+	})
+
+	Context("per IP before authentication", func() {
+		var lookups int
+
+		BeforeEach(func() {
+			lookups = 0
+			limit := config.RateLimit{Requests: 2, Per: time.Minute}
+			authenticate := func(c *gin.Context) {
+				// stands in for a credential lookup that always fails
+				lookups++
+				c.AbortWithStatus(http.StatusUnauthorized)
+			}
+			router.POST("/transactions", middleware.RateLimit(limit, middleware.ByIP), authenticate)
+		})
+
+		It("should turn away a flood of bad credentials without checking each", func() {
+			Expect(post("10.0.0.1:1234", "{}").Code).To(Equal(http.StatusUnauthorized))
+			Expect(post("10.0.0.1:1234", "{}").Code).To(Equal(http.StatusUnauthorized))
+			for range 10 {
+				Expect(post("10.0.0.1:1234", "{}").Code).To(Equal(http.StatusTooManyRequests))
+			}
+			Expect(lookups).To(Equal(2))
+
+			Expect(post("10.0.0.2:1234", "{}").Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	It("should pass everything through when disabled", func() {
+		router.POST("/transactions", middleware.RateLimit(config.RateLimit{}, middleware.ByCaller), func(c *gin.Context) {
+			c.Status(http.StatusAccepted)
+		})
+		for range 5 {
+			w := post("10.0.0.1:1234", "{}")
+			Expect(w.Code).To(Equal(http.StatusAccepted))
+			Expect(w.Header().Get("X-RateLimit-Limit")).To(BeEmpty())
+		}
+	})
+})
@@ -7,10 +7,12 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
 	model "github.com/imranzahoor/banking-ledger/internal/model"
+	constants "github.com/imranzahoor/banking-ledger/pkg/constants"
 )
 
 // MockAccountRepository is a mock of AccountRepository interface.
@@ -36,18 +38,65 @@ func (m *MockAccountRepository) EXPECT() *MockAccountRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ApplyTransaction mocks base method.
+func (m *MockAccountRepository) ApplyTransaction(ctx context.Context, txn *model.Transaction) (*model.ProcessedTransaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyTransaction", ctx, txn)
+	ret0, _ := ret[0].(*model.ProcessedTransaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyTransaction indicates an expected call of ApplyTransaction.
+func (mr *MockAccountRepositoryMockRecorder) ApplyTransaction(ctx, txn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyTransaction", reflect.TypeOf((*MockAccountRepository)(nil).ApplyTransaction), ctx, txn)
+}
+
+// CloseAccount mocks base method.
+func (m *MockAccountRepository) CloseAccount(ctx context.Context, id, payoutID uuid.UUID, newMessage func(*model.Transaction) (*model.OutboxMessage, error)) (*model.Account, *model.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseAccount", ctx, id, payoutID, newMessage)
+	ret0, _ := ret[0].(*model.Account)
+	ret1, _ := ret[1].(*model.Transaction)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CloseAccount indicates an expected call of CloseAccount.
+func (mr *MockAccountRepositoryMockRecorder) CloseAccount(ctx, id, payoutID, newMessage interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseAccount", reflect.TypeOf((*MockAccountRepository)(nil).CloseAccount), ctx, id, payoutID, newMessage)
+}
+
 // CreateAccount mocks base method.
-func (m *MockAccountRepository) CreateAccount(ctx context.Context, acc *model.Account) error {
+func (m *MockAccountRepository) CreateAccount(ctx context.Context, acc *model.Account, newMessage func(*model.Transaction) (*model.OutboxMessage, error)) (*model.Transaction, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateAccount", ctx, acc)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "CreateAccount", ctx, acc, newMessage)
+	ret0, _ := ret[0].(*model.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CreateAccount indicates an expected call of CreateAccount.
-func (mr *MockAccountRepositoryMockRecorder) CreateAccount(ctx, acc interface{}) *gomock.Call {
+func (mr *MockAccountRepositoryMockRecorder) CreateAccount(ctx, acc, newMessage interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockAccountRepository)(nil).CreateAccount), ctx, acc)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockAccountRepository)(nil).CreateAccount), ctx, acc, newMessage)
+}
+
+// ExpireHolds mocks base method.
+func (m *MockAccountRepository) ExpireHolds(ctx context.Context, now time.Time, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireHolds", ctx, now, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireHolds indicates an expected call of ExpireHolds.
+func (mr *MockAccountRepositoryMockRecorder) ExpireHolds(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireHolds", reflect.TypeOf((*MockAccountRepository)(nil).ExpireHolds), ctx, now, limit)
 }
 
 // GetAccountByID mocks base method.
@@ -65,16 +114,106 @@ func (mr *MockAccountRepositoryMockRecorder) GetAccountByID(ctx, id interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountByID", reflect.TypeOf((*MockAccountRepository)(nil).GetAccountByID), ctx, id)
 }
 
-// UpdateBalance mocks base method.
-func (m *MockAccountRepository) UpdateBalance(ctx context.Context, accountID uuid.UUID, delta int64) error {
+// GetHold mocks base method.
+func (m *MockAccountRepository) GetHold(ctx context.Context, id uuid.UUID) (*model.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHold", ctx, id)
+	ret0, _ := ret[0].(*model.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHold indicates an expected call of GetHold.
+func (mr *MockAccountRepositoryMockRecorder) GetHold(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHold", reflect.TypeOf((*MockAccountRepository)(nil).GetHold), ctx, id)
+}
+
+// ListAccounts mocks base method.
+func (m *MockAccountRepository) ListAccounts(ctx context.Context, afterID uuid.UUID, limit int) ([]model.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccounts", ctx, afterID, limit)
+	ret0, _ := ret[0].([]model.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccounts indicates an expected call of ListAccounts.
+func (mr *MockAccountRepositoryMockRecorder) ListAccounts(ctx, afterID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockAccountRepository)(nil).ListAccounts), ctx, afterID, limit)
+}
+
+// ListPendingLedgerAccounts mocks base method.
+func (m *MockAccountRepository) ListPendingLedgerAccounts(ctx context.Context) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingLedgerAccounts", ctx)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingLedgerAccounts indicates an expected call of ListPendingLedgerAccounts.
+func (mr *MockAccountRepositoryMockRecorder) ListPendingLedgerAccounts(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingLedgerAccounts", reflect.TypeOf((*MockAccountRepository)(nil).ListPendingLedgerAccounts), ctx)
+}
+
+// MarkLedgerWritten mocks base method.
+func (m *MockAccountRepository) MarkLedgerWritten(ctx context.Context, transactionID uuid.UUID) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateBalance", ctx, accountID, delta)
+	ret := m.ctrl.Call(m, "MarkLedgerWritten", ctx, transactionID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateBalance indicates an expected call of UpdateBalance.
-func (mr *MockAccountRepositoryMockRecorder) UpdateBalance(ctx, accountID, delta interface{}) *gomock.Call {
+// MarkLedgerWritten indicates an expected call of MarkLedgerWritten.
+func (mr *MockAccountRepositoryMockRecorder) MarkLedgerWritten(ctx, transactionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkLedgerWritten", reflect.TypeOf((*MockAccountRepository)(nil).MarkLedgerWritten), ctx, transactionID)
+}
+
+// SetOverdraftLimit mocks base method.
+func (m *MockAccountRepository) SetOverdraftLimit(ctx context.Context, id uuid.UUID, limit int64) (*model.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOverdraftLimit", ctx, id, limit)
+	ret0, _ := ret[0].(*model.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetOverdraftLimit indicates an expected call of SetOverdraftLimit.
+func (mr *MockAccountRepositoryMockRecorder) SetOverdraftLimit(ctx, id, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOverdraftLimit", reflect.TypeOf((*MockAccountRepository)(nil).SetOverdraftLimit), ctx, id, limit)
+}
+
+// SetOwner mocks base method.
+func (m *MockAccountRepository) SetOwner(ctx context.Context, id uuid.UUID, ownerID string) (*model.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOwner", ctx, id, ownerID)
+	ret0, _ := ret[0].(*model.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetOwner indicates an expected call of SetOwner.
+func (mr *MockAccountRepositoryMockRecorder) SetOwner(ctx, id, ownerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOwner", reflect.TypeOf((*MockAccountRepository)(nil).SetOwner), ctx, id, ownerID)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockAccountRepository) UpdateStatus(ctx context.Context, id uuid.UUID, from []constants.AccountStatus, to constants.AccountStatus) (*model.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, id, from, to)
+	ret0, _ := ret[0].(*model.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockAccountRepositoryMockRecorder) UpdateStatus(ctx, id, from, to interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBalance", reflect.TypeOf((*MockAccountRepository)(nil).UpdateBalance), ctx, accountID, delta)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockAccountRepository)(nil).UpdateStatus), ctx, id, from, to)
 }
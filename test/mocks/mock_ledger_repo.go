@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
@@ -36,19 +37,94 @@ func (m *MockLedgerRepository) EXPECT() *MockLedgerRepositoryMockRecorder {
 	return m.recorder
 }
 
+// GetBalanceAsOf mocks base method.
+func (m *MockLedgerRepository) GetBalanceAsOf(ctx context.Context, accountID uuid.UUID, asOf time.Time) (*model.BalanceSnapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceAsOf", ctx, accountID, asOf)
+	ret0, _ := ret[0].(*model.BalanceSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceAsOf indicates an expected call of GetBalanceAsOf.
+func (mr *MockLedgerRepositoryMockRecorder) GetBalanceAsOf(ctx, accountID, asOf interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceAsOf", reflect.TypeOf((*MockLedgerRepository)(nil).GetBalanceAsOf), ctx, accountID, asOf)
+}
+
+// GetJournalEntry mocks base method.
+func (m *MockLedgerRepository) GetJournalEntry(ctx context.Context, transactionID uuid.UUID) (*model.JournalEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJournalEntry", ctx, transactionID)
+	ret0, _ := ret[0].(*model.JournalEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJournalEntry indicates an expected call of GetJournalEntry.
+func (mr *MockLedgerRepositoryMockRecorder) GetJournalEntry(ctx, transactionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJournalEntry", reflect.TypeOf((*MockLedgerRepository)(nil).GetJournalEntry), ctx, transactionID)
+}
+
 // GetTransactionsByAccountID mocks base method.
-func (m *MockLedgerRepository) GetTransactionsByAccountID(ctx context.Context, accountID uuid.UUID, limit, offset int64) ([]model.Transaction, error) {
+func (m *MockLedgerRepository) GetTransactionsByAccountID(ctx context.Context, accountID uuid.UUID, filter model.TransactionFilter, limit, offset int64) ([]model.Transaction, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTransactionsByAccountID", ctx, accountID, limit, offset)
+	ret := m.ctrl.Call(m, "GetTransactionsByAccountID", ctx, accountID, filter, limit, offset)
 	ret0, _ := ret[0].([]model.Transaction)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetTransactionsByAccountID indicates an expected call of GetTransactionsByAccountID.
-func (mr *MockLedgerRepositoryMockRecorder) GetTransactionsByAccountID(ctx, accountID, limit, offset interface{}) *gomock.Call {
+func (mr *MockLedgerRepositoryMockRecorder) GetTransactionsByAccountID(ctx, accountID, filter, limit, offset interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionsByAccountID", reflect.TypeOf((*MockLedgerRepository)(nil).GetTransactionsByAccountID), ctx, accountID, limit, offset)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionsByAccountID", reflect.TypeOf((*MockLedgerRepository)(nil).GetTransactionsByAccountID), ctx, accountID, filter, limit, offset)
+}
+
+// GetTransactionsPage mocks base method.
+func (m *MockLedgerRepository) GetTransactionsPage(ctx context.Context, accountID uuid.UUID, filter model.TransactionFilter, cursor string, limit int64) ([]model.Transaction, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactionsPage", ctx, accountID, filter, cursor, limit)
+	ret0, _ := ret[0].([]model.Transaction)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTransactionsPage indicates an expected call of GetTransactionsPage.
+func (mr *MockLedgerRepositoryMockRecorder) GetTransactionsPage(ctx, accountID, filter, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionsPage", reflect.TypeOf((*MockLedgerRepository)(nil).GetTransactionsPage), ctx, accountID, filter, cursor, limit)
+}
+
+// GetTrialBalance mocks base method.
+func (m *MockLedgerRepository) GetTrialBalance(ctx context.Context) ([]model.TrialBalance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrialBalance", ctx)
+	ret0, _ := ret[0].([]model.TrialBalance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrialBalance indicates an expected call of GetTrialBalance.
+func (mr *MockLedgerRepositoryMockRecorder) GetTrialBalance(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrialBalance", reflect.TypeOf((*MockLedgerRepository)(nil).GetTrialBalance), ctx)
+}
+
+// InsertJournalEntry mocks base method.
+func (m *MockLedgerRepository) InsertJournalEntry(ctx context.Context, entry *model.JournalEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertJournalEntry", ctx, entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertJournalEntry indicates an expected call of InsertJournalEntry.
+func (mr *MockLedgerRepositoryMockRecorder) InsertJournalEntry(ctx, entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertJournalEntry", reflect.TypeOf((*MockLedgerRepository)(nil).InsertJournalEntry), ctx, entry)
 }
 
 // InsertTransaction mocks base method.
@@ -64,3 +140,45 @@ func (mr *MockLedgerRepositoryMockRecorder) InsertTransaction(ctx, txn interface
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertTransaction", reflect.TypeOf((*MockLedgerRepository)(nil).InsertTransaction), ctx, txn)
 }
+
+// InsertTransactions mocks base method.
+func (m *MockLedgerRepository) InsertTransactions(ctx context.Context, txns []*model.Transaction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertTransactions", ctx, txns)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertTransactions indicates an expected call of InsertTransactions.
+func (mr *MockLedgerRepositoryMockRecorder) InsertTransactions(ctx, txns interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertTransactions", reflect.TypeOf((*MockLedgerRepository)(nil).InsertTransactions), ctx, txns)
+}
+
+// SaveBalanceSnapshot mocks base method.
+func (m *MockLedgerRepository) SaveBalanceSnapshot(ctx context.Context, snap *model.BalanceSnapshot) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveBalanceSnapshot", ctx, snap)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveBalanceSnapshot indicates an expected call of SaveBalanceSnapshot.
+func (mr *MockLedgerRepositoryMockRecorder) SaveBalanceSnapshot(ctx, snap interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBalanceSnapshot", reflect.TypeOf((*MockLedgerRepository)(nil).SaveBalanceSnapshot), ctx, snap)
+}
+
+// StreamTransactionsByAccountID mocks base method.
+func (m *MockLedgerRepository) StreamTransactionsByAccountID(ctx context.Context, accountID uuid.UUID, from, to time.Time, fn func(*model.Transaction) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamTransactionsByAccountID", ctx, accountID, from, to, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamTransactionsByAccountID indicates an expected call of StreamTransactionsByAccountID.
+func (mr *MockLedgerRepositoryMockRecorder) StreamTransactionsByAccountID(ctx, accountID, from, to, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamTransactionsByAccountID", reflect.TypeOf((*MockLedgerRepository)(nil).StreamTransactionsByAccountID), ctx, accountID, from, to, fn)
+}
@@ -0,0 +1,92 @@
+package policy_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/policy"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+)
+
+var _ = Describe("Policy", func() {
+	as := func(role constants.Role) *model.Identity {
+		return &model.Identity{Subject: "user-42", Method: "jwt", Principal: "jwt:user-42", Role: role}
+	}
+
+	DescribeTable("route actions",
+		func(role constants.Role, action policy.Action, allowed bool) {
+			err := policy.Allow(as(role), action)
+			if allowed {
+				Expect(err).To(BeNil())
+			} else {
+				Expect(err).To(MatchError(errors.ErrForbidden))
+			}
+		},
+		Entry("admin manages accounts", constants.RoleAdmin, policy.ManageAccounts, true),
+		Entry("admin manages API keys", constants.RoleAdmin, policy.ManageAPIKeys, true),
+		Entry("auditor reads the ledger", constants.RoleAuditor, policy.ReadLedger, true),
+		Entry("auditor cannot transact", constants.RoleAuditor, policy.Transact, false),
+		Entry("auditor cannot reconcile", constants.RoleAuditor, policy.Reconcile, false),
+		Entry("teller transacts", constants.RoleTeller, policy.Transact, true),
+		Entry("teller cannot manage accounts", constants.RoleTeller, policy.ManageAccounts, false),
+		Entry("customer cannot read the ledger", constants.RoleCustomer, policy.ReadLedger, false),
+		Entry("unknown role gets nothing", constants.Role("superuser"), policy.ReadAccounts, false),
+	)
+
+	It("should deny a missing identity", func() {
+		Expect(policy.Allow(nil, policy.ReadAccounts)).To(MatchError(errors.ErrForbidden))
+	})
+
+	Context("for customers", func() {
+		own := &model.Account{OwnerID: "jwt:user-42"}
+		other := &model.Account{OwnerID: "jwt:user-7"}
+		unowned := &model.Account{}
+
+		It("should confine them to accounts they own", func() {
+			Expect(policy.AllowAccount(as(constants.RoleCustomer), policy.ReadAccounts, own)).To(BeNil())
+			Expect(policy.AllowAccount(as(constants.RoleCustomer), policy.ReadAccounts, other)).To(MatchError(errors.ErrForbidden))
+			Expect(policy.AllowAccount(as(constants.RoleCustomer), policy.ReadAccounts, unowned)).To(MatchError(errors.ErrForbidden))
+			Expect(policy.AllowAccount(as(constants.RoleCustomer), policy.ReadAccounts, nil)).To(MatchError(errors.ErrForbidden))
+		})
+
+		It("should match owners by how they authenticate as well as by name", func() {
+			apiKey := &model.Identity{Subject: "user-42", Method: "api_key", Principal: "key:user-42", Role: constants.RoleCustomer}
+			Expect(policy.AllowAccount(apiKey, policy.ReadAccounts, own)).To(MatchError(errors.ErrForbidden))
+			Expect(policy.AllowAccount(apiKey, policy.ReadAccounts, &model.Account{OwnerID: "key:user-42"})).To(BeNil())
+			Expect(policy.AllowAccount(as(constants.RoleCustomer), policy.ReadAccounts, &model.Account{OwnerID: "key:user-42"})).To(MatchError(errors.ErrForbidden))
+			Expect(policy.AllowAccount(as(constants.RoleCustomer), policy.ReadAccounts, &model.Account{OwnerID: "user-42"})).To(MatchError(errors.ErrForbidden))
+		})
+
+		It("should only let them transfer from their own accounts", func() {
+			Expect(policy.AllowTransaction(as(constants.RoleCustomer), constants.Transfer, own)).To(BeNil())
+			Expect(policy.AllowTransaction(as(constants.RoleCustomer), constants.Transfer, other)).To(MatchError(errors.ErrForbidden))
+			Expect(policy.AllowTransaction(as(constants.RoleCustomer), constants.Withdrawal, own)).To(MatchError(errors.ErrForbidden))
+		})
+	})
+
+	DescribeTable("owner IDs",
+		func(ownerID string, valid bool) {
+			Expect(model.ValidOwnerID(ownerID)).To(Equal(valid))
+		},
+		Entry("none", "", true),
+		Entry("a token subject", "jwt:user-42", true),
+		Entry("an API key", "key:payments-service", true),
+		Entry("an unprefixed name", "user-42", false),
+		Entry("a prefix alone", "jwt:", false),
+		Entry("an unknown kind of caller", "oidc:user-42", false),
+	)
+
+	It("should not confine other roles to owned accounts", func() {
+		Expect(policy.OwnAccountsOnly(as(constants.RoleTeller))).To(BeFalse())
+		Expect(policy.AllowAccount(as(constants.RoleAuditor), policy.ReadAccounts, nil)).To(BeNil())
+	})
+
+	It("should limit tellers to deposits and withdrawals", func() {
+		Expect(policy.AllowTransaction(as(constants.RoleTeller), constants.Deposit, nil)).To(BeNil())
+		Expect(policy.AllowTransaction(as(constants.RoleTeller), constants.Withdrawal, nil)).To(BeNil())
+		Expect(policy.AllowTransaction(as(constants.RoleTeller), constants.Transfer, nil)).To(MatchError(errors.ErrForbidden))
+		Expect(policy.AllowTransaction(as(constants.RoleAdmin), constants.Capture, nil)).To(BeNil())
+	})
+})
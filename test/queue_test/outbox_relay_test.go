@@ -0,0 +1,130 @@
+package queue_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	queue "github.com/imranzahoor/banking-ledger/pkg/kafka"
+	"github.com/imranzahoor/banking-ledger/test/mocks"
+	"github.com/segmentio/kafka-go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OutboxRelay", func() {
+	const batchSize = 2
+
+	var (
+		mockCtrl   *gomock.Controller
+		mockOutbox *mocks.MockOutboxRepository
+		mockWriter *mocks.MockMessageWriter
+		relay      *queue.OutboxRelay
+		ctx        context.Context
+		cancel     context.CancelFunc
+		msg        model.OutboxMessage
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockOutbox = mocks.NewMockOutboxRepository(mockCtrl)
+		mockWriter = mocks.NewMockMessageWriter(mockCtrl)
+		// a poll interval far beyond the test timeout, so only a full batch or
+		// cancellation lets Run move on
+		relay = queue.NewOutboxRelayWithWriter(mockWriter, mockOutbox, time.Hour, batchSize)
+		ctx, cancel = context.WithCancel(context.TODO())
+
+		out, err := model.NewTransactionMessage("transactions", &model.Transaction{ID: uuid.New(), Amount: 100}, map[string]string{"X-Request-ID": "req-1"})
+		Expect(err).NotTo(HaveOccurred())
+		msg = *out
+	})
+
+	AfterEach(func() {
+		cancel()
+		mockCtrl.Finish()
+	})
+
+	run := func() chan error {
+		done := make(chan error, 1)
+		go func() { done <- relay.Run(ctx) }()
+		return done
+	}
+
+	It("should publish pending messages and stop when cancelled", func() {
+		mockOutbox.EXPECT().
+			PublishPending(gomock.Any(), batchSize, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ int, publish func([]model.OutboxMessage) error, _ func(int) time.Duration) (int, error) {
+				defer cancel()
+				Expect(publish([]model.OutboxMessage{msg})).To(Succeed())
+				return 1, nil
+			})
+		mockWriter.EXPECT().
+			WriteMessages(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, msgs ...kafka.Message) error {
+				Expect(msgs).To(HaveLen(1))
+				Expect(msgs[0].Topic).To(Equal("transactions"))
+				Expect(string(msgs[0].Key)).To(Equal(msg.Key))
+				Expect(msgs[0].Value).To(Equal(msg.Payload))
+				Expect(msgs[0].Headers).To(ContainElement(kafka.Header{Key: "X-Request-ID", Value: []byte("req-1")}))
+				return nil
+			})
+		mockWriter.EXPECT().Close().Return(nil)
+
+		Eventually(run()).Should(Receive(BeNil()))
+	})
+
+	It("should hand a publishing failure back so the batch is retried later", func() {
+		mockOutbox.EXPECT().
+			PublishPending(gomock.Any(), batchSize, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ int, publish func([]model.OutboxMessage) error, retryAfter func(int) time.Duration) (int, error) {
+				defer cancel()
+				Expect(publish([]model.OutboxMessage{msg})).To(MatchError(errors.ErrFake))
+				Expect(retryAfter(1)).To(BeNumerically(">", 0))
+				Expect(retryAfter(2)).To(BeNumerically(">", retryAfter(1)))
+				return 0, nil
+			})
+		mockWriter.EXPECT().WriteMessages(gomock.Any(), gomock.Any()).Return(errors.ErrFake)
+		mockWriter.EXPECT().Close().Return(nil)
+
+		Eventually(run()).Should(Receive(BeNil()))
+	})
+
+	It("should drain a full batch without waiting for the next poll", func() {
+		gomock.InOrder(
+			mockOutbox.EXPECT().
+				PublishPending(gomock.Any(), batchSize, gomock.Any(), gomock.Any()).
+				Return(batchSize, nil),
+			mockOutbox.EXPECT().
+				PublishPending(gomock.Any(), batchSize, gomock.Any(), gomock.Any()).
+				DoAndReturn(func(context.Context, int, func([]model.OutboxMessage) error, func(int) time.Duration) (int, error) {
+					cancel()
+					return 0, nil
+				}),
+		)
+		mockWriter.EXPECT().Close().Return(nil)
+
+		Eventually(run()).Should(Receive(BeNil()))
+	})
+
+	It("should poll again after the outbox query fails", func() {
+		relay = queue.NewOutboxRelayWithWriter(mockWriter, mockOutbox, 10*time.Millisecond, batchSize)
+		gomock.InOrder(
+			mockOutbox.EXPECT().
+				PublishPending(gomock.Any(), batchSize, gomock.Any(), gomock.Any()).
+				Return(0, errors.ErrFake),
+			mockOutbox.EXPECT().
+				PublishPending(gomock.Any(), batchSize, gomock.Any(), gomock.Any()).
+				DoAndReturn(func(context.Context, int, func([]model.OutboxMessage) error, func(int) time.Duration) (int, error) {
+					cancel()
+					return 0, nil
+				}),
+		)
+		mockWriter.EXPECT().Close().Return(nil)
+
+		Eventually(run()).Should(Receive(BeNil()))
+	})
+})
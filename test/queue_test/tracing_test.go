@@ -0,0 +1,167 @@
+package queue_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	queue "github.com/imranzahoor/banking-ledger/pkg/kafka"
+	"github.com/imranzahoor/banking-ledger/pkg/tracing"
+	"github.com/imranzahoor/banking-ledger/test/mocks"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tracing through Kafka", func() {
+	var (
+		mockCtrl        *gomock.Controller
+		mockOutbox      *mocks.MockOutboxRepository
+		mockWriter      *mocks.MockMessageWriter
+		mockReader      *mocks.MockMessageReader
+		mockDLQ         *mocks.MockMessageWriter
+		mockAccountRepo *mocks.MockAccountRepository
+		mockLedgerRepo  *mocks.MockLedgerRepository
+		recorder        *tracetest.SpanRecorder
+		tracer          trace.Tracer
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockOutbox = mocks.NewMockOutboxRepository(mockCtrl)
+		mockWriter = mocks.NewMockMessageWriter(mockCtrl)
+		mockReader = mocks.NewMockMessageReader(mockCtrl)
+		mockDLQ = mocks.NewMockMessageWriter(mockCtrl)
+		mockAccountRepo = mocks.NewMockAccountRepository(mockCtrl)
+		mockLedgerRepo = mocks.NewMockLedgerRepository(mockCtrl)
+
+		provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+		DeferCleanup(func() {
+			otel.SetTracerProvider(provider)
+			otel.SetTextMapPropagator(propagator)
+		})
+		recorder = tracetest.NewSpanRecorder()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		otel.SetTracerProvider(tp)
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		tracer = tp.Tracer("test")
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	spanNamed := func(name string) sdktrace.ReadOnlySpan {
+		for _, s := range recorder.Ended() {
+			if s.Name() == name {
+				return s
+			}
+		}
+		Fail("no span named " + name)
+		return nil
+	}
+
+	// relay publishes msg through the outbox relay and returns what was
+	// written to Kafka.
+	relay := func(msg model.OutboxMessage) kafka.Message {
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+
+		var published kafka.Message
+		mockOutbox.EXPECT().
+			PublishPending(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ int, publish func([]model.OutboxMessage) error, _ func(int) time.Duration) (int, error) {
+				defer cancel()
+				Expect(publish([]model.OutboxMessage{msg})).To(Succeed())
+				return 1, nil
+			})
+		mockWriter.EXPECT().
+			WriteMessages(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, msgs ...kafka.Message) error {
+				published = msgs[0]
+				return nil
+			})
+		mockWriter.EXPECT().Close().Return(nil)
+
+		Expect(queue.NewOutboxRelayWithWriter(mockWriter, mockOutbox, time.Hour, 10).Run(ctx)).To(Succeed())
+		return published
+	}
+
+	// consume has the transaction consumer process m.
+	consume := func(m kafka.Message) {
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+
+		m.Topic, m.Partition, m.Offset = "transactions", 0, 7
+		gomock.InOrder(
+			mockReader.EXPECT().FetchMessage(gomock.Any()).Return(m, nil),
+			mockReader.EXPECT().FetchMessage(gomock.Any()).DoAndReturn(func(ctx context.Context) (kafka.Message, error) {
+				<-ctx.Done()
+				return kafka.Message{}, ctx.Err()
+			}).AnyTimes(),
+		)
+		mockReader.EXPECT().Stats().Return(kafka.ReaderStats{}).AnyTimes()
+		mockReader.EXPECT().CommitMessages(gomock.Any(), m).DoAndReturn(func(context.Context, ...kafka.Message) error {
+			cancel()
+			return nil
+		})
+		mockReader.EXPECT().Close().Return(nil)
+		mockDLQ.EXPECT().Close().Return(nil)
+		mockAccountRepo.EXPECT().ApplyTransaction(gomock.Any(), gomock.Any()).Return(&model.ProcessedTransaction{}, nil)
+		mockLedgerRepo.EXPECT().InsertJournalEntry(gomock.Any(), gomock.Any()).Return(nil)
+		mockLedgerRepo.EXPECT().InsertTransactions(gomock.Any(), gomock.Any()).Return(nil)
+		mockAccountRepo.EXPECT().MarkLedgerWritten(gomock.Any(), gomock.Any()).Return(nil)
+
+		kafkaCfg := config.KafkaConfig{Topic: "transactions", MaxRetries: 1, RetryBackoff: time.Millisecond, MaxRetryBackoff: time.Millisecond}
+		consumer := queue.NewTransactionConsumerWithClients(kafkaCfg, mockReader, mockDLQ, mockAccountRepo, mockLedgerRepo, mocks.NewMockTransactionRepository(mockCtrl))
+		Expect(consumer.Run(ctx)).To(MatchError(context.Canceled))
+	}
+
+	It("should carry the request's trace from the outbox to the consumer", func() {
+		reqCtx, reqSpan := tracer.Start(context.TODO(), "POST /api/v1/transactions")
+		txn := &model.Transaction{ID: uuid.New(), AccountID: uuid.New(), Type: constants.Deposit, Amount: 1000, Currency: "USD"}
+		msg, err := model.NewTransactionMessage("transactions", txn, tracing.InjectHeaders(reqCtx, map[string]string{"X-Request-ID": "req-1"}))
+		Expect(err).NotTo(HaveOccurred())
+		reqSpan.End()
+
+		published := relay(*msg)
+		publish := spanNamed("transactions publish")
+		Expect(publish.Parent().SpanID()).To(Equal(reqSpan.SpanContext().SpanID()))
+		Expect(publish.SpanContext().TraceID()).To(Equal(reqSpan.SpanContext().TraceID()))
+
+		// the relay re-injects its own span, not the one stored with the message
+		headers := make(map[string]string, len(published.Headers))
+		for _, h := range published.Headers {
+			headers[h.Key] = string(h.Value)
+		}
+		Expect(headers).To(HaveKeyWithValue("X-Request-ID", "req-1"))
+		sent := trace.SpanContextFromContext(tracing.ExtractHeaders(context.TODO(), headers))
+		Expect(sent.SpanID()).To(Equal(publish.SpanContext().SpanID()))
+
+		consume(published)
+		process := spanNamed("transactions process")
+		Expect(process.SpanKind()).To(Equal(trace.SpanKindConsumer))
+		Expect(process.Parent().SpanID()).To(Equal(publish.SpanContext().SpanID()))
+		Expect(process.SpanContext().TraceID()).To(Equal(reqSpan.SpanContext().TraceID()))
+	})
+
+	It("should start a new trace for a message without trace context", func() {
+		txn := &model.Transaction{ID: uuid.New(), AccountID: uuid.New(), Type: constants.Deposit, Amount: 1000, Currency: "USD"}
+		payload, err := model.NewTransactionMessage("transactions", txn, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		consume(kafka.Message{Key: []byte(txn.ID.String()), Value: payload.Payload})
+		process := spanNamed("transactions process")
+		Expect(process.Parent().IsValid()).To(BeFalse())
+	})
+})
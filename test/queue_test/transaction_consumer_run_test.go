@@ -0,0 +1,346 @@
+package queue_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	queue "github.com/imranzahoor/banking-ledger/pkg/kafka"
+	"github.com/imranzahoor/banking-ledger/pkg/metrics"
+	"github.com/imranzahoor/banking-ledger/test/mocks"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/segmentio/kafka-go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TransactionConsumer.Run", func() {
+	var (
+		mockCtrl        *gomock.Controller
+		mockReader      *mocks.MockMessageReader
+		mockDLQ         *mocks.MockMessageWriter
+		mockAccountRepo *mocks.MockAccountRepository
+		mockLedgerRepo  *mocks.MockLedgerRepository
+		mockTxnRepo     *mocks.MockTransactionRepository
+		consumer        *queue.TransactionConsumer
+		ctx             context.Context
+		cancel          context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockReader = mocks.NewMockMessageReader(mockCtrl)
+		mockDLQ = mocks.NewMockMessageWriter(mockCtrl)
+		mockAccountRepo = mocks.NewMockAccountRepository(mockCtrl)
+		mockLedgerRepo = mocks.NewMockLedgerRepository(mockCtrl)
+		mockTxnRepo = mocks.NewMockTransactionRepository(mockCtrl)
+		ctx, cancel = context.WithCancel(context.TODO())
+
+		kafkaCfg := config.KafkaConfig{
+			Topic:           "transactions",
+			MaxRetries:      2,
+			RetryBackoff:    time.Millisecond,
+			MaxRetryBackoff: time.Millisecond,
+			StatsInterval:   10 * time.Millisecond,
+		}
+		consumer = queue.NewTransactionConsumerWithClients(kafkaCfg, mockReader, mockDLQ, mockAccountRepo, mockLedgerRepo, mockTxnRepo)
+
+		mockReader.EXPECT().Close().Return(nil)
+		mockDLQ.EXPECT().Close().Return(nil)
+	})
+
+	AfterEach(func() {
+		cancel()
+		mockCtrl.Finish()
+	})
+
+	run := func() chan error {
+		done := make(chan error, 1)
+		go func() { done <- consumer.Run(ctx) }()
+		return done
+	}
+
+	// blockFetch makes FetchMessage wait for cancellation, as it does on an
+	// idle topic.
+	blockFetch := func(ctx context.Context) (kafka.Message, error) {
+		<-ctx.Done()
+		return kafka.Message{}, ctx.Err()
+	}
+
+	Describe("state", func() {
+		It("should stay starting until the reader joins its group", func() {
+			joined := make(chan struct{})
+			mockReader.EXPECT().Stats().DoAndReturn(func() kafka.ReaderStats {
+				select {
+				case <-joined:
+					return kafka.ReaderStats{Rebalances: 1}
+				default:
+					return kafka.ReaderStats{}
+				}
+			}).AnyTimes()
+			mockReader.EXPECT().FetchMessage(gomock.Any()).DoAndReturn(blockFetch)
+
+			done := run()
+			Consistently(consumer.State, 50*time.Millisecond).Should(Equal(constants.ConsumerStarting))
+
+			close(joined)
+			Eventually(consumer.State).Should(Equal(constants.ConsumerRunning))
+
+			cancel()
+			Eventually(done).Should(Receive(MatchError(context.Canceled)))
+			Expect(consumer.State()).To(Equal(constants.ConsumerStopped))
+		})
+
+		It("should be running once a message is fetched", func() {
+			mockReader.EXPECT().Stats().Return(kafka.ReaderStats{}).AnyTimes()
+			gomock.InOrder(
+				mockReader.EXPECT().FetchMessage(gomock.Any()).Return(kafka.Message{Topic: "transactions", Value: []byte("{")}, nil),
+				mockReader.EXPECT().FetchMessage(gomock.Any()).DoAndReturn(blockFetch),
+			)
+			mockDLQ.EXPECT().WriteMessages(gomock.Any(), gomock.Any()).Return(nil)
+			mockReader.EXPECT().CommitMessages(gomock.Any(), gomock.Any()).Return(nil)
+
+			done := run()
+			Eventually(consumer.State).Should(Equal(constants.ConsumerRunning))
+
+			cancel()
+			Eventually(done).Should(Receive(MatchError(context.Canceled)))
+		})
+
+		It("should report the reader's lag", func() {
+			mockReader.EXPECT().Stats().Return(kafka.ReaderStats{Lag: 17}).AnyTimes()
+			mockReader.EXPECT().FetchMessage(gomock.Any()).DoAndReturn(blockFetch)
+
+			done := run()
+			Eventually(func() float64 {
+				return testutil.ToFloat64(metrics.ConsumerLag.WithLabelValues("transactions"))
+			}).Should(Equal(17.0))
+
+			cancel()
+			Eventually(done).Should(Receive(MatchError(context.Canceled)))
+		})
+
+		It("should return the reader's error and report itself stopped", func() {
+			mockReader.EXPECT().Stats().Return(kafka.ReaderStats{}).AnyTimes()
+			mockReader.EXPECT().FetchMessage(gomock.Any()).Return(kafka.Message{}, errors.ErrFake)
+
+			Eventually(run()).Should(Receive(MatchError(errors.ErrFake)))
+			Expect(consumer.State()).To(Equal(constants.ConsumerStopped))
+		})
+	})
+
+	Describe("handling messages", func() {
+		var (
+			txn *model.Transaction
+			msg kafka.Message
+		)
+
+		BeforeEach(func() {
+			txn = &model.Transaction{ID: uuid.New(), AccountID: uuid.New(), Type: constants.Deposit, Amount: 1000, Currency: "USD"}
+			payload, err := json.Marshal(txn)
+			Expect(err).NotTo(HaveOccurred())
+			msg = kafka.Message{
+				Topic:     "transactions",
+				Partition: 3,
+				Offset:    42,
+				Key:       []byte(txn.ID.String()),
+				Value:     payload,
+				Headers:   []kafka.Header{{Key: "X-Request-ID", Value: []byte("req-1")}},
+			}
+			mockReader.EXPECT().Stats().Return(kafka.ReaderStats{}).AnyTimes()
+		})
+
+		// deliver hands msg to the consumer, then blocks until the test ends.
+		deliver := func() {
+			gomock.InOrder(
+				mockReader.EXPECT().FetchMessage(gomock.Any()).Return(msg, nil),
+				mockReader.EXPECT().FetchMessage(gomock.Any()).DoAndReturn(blockFetch).AnyTimes(),
+			)
+		}
+
+		// expectCommit cancels the consumer once msg is committed.
+		expectCommit := func() {
+			mockReader.EXPECT().CommitMessages(gomock.Any(), msg).DoAndReturn(func(context.Context, ...kafka.Message) error {
+				cancel()
+				return nil
+			})
+		}
+
+		headersOf := func(m kafka.Message) map[string]string {
+			headers := make(map[string]string, len(m.Headers))
+			for _, h := range m.Headers {
+				headers[h.Key] = string(h.Value)
+			}
+			return headers
+		}
+
+		It("should retry a transient failure and commit once it succeeds", func() {
+			deliver()
+			gomock.InOrder(
+				mockAccountRepo.EXPECT().ApplyTransaction(gomock.Any(), gomock.Any()).Return(nil, errors.ErrFake),
+				mockAccountRepo.EXPECT().
+					ApplyTransaction(gomock.Any(), gomock.Any()).
+					Return(&model.ProcessedTransaction{TransactionID: txn.ID}, nil),
+				mockLedgerRepo.EXPECT().InsertJournalEntry(gomock.Any(), gomock.Any()).Return(nil),
+				mockLedgerRepo.EXPECT().InsertTransactions(gomock.Any(), gomock.Any()).Return(nil),
+				mockAccountRepo.EXPECT().MarkLedgerWritten(gomock.Any(), txn.ID).Return(nil),
+			)
+			expectCommit()
+			// no dead-lettering is expected
+
+			Eventually(run()).Should(Receive(MatchError(context.Canceled)))
+		})
+
+		It("should dead-letter a message once retries run out", func() {
+			deliver()
+			mockAccountRepo.EXPECT().ApplyTransaction(gomock.Any(), gomock.Any()).Return(nil, errors.ErrFake).Times(3)
+			mockTxnRepo.EXPECT().MarkFailed(gomock.Any(), gomock.Any(), errors.ErrFake.Error()).Return(nil)
+			mockDLQ.EXPECT().
+				WriteMessages(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, msgs ...kafka.Message) error {
+					Expect(msgs).To(HaveLen(1))
+					Expect(msgs[0].Key).To(Equal(msg.Key))
+					Expect(msgs[0].Value).To(Equal(msg.Value))
+					headers := headersOf(msgs[0])
+					Expect(headers).To(HaveKeyWithValue("X-Request-ID", "req-1"))
+					Expect(headers).To(HaveKeyWithValue("dlq-error", errors.ErrFake.Error()))
+					Expect(headers).To(HaveKeyWithValue("dlq-original-topic", "transactions"))
+					Expect(headers).To(HaveKeyWithValue("dlq-original-partition", "3"))
+					Expect(headers).To(HaveKeyWithValue("dlq-original-offset", "42"))
+					Expect(headers).To(HaveKeyWithValue("dlq-attempts", "3"))
+					Expect(headers).To(HaveKey("dlq-failed-at"))
+					return nil
+				})
+			expectCommit()
+
+			Eventually(run()).Should(Receive(MatchError(context.Canceled)))
+		})
+
+		It("should dead-letter a malformed payload without processing it", func() {
+			msg.Value = []byte(`{"id": "not-a-uuid"`)
+			deliver()
+			mockDLQ.EXPECT().
+				WriteMessages(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, msgs ...kafka.Message) error {
+					headers := headersOf(msgs[0])
+					Expect(headers).To(HaveKeyWithValue("dlq-attempts", "0"))
+					Expect(headers).To(HaveKey("dlq-error"))
+					return nil
+				})
+			expectCommit()
+
+			Eventually(run()).Should(Receive(MatchError(context.Canceled)))
+		})
+
+		It("should commit a rejected transaction without dead-lettering it", func() {
+			deliver()
+			mockAccountRepo.EXPECT().ApplyTransaction(gomock.Any(), gomock.Any()).Return(nil, errors.ErrInsufficientFunds)
+			expectCommit()
+
+			Eventually(run()).Should(Receive(MatchError(context.Canceled)))
+		})
+
+		It("should stop without committing when the dead-letter topic stays unavailable", func() {
+			msg.Value = []byte("{")
+			deliver()
+			mockDLQ.EXPECT().WriteMessages(gomock.Any(), gomock.Any()).Return(errors.ErrFake).Times(3)
+			// no commit: the message is redelivered after a restart
+
+			var err error
+			Eventually(run()).Should(Receive(&err))
+			Expect(err).To(MatchError(errors.ErrFake))
+			Expect(err.Error()).To(ContainSubstring("dead-letter topic"))
+			Expect(consumer.State()).To(Equal(constants.ConsumerStopped))
+		})
+
+		Describe("metrics", func() {
+			// change returns how much c has grown since change was called.
+			change := func(c prometheus.Counter) func() float64 {
+				before := testutil.ToFloat64(c)
+				return func() float64 { return testutil.ToFloat64(c) - before }
+			}
+
+			It("should count processed transactions and retries", func() {
+				processed := change(metrics.TransactionsProcessed.WithLabelValues("deposit"))
+				retries := change(metrics.TransactionRetries)
+				deliver()
+				gomock.InOrder(
+					mockAccountRepo.EXPECT().ApplyTransaction(gomock.Any(), gomock.Any()).Return(nil, errors.ErrFake),
+					mockAccountRepo.EXPECT().ApplyTransaction(gomock.Any(), gomock.Any()).Return(&model.ProcessedTransaction{}, nil),
+				)
+				mockLedgerRepo.EXPECT().InsertJournalEntry(gomock.Any(), gomock.Any()).Return(nil)
+				mockLedgerRepo.EXPECT().InsertTransactions(gomock.Any(), gomock.Any()).Return(nil)
+				mockAccountRepo.EXPECT().MarkLedgerWritten(gomock.Any(), txn.ID).Return(nil)
+				expectCommit()
+
+				Eventually(run()).Should(Receive(MatchError(context.Canceled)))
+				Expect(processed()).To(Equal(1.0))
+				Expect(retries()).To(Equal(1.0))
+			})
+
+			It("should count a transaction that ran out of retries and its dead letter", func() {
+				failed := change(metrics.TransactionsFailed.WithLabelValues("deposit", "retries_exhausted"))
+				deadLettered := change(metrics.DeadLetterWrites.WithLabelValues("ok"))
+				deliver()
+				mockAccountRepo.EXPECT().ApplyTransaction(gomock.Any(), gomock.Any()).Return(nil, errors.ErrFake).Times(3)
+				mockTxnRepo.EXPECT().MarkFailed(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mockDLQ.EXPECT().WriteMessages(gomock.Any(), gomock.Any()).Return(nil)
+				expectCommit()
+
+				Eventually(run()).Should(Receive(MatchError(context.Canceled)))
+				Expect(failed()).To(Equal(1.0))
+				Expect(deadLettered()).To(Equal(1.0))
+			})
+
+			It("should count a malformed payload under an unknown type", func() {
+				failed := change(metrics.TransactionsFailed.WithLabelValues("unknown", "invalid_payload"))
+				msg.Value = []byte("{")
+				deliver()
+				mockDLQ.EXPECT().WriteMessages(gomock.Any(), gomock.Any()).Return(nil)
+				expectCommit()
+
+				Eventually(run()).Should(Receive(MatchError(context.Canceled)))
+				Expect(failed()).To(Equal(1.0))
+			})
+
+			It("should count every failed dead-letter write", func() {
+				dlqErrors := change(metrics.DeadLetterWrites.WithLabelValues("error"))
+				msg.Value = []byte("{")
+				deliver()
+				mockDLQ.EXPECT().WriteMessages(gomock.Any(), gomock.Any()).Return(errors.ErrFake).Times(3)
+
+				Eventually(run()).Should(Receive(MatchError(errors.ErrFake)))
+				Expect(dlqErrors()).To(Equal(3.0))
+			})
+
+			DescribeTable("labelling rejections",
+				func(err error, reason string) {
+					failed := change(metrics.TransactionsFailed.WithLabelValues("deposit", reason))
+					deliver()
+					mockAccountRepo.EXPECT().ApplyTransaction(gomock.Any(), gomock.Any()).Return(nil, err)
+					expectCommit()
+
+					Eventually(run()).Should(Receive(MatchError(context.Canceled)))
+					Expect(failed()).To(Equal(1.0))
+				},
+				Entry("insufficient funds", errors.ErrInsufficientFunds, "insufficient_funds"),
+				Entry("unknown account", errors.ErrAccountNotFound, "account_not_found"),
+				Entry("currency mismatch", errors.ErrCurrencyMismatch, "currency_mismatch"),
+				Entry("frozen account", errors.ErrAccountFrozen, "account_frozen"),
+				Entry("closed account", errors.ErrAccountClosed, "account_closed"),
+				Entry("expired hold", errors.ErrHoldExpired, "hold_expired"),
+				Entry("capture beyond the hold", errors.ErrCaptureExceedsHold, "capture_exceeds_hold"),
+				Entry("wrapped rejection", fmt.Errorf("applying transfer: %w", errors.ErrSameAccountTransfer), "same_account_transfer"),
+			)
+		})
+	})
+})
@@ -0,0 +1,180 @@
+package queue_test
+
+import (
+	"context"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	queue "github.com/imranzahoor/banking-ledger/pkg/kafka"
+	"github.com/imranzahoor/banking-ledger/test/mocks"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TransactionConsumer", func() {
+	var (
+		mockCtrl        *gomock.Controller
+		mockAccountRepo *mocks.MockAccountRepository
+		mockLedgerRepo  *mocks.MockLedgerRepository
+		mockTxnRepo     *mocks.MockTransactionRepository
+		consumer        *queue.TransactionConsumer
+		ctx             context.Context
+		txn             *model.Transaction
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAccountRepo = mocks.NewMockAccountRepository(mockCtrl)
+		mockLedgerRepo = mocks.NewMockLedgerRepository(mockCtrl)
+		mockTxnRepo = mocks.NewMockTransactionRepository(mockCtrl)
+		ctx = context.TODO()
+
+		kafkaCfg := config.KafkaConfig{
+			Brokers: []string{"localhost:9092"},
+			Topic:   "transactions",
+			GroupID: "test-group",
+		}
+		consumer = queue.NewTransactionConsumer(kafkaCfg, mockAccountRepo, mockLedgerRepo, mockTxnRepo)
+
+		txn = &model.Transaction{
+			ID:        uuid.New(),
+			AccountID: uuid.New(),
+			Type:      "deposit",
+			Amount:    1000,
+		}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should apply the balance, write the ledger and mark it written", func() {
+		gomock.InOrder(
+			mockAccountRepo.EXPECT().
+				ApplyTransaction(gomock.Any(), txn).
+				Return(&model.ProcessedTransaction{TransactionID: txn.ID}, nil),
+			mockLedgerRepo.EXPECT().InsertJournalEntry(gomock.Any(), gomock.Any()).Return(nil),
+			mockLedgerRepo.EXPECT().InsertTransactions(gomock.Any(), gomock.Len(1)).Return(nil),
+			mockAccountRepo.EXPECT().MarkLedgerWritten(gomock.Any(), txn.ID).Return(nil),
+		)
+
+		Expect(consumer.ProcessTransaction(ctx, txn)).To(Succeed())
+	})
+
+	It("should treat a redelivered transaction as a no-op", func() {
+		mockAccountRepo.EXPECT().
+			ApplyTransaction(gomock.Any(), txn).
+			Return(&model.ProcessedTransaction{TransactionID: txn.ID, LedgerWritten: true}, nil).
+			Times(1)
+
+		// no ledger writes and no second balance change are expected
+		Expect(consumer.ProcessTransaction(ctx, txn)).To(Succeed())
+	})
+
+	It("should finish the ledger write after a crash between Postgres and Mongo", func() {
+		gomock.InOrder(
+			mockAccountRepo.EXPECT().
+				ApplyTransaction(gomock.Any(), txn).
+				Return(&model.ProcessedTransaction{TransactionID: txn.ID, LedgerWritten: false}, nil),
+			mockLedgerRepo.EXPECT().InsertJournalEntry(gomock.Any(), gomock.Any()).Return(nil),
+			mockLedgerRepo.EXPECT().InsertTransactions(gomock.Any(), gomock.Any()).Return(nil),
+			mockAccountRepo.EXPECT().MarkLedgerWritten(gomock.Any(), txn.ID).Return(nil),
+		)
+
+		Expect(consumer.ProcessTransaction(ctx, txn)).To(Succeed())
+	})
+
+	It("should not mark the ledger written when the Mongo write fails", func() {
+		mockAccountRepo.EXPECT().
+			ApplyTransaction(gomock.Any(), txn).
+			Return(&model.ProcessedTransaction{TransactionID: txn.ID}, nil)
+		mockLedgerRepo.EXPECT().InsertJournalEntry(gomock.Any(), gomock.Any()).Return(errors.ErrFake)
+
+		Expect(consumer.ProcessTransaction(ctx, txn)).To(MatchError(errors.ErrFake))
+	})
+
+	It("should surface a rejected balance change without touching the ledger", func() {
+		mockAccountRepo.EXPECT().
+			ApplyTransaction(gomock.Any(), txn).
+			Return(nil, errors.ErrInsufficientFunds)
+
+		Expect(consumer.ProcessTransaction(ctx, txn)).To(MatchError(errors.ErrInsufficientFunds))
+	})
+
+	It("should post a capture against the cash account", func() {
+		txn.Type = constants.Capture
+		txn.HoldID = uuid.New()
+		gomock.InOrder(
+			mockAccountRepo.EXPECT().
+				ApplyTransaction(gomock.Any(), txn).
+				Return(&model.ProcessedTransaction{TransactionID: txn.ID}, nil),
+			mockLedgerRepo.EXPECT().
+				InsertJournalEntry(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, entry *model.JournalEntry) error {
+					Expect(entry.Validate()).To(Succeed())
+					Expect(entry.Postings[0].AccountID).To(Equal(txn.AccountID))
+					Expect(entry.Postings[1].AccountID).To(Equal(constants.CashAccountID))
+					return nil
+				}),
+			mockLedgerRepo.EXPECT().InsertTransactions(gomock.Any(), gomock.Len(1)).Return(nil),
+			mockAccountRepo.EXPECT().MarkLedgerWritten(gomock.Any(), txn.ID).Return(nil),
+		)
+
+		Expect(consumer.ProcessTransaction(ctx, txn)).To(Succeed())
+	})
+
+	Describe("transfers", func() {
+		BeforeEach(func() {
+			txn.Type = constants.Transfer
+			txn.CounterpartyID = uuid.New()
+			txn.Currency = "USD"
+		})
+
+		It("should post both sides to the ledger", func() {
+			gomock.InOrder(
+				mockAccountRepo.EXPECT().
+					ApplyTransaction(gomock.Any(), txn).
+					Return(&model.ProcessedTransaction{TransactionID: txn.ID}, nil),
+				mockLedgerRepo.EXPECT().
+					InsertJournalEntry(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, entry *model.JournalEntry) error {
+						Expect(entry.Validate()).To(Succeed())
+						Expect(entry.Postings).To(ConsistOf(
+							model.Posting{AccountID: txn.AccountID, Direction: constants.Debit, Amount: 1000},
+							model.Posting{AccountID: txn.CounterpartyID, Direction: constants.Credit, Amount: 1000},
+						))
+						return nil
+					}),
+				mockLedgerRepo.EXPECT().
+					InsertTransactions(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, entries []*model.Transaction) error {
+						Expect(entries).To(HaveLen(2))
+						Expect(entries[0].AccountID).To(Equal(txn.AccountID))
+						Expect(entries[0].Direction).To(Equal(constants.Debit))
+						Expect(entries[1].AccountID).To(Equal(txn.CounterpartyID))
+						Expect(entries[1].Direction).To(Equal(constants.Credit))
+						return nil
+					}),
+				mockAccountRepo.EXPECT().MarkLedgerWritten(gomock.Any(), txn.ID).Return(nil),
+			)
+
+			Expect(consumer.ProcessTransaction(ctx, txn)).To(Succeed())
+		})
+
+		DescribeTable("rejected by the account repository",
+			func(rejection error) {
+				mockAccountRepo.EXPECT().ApplyTransaction(gomock.Any(), txn).Return(nil, rejection)
+				// neither side reaches the ledger
+
+				Expect(consumer.ProcessTransaction(ctx, txn)).To(MatchError(rejection))
+			},
+			Entry("between currencies", errors.ErrCurrencyMismatch),
+			Entry("into a closed account", errors.ErrAccountClosed),
+		)
+	})
+})
@@ -0,0 +1,424 @@
+package repository_test
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/repository/postgres"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("AccountRepo", func() {
+	var (
+		db   *gorm.DB
+		repo *postgres.AccountRepo
+		ctx  context.Context
+	)
+
+	BeforeEach(func() {
+		db = newTestDB()
+		repo = postgres.NewAccountRepo(db)
+		ctx = context.TODO()
+	})
+
+	createAccount := func(balance, overdraftLimit int64) *model.Account {
+		acc := &model.Account{
+			OwnerName:      "Alice",
+			Balance:        balance,
+			Currency:       "USD",
+			OverdraftLimit: overdraftLimit,
+			Status:         constants.AccountActive,
+		}
+		_, err := repo.CreateAccount(ctx, acc, newTestMessage)
+		Expect(err).NotTo(HaveOccurred())
+		return acc
+	}
+
+	balanceOf := func(id uuid.UUID) int64 {
+		acc, err := repo.GetAccountByID(ctx, id.String())
+		Expect(err).NotTo(HaveOccurred())
+		return acc.Balance
+	}
+
+	countRows := func(value interface{}) int64 {
+		var n int64
+		Expect(db.Model(value).Count(&n).Error).NotTo(HaveOccurred())
+		return n
+	}
+
+	Describe("CreateAccount", func() {
+		It("should record a non-zero opening balance with the account", func() {
+			acc := &model.Account{OwnerName: "Alice", Balance: 500, Currency: "USD", Status: constants.AccountActive}
+			opening, err := repo.CreateAccount(ctx, acc, newTestMessage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(opening).NotTo(BeNil())
+			Expect(opening.AccountID).To(Equal(acc.ID))
+			Expect(opening.Type).To(Equal(constants.Deposit))
+			Expect(opening.Amount).To(Equal(int64(500)))
+
+			var stored model.Transaction
+			Expect(db.First(&stored, "id = ?", opening.ID).Error).NotTo(HaveOccurred())
+			Expect(stored.Status).To(Equal(constants.StatusCompleted))
+			Expect(countRows(&model.OutboxMessage{})).To(Equal(int64(1)))
+
+			pending, err := repo.ListPendingLedgerAccounts(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pending).To(Equal([]uuid.UUID{acc.ID}))
+		})
+
+		It("should not record an opening transaction for a zero balance", func() {
+			opening, err := repo.CreateAccount(ctx, &model.Account{OwnerName: "Alice", Currency: "USD", Status: constants.AccountActive}, newTestMessage)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(opening).To(BeNil())
+			Expect(countRows(&model.Transaction{})).To(BeZero())
+			Expect(countRows(&model.OutboxMessage{})).To(BeZero())
+		})
+
+		It("should roll the account back when the opening balance cannot be enqueued", func() {
+			failure := stderrors.New("encode failed")
+			acc := &model.Account{OwnerName: "Alice", Balance: 500, Currency: "USD", Status: constants.AccountActive}
+			_, err := repo.CreateAccount(ctx, acc, func(*model.Transaction) (*model.OutboxMessage, error) {
+				return nil, failure
+			})
+			Expect(err).To(MatchError(failure))
+
+			stored, err := repo.GetAccountByID(ctx, acc.ID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stored).To(BeNil())
+			Expect(countRows(&model.Transaction{})).To(BeZero())
+		})
+	})
+
+	Describe("transfers", func() {
+		var from, to *model.Account
+
+		BeforeEach(func() {
+			from = createAccount(1000, 0)
+			to = createAccount(100, 0)
+		})
+
+		transfer := func(amount int64, currencyCode string) *model.Transaction {
+			return &model.Transaction{
+				ID:             uuid.New(),
+				AccountID:      from.ID,
+				CounterpartyID: to.ID,
+				Type:           constants.Transfer,
+				Amount:         amount,
+				Currency:       currencyCode,
+			}
+		}
+
+		It("should move the amount between both balances", func() {
+			processed, err := repo.ApplyTransaction(ctx, transfer(300, "USD"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(processed.LedgerWritten).To(BeFalse())
+
+			Expect(balanceOf(from.ID)).To(Equal(int64(700)))
+			Expect(balanceOf(to.ID)).To(Equal(int64(400)))
+		})
+
+		It("should leave both balances alone when the source cannot cover it", func() {
+			_, err := repo.ApplyTransaction(ctx, transfer(1001, "USD"))
+			Expect(err).To(MatchError(errors.ErrInsufficientFunds))
+
+			Expect(balanceOf(from.ID)).To(Equal(int64(1000)))
+			Expect(balanceOf(to.ID)).To(Equal(int64(100)))
+		})
+
+		It("should undo the debit when the destination is closed", func() {
+			Expect(db.Model(to).Update("status", constants.AccountClosed).Error).NotTo(HaveOccurred())
+
+			_, err := repo.ApplyTransaction(ctx, transfer(300, "USD"))
+			Expect(err).To(MatchError(errors.ErrAccountClosed))
+
+			Expect(balanceOf(from.ID)).To(Equal(int64(1000)))
+			Expect(balanceOf(to.ID)).To(Equal(int64(100)))
+		})
+
+		DescribeTable("between currencies",
+			func(currencyCode string) {
+				Expect(db.Model(to).Update("currency", "EUR").Error).NotTo(HaveOccurred())
+
+				_, err := repo.ApplyTransaction(ctx, transfer(300, currencyCode))
+				Expect(err).To(MatchError(errors.ErrCurrencyMismatch))
+
+				Expect(balanceOf(from.ID)).To(Equal(int64(1000)))
+				Expect(balanceOf(to.ID)).To(Equal(int64(100)))
+			},
+			Entry("in the source's currency", "USD"),
+			Entry("in the destination's currency", "EUR"),
+			Entry("from a message without a currency", ""),
+		)
+
+		It("should reject a transfer to the same account", func() {
+			txn := transfer(300, "USD")
+			txn.CounterpartyID = from.ID
+
+			_, err := repo.ApplyTransaction(ctx, txn)
+			Expect(err).To(MatchError(errors.ErrSameAccountTransfer))
+			Expect(balanceOf(from.ID)).To(Equal(int64(1000)))
+		})
+	})
+
+	Describe("overdraft limits", func() {
+		It("should accept a deposit into an account drawn beyond a lowered limit", func() {
+			acc := createAccount(200, 1000)
+			_, err := repo.ApplyTransaction(ctx, &model.Transaction{
+				ID:        uuid.New(),
+				AccountID: acc.ID,
+				Type:      constants.Withdrawal,
+				Amount:    1000,
+				Currency:  "USD",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(balanceOf(acc.ID)).To(Equal(int64(-800)))
+
+			_, err = repo.SetOverdraftLimit(ctx, acc.ID, 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = repo.ApplyTransaction(ctx, &model.Transaction{
+				ID:        uuid.New(),
+				AccountID: acc.ID,
+				Type:      constants.Deposit,
+				Amount:    100,
+				Currency:  "USD",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(balanceOf(acc.ID)).To(Equal(int64(-700)))
+		})
+
+		It("should still reject a debit beyond the lowered limit", func() {
+			acc := createAccount(-800, 0)
+
+			_, err := repo.ApplyTransaction(ctx, &model.Transaction{
+				ID:        uuid.New(),
+				AccountID: acc.ID,
+				Type:      constants.Withdrawal,
+				Amount:    1,
+				Currency:  "USD",
+			})
+			Expect(err).To(MatchError(errors.ErrInsufficientFunds))
+			Expect(balanceOf(acc.ID)).To(Equal(int64(-800)))
+		})
+	})
+
+	Describe("SetOwner", func() {
+		It("should hand an existing account to a customer", func() {
+			acc := createAccount(0, 0)
+			updated, err := repo.SetOwner(ctx, acc.ID, "user-42")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated.OwnerID).To(Equal("user-42"))
+
+			stored, err := repo.GetAccountByID(ctx, acc.ID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stored.OwnerID).To(Equal("user-42"))
+		})
+
+		It("should not change the owner of a closed account", func() {
+			acc := createAccount(0, 0)
+			Expect(db.Model(acc).Update("status", constants.AccountClosed).Error).NotTo(HaveOccurred())
+
+			_, err := repo.SetOwner(ctx, acc.ID, "user-42")
+			Expect(err).To(MatchError(errors.ErrAccountClosed))
+		})
+
+		It("should report an unknown account", func() {
+			_, err := repo.SetOwner(ctx, uuid.New(), "user-42")
+			Expect(err).To(MatchError(errors.ErrAccountNotFound))
+		})
+	})
+
+	Describe("holds", func() {
+		var acc *model.Account
+
+		BeforeEach(func() {
+			acc = createAccount(1000, 0)
+		})
+
+		heldOf := func(id uuid.UUID) int64 {
+			acc, err := repo.GetAccountByID(ctx, id.String())
+			Expect(err).NotTo(HaveOccurred())
+			return acc.HeldBalance
+		}
+
+		holdStatus := func(id uuid.UUID) constants.HoldStatus {
+			hold, err := repo.GetHold(ctx, id)
+			Expect(err).NotTo(HaveOccurred())
+			return hold.Status
+		}
+
+		place := func(amount int64) uuid.UUID {
+			expiresAt := time.Now().UTC().Add(time.Hour)
+			txn := &model.Transaction{
+				ID:        uuid.New(),
+				AccountID: acc.ID,
+				Type:      constants.Hold,
+				Amount:    amount,
+				Currency:  "USD",
+				ExpiresAt: &expiresAt,
+			}
+			_, err := repo.ApplyTransaction(ctx, txn)
+			Expect(err).NotTo(HaveOccurred())
+			return txn.ID
+		}
+
+		settle := func(typ constants.TransactionType, holdID uuid.UUID, amount int64) error {
+			_, err := repo.ApplyTransaction(ctx, &model.Transaction{
+				ID:        uuid.New(),
+				AccountID: acc.ID,
+				HoldID:    holdID,
+				Type:      typ,
+				Amount:    amount,
+				Currency:  "USD",
+			})
+			return err
+		}
+
+		lapse := func(holdID uuid.UUID) {
+			Expect(db.Model(&model.Hold{}).Where("id = ?", holdID).
+				Update("expires_at", time.Now().UTC().Add(-time.Minute)).Error).NotTo(HaveOccurred())
+		}
+
+		It("should reserve funds until the hold is captured", func() {
+			id := place(400)
+			Expect(heldOf(acc.ID)).To(Equal(int64(400)))
+			Expect(balanceOf(acc.ID)).To(Equal(int64(1000)))
+
+			Expect(settle(constants.Capture, id, 400)).To(Succeed())
+			Expect(heldOf(acc.ID)).To(BeZero())
+			Expect(balanceOf(acc.ID)).To(Equal(int64(600)))
+			Expect(holdStatus(id)).To(Equal(constants.HoldCaptured))
+		})
+
+		It("should return the funds when the hold is voided", func() {
+			id := place(400)
+
+			Expect(settle(constants.Void, id, 0)).To(Succeed())
+			Expect(heldOf(acc.ID)).To(BeZero())
+			Expect(balanceOf(acc.ID)).To(Equal(int64(1000)))
+			Expect(holdStatus(id)).To(Equal(constants.HoldVoided))
+		})
+
+		It("should release the rest of a partially captured hold", func() {
+			id := place(400)
+
+			Expect(settle(constants.Capture, id, 150)).To(Succeed())
+			Expect(heldOf(acc.ID)).To(BeZero())
+			Expect(balanceOf(acc.ID)).To(Equal(int64(850)))
+
+			hold, err := repo.GetHold(ctx, id)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hold.Captured).To(Equal(int64(150)))
+			Expect(settle(constants.Capture, id, 100)).To(MatchError(errors.ErrHoldNotActive))
+		})
+
+		It("should not capture more than is held", func() {
+			id := place(400)
+
+			Expect(settle(constants.Capture, id, 401)).To(MatchError(errors.ErrCaptureExceedsHold))
+			Expect(heldOf(acc.ID)).To(Equal(int64(400)))
+			Expect(balanceOf(acc.ID)).To(Equal(int64(1000)))
+			Expect(holdStatus(id)).To(Equal(constants.HoldActive))
+		})
+
+		It("should not capture an expired hold", func() {
+			id := place(400)
+			lapse(id)
+
+			Expect(settle(constants.Capture, id, 400)).To(MatchError(errors.ErrHoldExpired))
+			Expect(balanceOf(acc.ID)).To(Equal(int64(1000)))
+		})
+
+		It("should keep held funds from being withdrawn", func() {
+			place(800)
+
+			withdraw := func(amount int64) error {
+				_, err := repo.ApplyTransaction(ctx, &model.Transaction{
+					ID:        uuid.New(),
+					AccountID: acc.ID,
+					Type:      constants.Withdrawal,
+					Amount:    amount,
+					Currency:  "USD",
+				})
+				return err
+			}
+			Expect(withdraw(201)).To(MatchError(errors.ErrInsufficientFunds))
+			Expect(withdraw(200)).To(Succeed())
+			Expect(balanceOf(acc.ID)).To(Equal(int64(800)))
+		})
+
+		Describe("ExpireHolds", func() {
+			It("should release lapsed holds and skip settled ones", func() {
+				active, captured, voided, current := place(100), place(200), place(300), place(50)
+				Expect(settle(constants.Capture, captured, 200)).To(Succeed())
+				Expect(settle(constants.Void, voided, 0)).To(Succeed())
+				for _, id := range []uuid.UUID{active, captured, voided} {
+					lapse(id)
+				}
+
+				expired, err := repo.ExpireHolds(ctx, time.Now().UTC(), 10)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(expired).To(Equal(1))
+
+				Expect(holdStatus(active)).To(Equal(constants.HoldExpired))
+				Expect(holdStatus(captured)).To(Equal(constants.HoldCaptured))
+				Expect(holdStatus(voided)).To(Equal(constants.HoldVoided))
+				Expect(holdStatus(current)).To(Equal(constants.HoldActive))
+				Expect(heldOf(acc.ID)).To(Equal(int64(50)))
+				Expect(balanceOf(acc.ID)).To(Equal(int64(800)))
+			})
+
+			It("should release no more than limit holds at once", func() {
+				for range 3 {
+					lapse(place(100))
+				}
+
+				expired, err := repo.ExpireHolds(ctx, time.Now().UTC(), 2)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(expired).To(Equal(2))
+				Expect(heldOf(acc.ID)).To(Equal(int64(100)))
+			})
+		})
+
+		It("should not close an account with active holds", func() {
+			place(400)
+
+			_, _, err := repo.CloseAccount(ctx, acc.ID, uuid.Nil, newTestMessage)
+			Expect(err).To(MatchError(errors.ErrActiveHolds))
+		})
+	})
+
+	Describe("ListPendingLedgerAccounts", func() {
+		It("should list both sides of a transfer until its ledger entries are written", func() {
+			from := createAccount(0, 500)
+			to := createAccount(0, 0)
+			txn := &model.Transaction{
+				ID:             uuid.New(),
+				AccountID:      from.ID,
+				CounterpartyID: to.ID,
+				Type:           constants.Transfer,
+				Amount:         200,
+				Currency:       "USD",
+			}
+			_, err := repo.ApplyTransaction(ctx, txn)
+			Expect(err).NotTo(HaveOccurred())
+
+			pending, err := repo.ListPendingLedgerAccounts(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pending).To(ConsistOf(Equal(from.ID), Equal(to.ID)))
+
+			Expect(repo.MarkLedgerWritten(ctx, txn.ID)).To(Succeed())
+			pending, err = repo.ListPendingLedgerAccounts(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pending).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,125 @@
+package repository_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/repository/mongo"
+	"github.com/imranzahoor/banking-ledger/internal/repository/postgres"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("Instrumentation", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.TODO()
+	})
+
+	// calls returns how many calls with the given labels have been timed since
+	// calls was called.
+	calls := func(store, operation, table, outcome string) func() uint64 {
+		count := func() uint64 {
+			var m dto.Metric
+			Expect(metrics.DBCallDuration.WithLabelValues(store, operation, table, outcome).(prometheus.Histogram).Write(&m)).To(Succeed())
+			return m.GetHistogram().GetSampleCount()
+		}
+		before := count()
+		return func() uint64 { return count() - before }
+	}
+
+	Describe("Postgres", func() {
+		var (
+			db   *gorm.DB
+			repo *postgres.AccountRepo
+		)
+
+		BeforeEach(func() {
+			db = newTestDB()
+			Expect(postgres.Instrument(db)).To(Succeed())
+			repo = postgres.NewAccountRepo(db)
+		})
+
+		It("should time repository calls by operation and table", func() {
+			created := calls("postgres", "create", "accounts", "ok")
+			queried := calls("postgres", "query", "accounts", "ok")
+
+			acc := &model.Account{OwnerName: "Alice", Currency: "USD", Status: constants.AccountActive}
+			_, err := repo.CreateAccount(ctx, acc, newTestMessage)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = repo.GetAccountByID(ctx, acc.ID.String())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(created()).To(Equal(uint64(1)))
+			Expect(queried()).To(Equal(uint64(1)))
+		})
+
+		It("should not count a missing record as a failure", func() {
+			queried := calls("postgres", "query", "accounts", "ok")
+			failed := calls("postgres", "query", "accounts", "error")
+
+			acc, err := repo.GetAccountByID(ctx, uuid.New().String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(acc).To(BeNil())
+
+			Expect(queried()).To(Equal(uint64(1)))
+			Expect(failed()).To(BeZero())
+		})
+
+		It("should count a failed statement", func() {
+			failed := calls("postgres", "query", "accounts", "error")
+
+			Expect(db.Migrator().DropTable(&model.Account{})).To(Succeed())
+			_, err := repo.GetAccountByID(ctx, uuid.New().String())
+			Expect(err).To(HaveOccurred())
+
+			Expect(failed()).To(Equal(uint64(1)))
+		})
+	})
+
+	Describe("MongoDB", func() {
+		var (
+			mt   *mtest.T
+			repo *mongo.LedgerRepo
+		)
+
+		BeforeEach(func() {
+			mt = mtest.New(suiteT, mtest.NewOptions().
+				ClientType(mtest.Mock).
+				ShareClient(true).
+				ClientOptions(options.Client().SetMonitor(mongo.NewCommandMonitor())))
+			repo = mongo.NewLedgerRepo(mt.Client, "ledger")
+		})
+
+		It("should time commands by name and collection", func() {
+			found := calls("mongo", "find", "journal_entries", "ok")
+
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "ledger.journal_entries", mtest.FirstBatch))
+			_, err := repo.GetJournalEntry(ctx, uuid.New())
+			Expect(err).To(MatchError(errors.ErrJournalEntryNotFound))
+
+			Expect(found()).To(Equal(uint64(1)))
+		})
+
+		It("should count a failed command", func() {
+			failed := calls("mongo", "find", "journal_entries", "error")
+
+			mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 2, Message: "bad value"}))
+			_, err := repo.GetJournalEntry(ctx, uuid.New())
+			Expect(err).To(HaveOccurred())
+
+			Expect(failed()).To(Equal(uint64(1)))
+		})
+	})
+})
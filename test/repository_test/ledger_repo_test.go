@@ -0,0 +1,208 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/repository/mongo"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+var _ = Describe("LedgerRepo", func() {
+	var (
+		mt        *mtest.T
+		repo      *mongo.LedgerRepo
+		ctx       context.Context
+		accountID uuid.UUID
+	)
+
+	BeforeEach(func() {
+		mt = newMockMongo()
+		repo = mongo.NewLedgerRepo(mt.Client, "ledger")
+		ctx = context.TODO()
+		accountID = uuid.New()
+	})
+
+	entry := func(id primitive.ObjectID, createdAt time.Time) bson.D {
+		return bson.D{
+			{Key: "_id", Value: id},
+			{Key: "id", Value: uuid.New()},
+			{Key: "accountid", Value: accountID},
+			{Key: "type", Value: constants.Deposit},
+			{Key: "amount", Value: int64(100)},
+			{Key: "createdat", Value: createdAt},
+		}
+	}
+
+	respond := func(docs ...bson.D) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "ledger.transactions", mtest.FirstBatch, docs...))
+	}
+
+	// findCommand returns the oldest find command not yet inspected.
+	findCommand := func() bson.M {
+		evt := mt.GetStartedEvent()
+		Expect(evt).NotTo(BeNil())
+		Expect(evt.CommandName).To(Equal("find"))
+		var cmd bson.M
+		Expect(bson.Unmarshal(evt.Command, &cmd)).To(Succeed())
+		return cmd
+	}
+
+	Describe("GetTransactionsPage", func() {
+		var (
+			newer, older  time.Time
+			idA, idB, idC primitive.ObjectID
+			noFilter      model.TransactionFilter
+			page          []model.Transaction
+			next          string
+			err           error
+		)
+
+		BeforeEach(func() {
+			newer = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+			older = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+			idA, idB, idC = primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+		})
+
+		It("should page newest first and continue after entries sharing a timestamp", func() {
+			// B and C were written in the same millisecond; _id breaks the tie
+			respond(entry(idA, newer), entry(idC, older), entry(idB, older))
+			page, next, err = repo.GetTransactionsPage(ctx, accountID, noFilter, "", 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(page).To(HaveLen(2))
+			Expect(page[0].CreatedAt).To(Equal(newer))
+			Expect(next).NotTo(BeEmpty())
+
+			cmd := findCommand()
+			Expect(cmd["sort"]).To(Equal(bson.M{"createdat": int32(-1), "_id": int32(-1)}))
+			Expect(cmd["limit"]).To(BeEquivalentTo(3))
+			Expect(cmd["filter"]).To(Equal(bson.M{"accountid": primitive.Binary{Subtype: 0x00, Data: accountID[:]}}))
+
+			respond(entry(idB, older))
+			page, next, err = repo.GetTransactionsPage(ctx, accountID, noFilter, next, 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(page).To(HaveLen(1))
+			Expect(next).To(BeEmpty())
+
+			// the cursor round-trips the last entry's timestamp and _id
+			filter := findCommand()["filter"].(bson.M)
+			Expect(filter["$or"]).To(Equal(bson.A{
+				bson.M{"createdat": bson.M{"$lt": primitive.NewDateTimeFromTime(older)}},
+				bson.M{"createdat": primitive.NewDateTimeFromTime(older), "_id": bson.M{"$lt": idC}},
+			}))
+		})
+
+		It("should return no cursor when the last page is full but nothing follows", func() {
+			respond(entry(idA, newer), entry(idB, older))
+			page, next, err = repo.GetTransactionsPage(ctx, accountID, noFilter, "", 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(page).To(HaveLen(2))
+			Expect(next).To(BeEmpty())
+		})
+
+		DescribeTable("should reject a malformed cursor without querying",
+			func(cursor func(valid string) string) {
+				respond(entry(idA, newer), entry(idB, older), entry(idC, older))
+				_, valid, err := repo.GetTransactionsPage(ctx, accountID, noFilter, "", 1)
+				Expect(err).NotTo(HaveOccurred())
+				findCommand()
+
+				_, _, err = repo.GetTransactionsPage(ctx, accountID, noFilter, cursor(valid), 1)
+				Expect(err).To(MatchError(errors.ErrInvalidCursor))
+				Expect(mt.GetStartedEvent()).To(BeNil())
+			},
+			Entry("not base64", func(string) string { return "not a cursor!" }),
+			Entry("truncated", func(valid string) string { return valid[:len(valid)-2] }),
+			Entry("extended", func(valid string) string { return valid + "AAAA" }),
+			Entry("padded base64", func(valid string) string {
+				raw, _ := base64.RawURLEncoding.DecodeString(valid)
+				return base64.URLEncoding.EncodeToString(raw)
+			}),
+		)
+
+		It("should keep a rewritten cursor within the caller's account", func() {
+			respond(entry(idA, newer), entry(idB, older))
+			_, valid, err := repo.GetTransactionsPage(ctx, accountID, noFilter, "", 1)
+			Expect(err).NotTo(HaveOccurred())
+			findCommand()
+
+			raw, err := base64.RawURLEncoding.DecodeString(valid)
+			Expect(err).NotTo(HaveOccurred())
+			raw[0] ^= 0xff
+			respond()
+			_, _, err = repo.GetTransactionsPage(ctx, accountID, noFilter, base64.RawURLEncoding.EncodeToString(raw), 1)
+			Expect(err).NotTo(HaveOccurred())
+
+			filter := findCommand()["filter"].(bson.M)
+			Expect(filter).To(HaveKeyWithValue("accountid", primitive.Binary{Subtype: 0x00, Data: accountID[:]}))
+		})
+	})
+
+	Describe("transaction filters", func() {
+		var from, to time.Time
+
+		BeforeEach(func() {
+			from = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+			to = time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+		})
+
+		bounds := bson.M{
+			"$gt":  primitive.NewDateTimeFromTime(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)),
+			"$lte": primitive.NewDateTimeFromTime(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)),
+		}
+
+		It("should translate every filter into the query", func() {
+			minAmount, maxAmount := int64(100), int64(5000)
+			filter := model.TransactionFilter{
+				From:        &from,
+				To:          &to,
+				Types:       []constants.TransactionType{constants.Withdrawal, constants.Transfer},
+				MinAmount:   &minAmount,
+				MaxAmount:   &maxAmount,
+				Description: "rent (march)",
+			}
+
+			respond()
+			_, _, err := repo.GetTransactionsPage(ctx, accountID, filter, "", 10)
+			Expect(err).NotTo(HaveOccurred())
+
+			query := findCommand()["filter"].(bson.M)
+			Expect(query).To(HaveKeyWithValue("createdat", bounds))
+			Expect(query).To(HaveKeyWithValue("type", bson.M{"$in": bson.A{"withdrawal", "transfer"}}))
+			Expect(query).To(HaveKeyWithValue("amount", bson.M{"$gte": int64(100), "$lte": int64(5000)}))
+			// matched literally and case-insensitively
+			Expect(query).To(HaveKeyWithValue("description", primitive.Regex{Pattern: `rent \(march\)`, Options: "i"}))
+		})
+
+		It("should leave out bounds that are not given", func() {
+			minAmount := int64(100)
+			respond()
+			_, err := repo.GetTransactionsByAccountID(ctx, accountID, model.TransactionFilter{To: &to, MinAmount: &minAmount}, 10, 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			query := findCommand()["filter"].(bson.M)
+			Expect(query).To(HaveKeyWithValue("createdat", bson.M{"$lte": bounds["$lte"]}))
+			Expect(query).To(HaveKeyWithValue("amount", bson.M{"$gte": int64(100)}))
+			Expect(query).NotTo(HaveKey("type"))
+			Expect(query).NotTo(HaveKey("description"))
+		})
+
+		It("should bound history the same way as statements", func() {
+			respond()
+			Expect(repo.StreamTransactionsByAccountID(ctx, accountID, from, to, func(*model.Transaction) error {
+				return nil
+			})).To(Succeed())
+			Expect(findCommand()["filter"].(bson.M)).To(HaveKeyWithValue("createdat", bounds))
+		})
+	})
+})
@@ -0,0 +1,130 @@
+package repository_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/repository/postgres"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("OutboxRepo", func() {
+	var (
+		db   *gorm.DB
+		repo *postgres.OutboxRepo
+		ctx  context.Context
+	)
+
+	BeforeEach(func() {
+		db = newTestDB()
+		repo = postgres.NewOutboxRepo(db)
+		ctx = context.TODO()
+	})
+
+	enqueue := func() *model.OutboxMessage {
+		msg, err := newTestMessage(&model.Transaction{ID: uuid.New(), AccountID: uuid.New(), Amount: 100})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(repo.Enqueue(ctx, msg)).To(Succeed())
+		return msg
+	}
+
+	reload := func(id uuid.UUID) model.OutboxMessage {
+		var msg model.OutboxMessage
+		Expect(db.First(&msg, "id = ?", id).Error).NotTo(HaveOccurred())
+		return msg
+	}
+
+	noRetry := func(int) time.Duration { return time.Hour }
+
+	It("should publish due messages in creation order and mark them sent", func() {
+		first, second := enqueue(), enqueue()
+
+		var published []uuid.UUID
+		sent, err := repo.PublishPending(ctx, 10, func(msgs []model.OutboxMessage) error {
+			for _, m := range msgs {
+				published = append(published, m.ID)
+			}
+			return nil
+		}, noRetry)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sent).To(Equal(2))
+		Expect(published).To(Equal([]uuid.UUID{first.ID, second.ID}))
+		Expect(reload(first.ID).SentAt).NotTo(BeNil())
+		Expect(reload(second.ID).SentAt).NotTo(BeNil())
+
+		sent, err = repo.PublishPending(ctx, 10, func([]model.OutboxMessage) error {
+			Fail("sent messages must not be published again")
+			return nil
+		}, noRetry)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sent).To(BeZero())
+	})
+
+	It("should publish at most limit messages per call", func() {
+		enqueue()
+		enqueue()
+		enqueue()
+
+		sent, err := repo.PublishPending(ctx, 2, func(msgs []model.OutboxMessage) error {
+			Expect(msgs).To(HaveLen(2))
+			return nil
+		}, noRetry)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sent).To(Equal(2))
+
+		sent, err = repo.PublishPending(ctx, 2, func([]model.OutboxMessage) error { return nil }, noRetry)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sent).To(Equal(1))
+	})
+
+	It("should reschedule messages that fail to publish", func() {
+		msg := enqueue()
+
+		var backoffFor []int
+		retryAfter := func(attempts int) time.Duration {
+			backoffFor = append(backoffFor, attempts)
+			return -time.Second // due again straight away
+		}
+		failing := func([]model.OutboxMessage) error { return errors.ErrFake }
+
+		for i := 0; i < 2; i++ {
+			sent, err := repo.PublishPending(ctx, 10, failing, retryAfter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sent).To(BeZero())
+		}
+		Expect(backoffFor).To(Equal([]int{1, 2}))
+
+		stored := reload(msg.ID)
+		Expect(stored.Attempts).To(Equal(2))
+		Expect(stored.LastError).To(Equal(errors.ErrFake.Error()))
+		Expect(stored.SentAt).To(BeNil())
+
+		sent, err := repo.PublishPending(ctx, 10, func([]model.OutboxMessage) error { return nil }, retryAfter)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sent).To(Equal(1))
+		stored = reload(msg.ID)
+		Expect(stored.SentAt).NotTo(BeNil())
+		Expect(stored.LastError).To(BeEmpty())
+	})
+
+	It("should hold back a failed message until its retry is due", func() {
+		enqueue()
+
+		sent, err := repo.PublishPending(ctx, 10, func([]model.OutboxMessage) error { return errors.ErrFake }, noRetry)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sent).To(BeZero())
+
+		sent, err = repo.PublishPending(ctx, 10, func([]model.OutboxMessage) error {
+			Fail("the message is not due yet")
+			return nil
+		}, noRetry)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sent).To(BeZero())
+	})
+})
@@ -0,0 +1,74 @@
+package repository_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// suiteT is the test running the suite; the MongoDB driver's mock deployment
+// is tied to one.
+var suiteT *testing.T
+
+func TestRepository(t *testing.T) {
+	suiteT = t
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Repository Suite")
+}
+
+// newTestDB opens an in-memory SQLite database with the Postgres schema.
+// The repositories always assign IDs themselves, so the Postgres-only
+// gen_random_uuid() column default is dropped from the DDL.
+func newTestDB() *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	// every connection to :memory: is a separate database
+	sqlDB, err := db.DB()
+	Expect(err).NotTo(HaveOccurred())
+	sqlDB.SetMaxOpenConns(1)
+	DeferCleanup(sqlDB.Close)
+
+	Expect(db.Callback().Raw().Before("gorm:raw").Register("test:drop_uuid_default", func(db *gorm.DB) {
+		sql := strings.ReplaceAll(db.Statement.SQL.String(), " DEFAULT gen_random_uuid()", "")
+		db.Statement.SQL.Reset()
+		db.Statement.SQL.WriteString(sql)
+	})).To(Succeed())
+
+	Expect(db.AutoMigrate(
+		&model.Account{},
+		&model.Transaction{},
+		&model.IdempotencyKey{},
+		&model.OutboxMessage{},
+		&model.ProcessedTransaction{},
+		&model.Hold{},
+		&model.Schedule{},
+		&model.ScheduleExecution{},
+		&model.APIKey{},
+	)).To(Succeed())
+	return db
+}
+
+// newTestMessage builds the outbox message for a transaction the repositories
+// apply directly.
+func newTestMessage(txn *model.Transaction) (*model.OutboxMessage, error) {
+	return model.NewTransactionMessage("transactions", txn, nil)
+}
+
+// newMockMongo returns a MongoDB client backed by the driver's mock deployment.
+// Queue replies with AddMockResponses and read the commands sent with
+// GetStartedEvent; no server is involved, so query semantics are not checked.
+// The client is disconnected when the suite ends.
+func newMockMongo() *mtest.T {
+	return mtest.New(suiteT, mtest.NewOptions().ClientType(mtest.Mock).ShareClient(true))
+}
@@ -0,0 +1,158 @@
+package repository_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/repository/postgres"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+)
+
+var _ = Describe("ScheduleRepo", func() {
+	var (
+		repo *postgres.ScheduleRepo
+		ctx  context.Context
+		now  time.Time
+	)
+
+	BeforeEach(func() {
+		repo = postgres.NewScheduleRepo(newTestDB())
+		ctx = context.TODO()
+		now = time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	})
+
+	// createSchedule stores a monthly withdrawal next due at nextRunAt.
+	createSchedule := func(nextRunAt time.Time, status constants.ScheduleStatus) *model.Schedule {
+		sched := &model.Schedule{
+			AccountID: uuid.New(),
+			Type:      constants.Withdrawal,
+			Amount:    500,
+			Currency:  "USD",
+			Cron:      "0 9 1 * *",
+			Status:    status,
+			NextRunAt: &nextRunAt,
+		}
+		Expect(repo.CreateSchedule(ctx, sched)).To(Succeed())
+		return sched
+	}
+
+	idsOf := func(schedules []model.Schedule) []uuid.UUID {
+		ids := make([]uuid.UUID, len(schedules))
+		for i, s := range schedules {
+			ids[i] = s.ID
+		}
+		return ids
+	}
+
+	listDue := func(at time.Time) []uuid.UUID {
+		due, err := repo.ListDue(ctx, at, 10)
+		Expect(err).NotTo(HaveOccurred())
+		return idsOf(due)
+	}
+
+	get := func(id uuid.UUID) *model.Schedule {
+		sched, err := repo.GetSchedule(ctx, id)
+		Expect(err).NotTo(HaveOccurred())
+		return sched
+	}
+
+	executionsOf := func(id uuid.UUID) []model.ScheduleExecution {
+		execs, err := repo.ListExecutions(ctx, id, 10)
+		Expect(err).NotTo(HaveOccurred())
+		return execs
+	}
+
+	Describe("ListDue", func() {
+		It("should list active schedules due by now, oldest first", func() {
+			onTime := createSchedule(now, constants.ScheduleActive)
+			late := createSchedule(now.Add(-time.Hour), constants.ScheduleActive)
+			createSchedule(now.Add(time.Minute), constants.ScheduleActive)
+			createSchedule(now.Add(-time.Hour), constants.SchedulePaused)
+			createSchedule(now.Add(-time.Hour), constants.ScheduleCancelled)
+
+			Expect(listDue(now)).To(Equal([]uuid.UUID{late.ID, onTime.ID}))
+		})
+
+		It("should list no more than limit schedules", func() {
+			first := createSchedule(now.Add(-2*time.Hour), constants.ScheduleActive)
+			createSchedule(now.Add(-time.Hour), constants.ScheduleActive)
+
+			due, err := repo.ListDue(ctx, now, 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(idsOf(due)).To(Equal([]uuid.UUID{first.ID}))
+		})
+	})
+
+	Describe("RecordExecution", func() {
+		var (
+			sched *model.Schedule
+			next  time.Time
+		)
+
+		BeforeEach(func() {
+			sched = createSchedule(now, constants.ScheduleActive)
+			next = time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)
+		})
+
+		record := func(nextRunAt *time.Time) {
+			exec := &model.ScheduleExecution{
+				ScheduleID:    sched.ID,
+				DueAt:         now,
+				TransactionID: uuid.New(),
+				Outcome:       constants.ExecutionEnqueued,
+			}
+			Expect(repo.RecordExecution(ctx, exec, nextRunAt)).To(Succeed())
+		}
+
+		It("should store the outcome and advance to the next occurrence", func() {
+			record(&next)
+
+			advanced := get(sched.ID)
+			Expect(advanced.Status).To(Equal(constants.ScheduleActive))
+			Expect(advanced.NextRunAt.Equal(next)).To(BeTrue())
+			Expect(advanced.LastRunAt.Equal(now)).To(BeTrue())
+			Expect(executionsOf(sched.ID)).To(HaveLen(1))
+			Expect(listDue(now)).To(BeEmpty())
+			Expect(listDue(next)).To(Equal([]uuid.UUID{sched.ID}))
+		})
+
+		It("should not advance a schedule another scheduler already ran", func() {
+			record(&next)
+
+			// a second scheduler listed the schedule as due before the first
+			// recorded it, and computed the next run from a later clock
+			later := next.AddDate(0, 1, 0)
+			record(&later)
+
+			Expect(get(sched.ID).NextRunAt.Equal(next)).To(BeTrue())
+			Expect(executionsOf(sched.ID)).To(HaveLen(1))
+		})
+
+		It("should not advance a schedule paused while it ran", func() {
+			_, err := repo.UpdateStatus(ctx, sched.ID, []constants.ScheduleStatus{constants.ScheduleActive}, constants.SchedulePaused, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			record(&next)
+
+			paused := get(sched.ID)
+			Expect(paused.Status).To(Equal(constants.SchedulePaused))
+			Expect(paused.NextRunAt.Equal(now)).To(BeTrue())
+		})
+
+		It("should complete a one-off schedule", func() {
+			// a one-off schedule has no occurrence after its only one
+			record(nil)
+
+			completed := get(sched.ID)
+			Expect(completed.Status).To(Equal(constants.ScheduleCompleted))
+			Expect(completed.NextRunAt).To(BeNil())
+			Expect(completed.LastRunAt.Equal(now)).To(BeTrue())
+			Expect(listDue(now.AddDate(1, 0, 0))).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,140 @@
+package repository_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/repository/postgres"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("TransactionRepo", func() {
+	var (
+		db          *gorm.DB
+		accountRepo *postgres.AccountRepo
+		repo        *postgres.TransactionRepo
+		ctx         context.Context
+		acc         *model.Account
+	)
+
+	BeforeEach(func() {
+		db = newTestDB()
+		accountRepo = postgres.NewAccountRepo(db)
+		repo = postgres.NewTransactionRepo(db)
+		ctx = context.TODO()
+
+		acc = &model.Account{OwnerName: "Alice", Currency: "USD", Status: constants.AccountActive}
+		_, err := accountRepo.CreateAccount(ctx, acc, newTestMessage)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	deposit := func() *model.Transaction {
+		return &model.Transaction{
+			ID:        uuid.New(),
+			AccountID: acc.ID,
+			Type:      constants.Deposit,
+			Amount:    100,
+			Currency:  "USD",
+		}
+	}
+
+	createPending := func(txn *model.Transaction) {
+		msg, err := model.NewTransactionMessage("transactions", txn, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(repo.CreatePending(ctx, txn, msg)).To(Succeed())
+	}
+
+	statusOf := func(id uuid.UUID) constants.TransactionStatus {
+		txn, err := repo.GetByID(ctx, id)
+		Expect(err).NotTo(HaveOccurred())
+		return txn.Status
+	}
+
+	Describe("CreatePendingWithKey", func() {
+		It("should store the key with the transaction and refuse a second claim", func() {
+			first := deposit()
+			msg, err := model.NewTransactionMessage("transactions", first, nil)
+			Expect(err).NotTo(HaveOccurred())
+			created, err := repo.CreatePendingWithKey(ctx, first, msg, &model.IdempotencyKey{
+				Scope: "jwt:user-42", Key: "key-1", Fingerprint: "f", TransactionID: first.ID,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created).To(BeTrue())
+
+			second := deposit()
+			msg, err = model.NewTransactionMessage("transactions", second, nil)
+			Expect(err).NotTo(HaveOccurred())
+			created, err = repo.CreatePendingWithKey(ctx, second, msg, &model.IdempotencyKey{
+				Scope: "jwt:user-42", Key: "key-1", Fingerprint: "f", TransactionID: second.ID,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created).To(BeFalse())
+
+			key, err := postgres.NewIdempotencyRepo(db).GetByKey(ctx, "jwt:user-42", "key-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key.TransactionID).To(Equal(first.ID))
+			Expect(statusOf(first.ID)).To(Equal(constants.StatusPending))
+			_, err = repo.GetByID(ctx, second.ID)
+			Expect(err).To(MatchError(errors.ErrTransactionNotFound))
+		})
+
+		It("should let callers in different scopes use the same key", func() {
+			mine, theirs := deposit(), deposit()
+			for scope, txn := range map[string]*model.Transaction{"jwt:user-42": mine, "jwt:user-7": theirs} {
+				msg, err := model.NewTransactionMessage("transactions", txn, nil)
+				Expect(err).NotTo(HaveOccurred())
+				created, err := repo.CreatePendingWithKey(ctx, txn, msg, &model.IdempotencyKey{
+					Scope: scope, Key: "key-1", Fingerprint: "f", TransactionID: txn.ID,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(created).To(BeTrue())
+			}
+
+			idempotencyRepo := postgres.NewIdempotencyRepo(db)
+			key, err := idempotencyRepo.GetByKey(ctx, "jwt:user-7", "key-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key.TransactionID).To(Equal(theirs.ID))
+			key, err = idempotencyRepo.GetByKey(ctx, "key:"+uuid.NewString(), "key-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key).To(BeNil())
+		})
+	})
+
+	Describe("MarkFailed", func() {
+		It("should mark a pending transaction failed", func() {
+			txn := deposit()
+			createPending(txn)
+
+			Expect(repo.MarkFailed(ctx, txn, "ledger unavailable")).To(Succeed())
+
+			failed, err := repo.GetByID(ctx, txn.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failed.Status).To(Equal(constants.StatusFailed))
+			Expect(failed.FailureReason).To(Equal("ledger unavailable"))
+		})
+
+		It("should record a transaction enqueued before status tracking", func() {
+			txn := deposit()
+
+			Expect(repo.MarkFailed(ctx, txn, "ledger unavailable")).To(Succeed())
+			Expect(statusOf(txn.ID)).To(Equal(constants.StatusFailed))
+		})
+
+		It("should leave a transaction whose balances were applied completed", func() {
+			txn := deposit()
+			createPending(txn)
+			_, err := accountRepo.ApplyTransaction(ctx, txn)
+			Expect(err).NotTo(HaveOccurred())
+
+			// only the ledger write failed
+			Expect(repo.MarkFailed(ctx, txn, "ledger unavailable")).To(Succeed())
+			Expect(statusOf(txn.ID)).To(Equal(constants.StatusCompleted))
+		})
+	})
+})
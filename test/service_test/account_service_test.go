@@ -1,7 +1,12 @@
 package service_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -10,6 +15,10 @@ import (
 	"github.com/google/uuid"
 	"github.com/imranzahoor/banking-ledger/internal/model"
 	"github.com/imranzahoor/banking-ledger/internal/service"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/pkg/statement"
 	"github.com/imranzahoor/banking-ledger/test/mocks"
 )
 
@@ -17,6 +26,7 @@ var _ = Describe("AccountService", func() {
 	var (
 		mockCtrl      *gomock.Controller
 		mockRepo      *mocks.MockAccountRepository
+		mockLedger    *mocks.MockLedgerRepository
 		accountSvc    *service.AccountService
 		ctx           context.Context
 		sampleAccount *model.Account
@@ -25,7 +35,8 @@ var _ = Describe("AccountService", func() {
 	BeforeEach(func() {
 		mockCtrl = gomock.NewController(GinkgoT())
 		mockRepo = mocks.NewMockAccountRepository(mockCtrl)
-		accountSvc = service.NewAccountService(mockRepo)
+		mockLedger = mocks.NewMockLedgerRepository(mockCtrl)
+		accountSvc = service.NewAccountService(mockRepo, mockLedger, config.Config{KafkaTopic: "transactions"})
 		ctx = context.TODO()
 
 		sampleAccount = &model.Account{
@@ -41,14 +52,67 @@ var _ = Describe("AccountService", func() {
 
 	It("should create an account successfully", func() {
 		mockRepo.EXPECT().
-			CreateAccount(gomock.Any(), gomock.Any()).
-			Return(nil).
+			CreateAccount(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, nil).
 			Times(1)
 
-		_, err := accountSvc.CreateAccount(ctx, sampleAccount.OwnerName, sampleAccount.Balance)
+		_, err := accountSvc.CreateAccount(ctx, sampleAccount.OwnerName, "", sampleAccount.Balance, "")
 		Expect(err).To(BeNil())
 	})
 
+	It("should leave journaling a non-zero opening balance to the consumer", func() {
+		mockRepo.EXPECT().
+			CreateAccount(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, acc *model.Account, newMessage func(*model.Transaction) (*model.OutboxMessage, error)) (*model.Transaction, error) {
+				opening := &model.Transaction{ID: uuid.New(), AccountID: acc.ID, Type: constants.Deposit, Amount: acc.Balance, Currency: acc.Currency}
+				msg, err := newMessage(opening)
+				Expect(err).To(BeNil())
+				Expect(msg.Topic).To(Equal("transactions"))
+				Expect(msg.Key).To(Equal(opening.ID.String()))
+				return opening, nil
+			}).
+			Times(1)
+		// no ledger writes are expected here; the outbox message delivers them
+
+		acc, err := accountSvc.CreateAccount(ctx, "Bob", "", 500, "eur")
+		Expect(err).To(BeNil())
+		Expect(acc.Balance).To(Equal(int64(500)))
+		Expect(acc.Currency).To(Equal("EUR"))
+	})
+
+	It("should default to USD when no currency is given", func() {
+		mockRepo.EXPECT().
+			CreateAccount(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, nil).
+			Times(1)
+
+		acc, err := accountSvc.CreateAccount(ctx, "Carol", "", 0, "")
+		Expect(err).To(BeNil())
+		Expect(acc.Currency).To(Equal("USD"))
+	})
+
+	It("should reject an unsupported currency", func() {
+		_, err := accountSvc.CreateAccount(ctx, "Dave", "", 0, "XYZ")
+		Expect(err).To(MatchError(errors.ErrUnsupportedCurrency))
+	})
+
+	It("should take the owner as a principal", func() {
+		mockRepo.EXPECT().
+			CreateAccount(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, nil).
+			Times(1)
+
+		acc, err := accountSvc.CreateAccount(ctx, "Erin", "jwt:user-42", 0, "")
+		Expect(err).To(BeNil())
+		Expect(acc.OwnerID).To(Equal("jwt:user-42"))
+
+		// a bare name could be an API key's or a token subject's
+		_, err = accountSvc.CreateAccount(ctx, "Erin", "user-42", 0, "")
+		Expect(err).To(MatchError(errors.ErrInvalidOwnerID))
+		_, err = accountSvc.SetOwner(ctx, uuid.New(), "user-42")
+		Expect(err).To(MatchError(errors.ErrInvalidOwnerID))
+	})
+
 	It("should fetch an account by ID", func() {
 		mockRepo.EXPECT().
 			GetAccountByID(gomock.Any(), sampleAccount.ID.String()).
@@ -59,4 +123,249 @@ var _ = Describe("AccountService", func() {
 		Expect(err).To(BeNil())
 		Expect(acc).To(Equal(sampleAccount))
 	})
+
+	It("should only freeze an active account", func() {
+		frozen := &model.Account{ID: sampleAccount.ID, Status: constants.AccountFrozen}
+		mockRepo.EXPECT().
+			UpdateStatus(gomock.Any(), sampleAccount.ID, []constants.AccountStatus{constants.AccountActive}, constants.AccountFrozen).
+			Return(frozen, nil).
+			Times(1)
+
+		acc, err := accountSvc.FreezeAccount(ctx, sampleAccount.ID)
+		Expect(err).To(BeNil())
+		Expect(acc.Status).To(Equal(constants.AccountFrozen))
+	})
+
+	It("should only unfreeze a frozen account", func() {
+		mockRepo.EXPECT().
+			UpdateStatus(gomock.Any(), sampleAccount.ID, []constants.AccountStatus{constants.AccountFrozen}, constants.AccountActive).
+			Return(nil, errors.ErrInvalidStatusChange).
+			Times(1)
+
+		_, err := accountSvc.UnfreezeAccount(ctx, sampleAccount.ID)
+		Expect(err).To(MatchError(errors.ErrInvalidStatusChange))
+	})
+
+	It("should enqueue the closure payout on the transactions topic", func() {
+		payoutID := uuid.New()
+		mockRepo.EXPECT().
+			CloseAccount(gomock.Any(), sampleAccount.ID, payoutID, gomock.Any()).
+			DoAndReturn(func(_ context.Context, id, to uuid.UUID, newMessage func(*model.Transaction) (*model.OutboxMessage, error)) (*model.Account, *model.Transaction, error) {
+				payout := &model.Transaction{ID: uuid.New(), AccountID: id, CounterpartyID: to, Type: constants.Transfer, Amount: 100}
+				msg, err := newMessage(payout)
+				Expect(err).To(BeNil())
+				Expect(msg.Topic).To(Equal("transactions"))
+				Expect(msg.Key).To(Equal(payout.ID.String()))
+				return &model.Account{ID: id, Status: constants.AccountClosed}, payout, nil
+			}).
+			Times(1)
+
+		acc, payout, err := accountSvc.CloseAccount(ctx, sampleAccount.ID, payoutID)
+		Expect(err).To(BeNil())
+		Expect(acc.Status).To(Equal(constants.AccountClosed))
+		Expect(payout.CounterpartyID).To(Equal(payoutID))
+	})
+
+	It("should set an overdraft limit", func() {
+		updated := &model.Account{ID: sampleAccount.ID, Balance: -200, OverdraftLimit: 1000}
+		mockRepo.EXPECT().
+			SetOverdraftLimit(gomock.Any(), sampleAccount.ID, int64(1000)).
+			Return(updated, nil).
+			Times(1)
+
+		acc, err := accountSvc.SetOverdraftLimit(ctx, sampleAccount.ID, 1000)
+		Expect(err).To(BeNil())
+		Expect(acc.AvailableCredit()).To(Equal(int64(800)))
+	})
+
+	It("should reject a negative overdraft limit", func() {
+		_, err := accountSvc.SetOverdraftLimit(ctx, sampleAccount.ID, -1)
+		Expect(err).To(MatchError(errors.ErrInvalidOverdraftLimit))
+	})
+
+	Describe("GetBalanceAsOf", func() {
+		BeforeEach(func() {
+			sampleAccount.Currency = "EUR"
+			mockRepo.EXPECT().
+				GetAccountByID(gomock.Any(), sampleAccount.ID.String()).
+				Return(sampleAccount, nil).
+				Times(1)
+		})
+
+		It("should snapshot a settled balance", func() {
+			asOf := time.Date(2026, 3, 31, 23, 59, 0, 0, time.UTC)
+			mockLedger.EXPECT().
+				GetBalanceAsOf(gomock.Any(), sampleAccount.ID, asOf).
+				Return(&model.BalanceSnapshot{AccountID: sampleAccount.ID, Balance: 1500, AsOf: asOf, Entries: 3}, nil)
+			mockLedger.EXPECT().
+				SaveBalanceSnapshot(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, snap *model.BalanceSnapshot) error {
+					Expect(snap.Currency).To(Equal("EUR"))
+					Expect(snap.Balance).To(Equal(int64(1500)))
+					return nil
+				})
+
+			snap, err := accountSvc.GetBalanceAsOf(ctx, sampleAccount.ID, asOf)
+			Expect(err).To(BeNil())
+			Expect(snap.Balance).To(Equal(int64(1500)))
+		})
+
+		It("should not snapshot a balance that may still change", func() {
+			asOf := time.Now().UTC()
+			mockLedger.EXPECT().
+				GetBalanceAsOf(gomock.Any(), sampleAccount.ID, asOf).
+				Return(&model.BalanceSnapshot{AccountID: sampleAccount.ID, Balance: 1500, AsOf: asOf, Entries: 3}, nil)
+
+			_, err := accountSvc.GetBalanceAsOf(ctx, sampleAccount.ID, asOf)
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("WriteStatement", func() {
+		var (
+			from, to time.Time
+			entries  []*model.Transaction
+		)
+
+		BeforeEach(func() {
+			from = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+			to = time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+			entries = []*model.Transaction{
+				{ID: uuid.New(), Type: constants.Deposit, Direction: constants.Credit, Amount: 2500, CreatedAt: from.Add(time.Hour)},
+				{ID: uuid.New(), Type: constants.Transfer, Direction: constants.Debit, Amount: 1000,
+					CounterpartyID: uuid.New(), Description: "rent", CreatedAt: from.Add(48 * time.Hour)},
+			}
+		})
+
+		expectStatement := func() {
+			sampleAccount.Currency = "USD"
+			mockRepo.EXPECT().
+				GetAccountByID(gomock.Any(), sampleAccount.ID.String()).
+				Return(sampleAccount, nil)
+			mockLedger.EXPECT().
+				GetBalanceAsOf(gomock.Any(), sampleAccount.ID, from).
+				Return(&model.BalanceSnapshot{AccountID: sampleAccount.ID, Balance: 500, AsOf: from}, nil)
+			mockLedger.EXPECT().
+				GetBalanceAsOf(gomock.Any(), sampleAccount.ID, to).
+				Return(&model.BalanceSnapshot{AccountID: sampleAccount.ID, Balance: 2000, AsOf: to}, nil)
+			mockLedger.EXPECT().
+				StreamTransactionsByAccountID(gomock.Any(), sampleAccount.ID, from, to, gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ uuid.UUID, _, _ time.Time, fn func(*model.Transaction) error) error {
+					for _, e := range entries {
+						if err := fn(e); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+		}
+
+		It("should write a CSV statement framed by opening and closing balances", func() {
+			expectStatement()
+			var buf bytes.Buffer
+
+			err := accountSvc.WriteStatement(ctx, sampleAccount.ID, from, to, statement.NewWriter(statement.FormatCSV, &buf))
+			Expect(err).To(BeNil())
+
+			rows, err := csv.NewReader(&buf).ReadAll()
+			Expect(err).To(BeNil())
+			Expect(rows).To(HaveLen(5))
+			Expect(rows[1][2]).To(Equal("opening_balance"))
+			Expect(rows[1][6]).To(Equal("5.00"))
+			Expect(rows[2][5:7]).To(Equal([]string{"25.00", "30.00"}))
+			Expect(rows[3][3:7]).To(Equal([]string{"rent", entries[1].CounterpartyID.String(), "-10.00", "20.00"}))
+			Expect(rows[4][2]).To(Equal("closing_balance"))
+			Expect(rows[4][6]).To(Equal("20.00"))
+		})
+
+		It("should write well-formed camt.053 with both balances before the entries", func() {
+			expectStatement()
+			var buf bytes.Buffer
+
+			err := accountSvc.WriteStatement(ctx, sampleAccount.ID, from, to, statement.NewWriter(statement.FormatCamt053, &buf))
+			Expect(err).To(BeNil())
+
+			var doc struct {
+				Stmt struct {
+					Bal []struct {
+						Code string `xml:"Tp>CdOrPrtry>Cd"`
+						Amt  string `xml:"Amt"`
+					} `xml:"Bal"`
+					Ntry []struct {
+						Amt       string `xml:"Amt"`
+						CdtDbtInd string `xml:"CdtDbtInd"`
+					} `xml:"Ntry"`
+				} `xml:"BkToCstmrStmt>Stmt"`
+			}
+			Expect(xml.Unmarshal(buf.Bytes(), &doc)).To(Succeed())
+			Expect(doc.Stmt.Bal).To(HaveLen(2))
+			Expect(doc.Stmt.Bal[0].Code).To(Equal("OPBD"))
+			Expect(doc.Stmt.Bal[1].Amt).To(Equal("20.00"))
+			Expect(doc.Stmt.Ntry).To(HaveLen(2))
+			Expect(doc.Stmt.Ntry[1].CdtDbtInd).To(Equal("DBIT"))
+			Expect(doc.Stmt.Ntry[1].Amt).To(Equal("10.00"))
+		})
+
+		It("should write an OFX statement with signed amounts and both balances", func() {
+			expectStatement()
+			var buf bytes.Buffer
+
+			err := accountSvc.WriteStatement(ctx, sampleAccount.ID, from, to, statement.NewWriter(statement.FormatOFX, &buf))
+			Expect(err).To(BeNil())
+			Expect(buf.String()).To(HavePrefix(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n" + `<?OFX OFXHEADER="200" VERSION="220"`))
+
+			var doc struct {
+				Stmt struct {
+					Currency string `xml:"CURDEF"`
+					AcctID   string `xml:"BANKACCTFROM>ACCTID"`
+					Start    string `xml:"BANKTRANLIST>DTSTART"`
+					Trn      []struct {
+						Type   string `xml:"TRNTYPE"`
+						Posted string `xml:"DTPOSTED"`
+						Amount string `xml:"TRNAMT"`
+						FitID  string `xml:"FITID"`
+						Memo   string `xml:"MEMO"`
+					} `xml:"BANKTRANLIST>STMTTRN"`
+					Ledger  string `xml:"LEDGERBAL>BALAMT"`
+					Opening string `xml:"BALLIST>BAL>VALUE"`
+				} `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS"`
+			}
+			Expect(xml.Unmarshal(buf.Bytes(), &doc)).To(Succeed())
+			Expect(doc.Stmt.Currency).To(Equal("USD"))
+			Expect(doc.Stmt.AcctID).To(Equal(strings.ReplaceAll(sampleAccount.ID.String(), "-", "")))
+			Expect(doc.Stmt.Start).To(Equal("20260301000000.000[0:GMT]"))
+			Expect(doc.Stmt.Trn).To(HaveLen(2))
+			Expect(doc.Stmt.Trn[0].Type).To(Equal("CREDIT"))
+			Expect(doc.Stmt.Trn[0].Amount).To(Equal("25.00"))
+			Expect(doc.Stmt.Trn[0].Posted).To(Equal("20260301010000.000[0:GMT]"))
+			Expect(doc.Stmt.Trn[1].Type).To(Equal("DEBIT"))
+			Expect(doc.Stmt.Trn[1].Amount).To(Equal("-10.00"))
+			Expect(doc.Stmt.Trn[1].FitID).To(Equal(entries[1].ID.String()))
+			Expect(doc.Stmt.Trn[1].Memo).To(Equal("rent"))
+			Expect(doc.Stmt.Ledger).To(Equal("20.00"))
+			Expect(doc.Stmt.Opening).To(Equal("5.00"))
+		})
+
+		It("should keep spreadsheets from evaluating descriptions as formulas", func() {
+			entries[1].Description = "=HYPERLINK(\"http://evil\")"
+			expectStatement()
+			var buf bytes.Buffer
+
+			err := accountSvc.WriteStatement(ctx, sampleAccount.ID, from, to, statement.NewWriter(statement.FormatCSV, &buf))
+			Expect(err).To(BeNil())
+
+			rows, err := csv.NewReader(&buf).ReadAll()
+			Expect(err).To(BeNil())
+			Expect(rows[3][3]).To(Equal("'=HYPERLINK(\"http://evil\")"))
+			Expect(rows[3][5]).To(Equal("-10.00"))
+		})
+
+		It("should reject a period that does not end after it starts", func() {
+			var buf bytes.Buffer
+
+			err := accountSvc.WriteStatement(ctx, sampleAccount.ID, to, from, statement.NewWriter(statement.FormatOFX, &buf))
+			Expect(err).To(MatchError(errors.ErrInvalidStatementPeriod))
+			Expect(buf.Len()).To(BeZero())
+		})
+	})
 })
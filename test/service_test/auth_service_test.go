@@ -0,0 +1,218 @@
+package service_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/service"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/test/mocks"
+)
+
+var _ = Describe("AuthService", func() {
+	var (
+		mockCtrl    *gomock.Controller
+		mockKeyRepo *mocks.MockAPIKeyRepository
+		authSvc     *service.AuthService
+		ctx         context.Context
+		cfg         config.Config
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockKeyRepo = mocks.NewMockAPIKeyRepository(mockCtrl)
+		ctx = context.TODO()
+		cfg = config.Config{
+			AdminAPIKey:   "bootstrap-admin-key",
+			JWTHMACSecret: "test-secret",
+			JWTIssuer:     "https://auth.example.com",
+		}
+
+		var err error
+		authSvc, err = service.NewAuthService(mockKeyRepo, cfg)
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("startup", func() {
+		It("should refuse to start with authentication on and no real admin key", func() {
+			cfg.AuthEnabled = true
+
+			for _, key := range []string{"", "change-me"} {
+				cfg.AdminAPIKey = key
+				_, err := service.NewAuthService(mockKeyRepo, cfg)
+				Expect(err).To(HaveOccurred())
+			}
+
+			cfg.AdminAPIKey = "bootstrap-admin-key"
+			_, err := service.NewAuthService(mockKeyRepo, cfg)
+			Expect(err).To(BeNil())
+		})
+
+		It("should not require an admin key with authentication off", func() {
+			cfg.AuthEnabled = false
+			cfg.AdminAPIKey = ""
+
+			_, err := service.NewAuthService(mockKeyRepo, cfg)
+			Expect(err).To(BeNil())
+		})
+	})
+
+	Describe("API keys", func() {
+		It("should store only the hash of a new key and authenticate with it", func() {
+			var stored model.APIKey
+			mockKeyRepo.EXPECT().
+				CreateAPIKey(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, key *model.APIKey) error {
+					key.ID = uuid.New()
+					stored = *key
+					return nil
+				})
+
+			key, secret, err := authSvc.CreateAPIKey(ctx, "payments-service", constants.RoleTeller)
+			Expect(err).To(BeNil())
+			Expect(secret).To(HavePrefix("bl_"))
+			Expect(strings.HasPrefix(secret, key.Prefix)).To(BeTrue())
+			Expect(stored.KeyHash).To(HaveLen(64))
+			Expect(stored.KeyHash).NotTo(ContainSubstring(secret))
+
+			mockKeyRepo.EXPECT().GetByHash(gomock.Any(), stored.KeyHash).Return(&stored, nil)
+
+			identity, err := authSvc.AuthenticateAPIKey(ctx, secret)
+			Expect(err).To(BeNil())
+			Expect(identity.Subject).To(Equal("payments-service"))
+			Expect(identity.Principal).To(Equal("key:payments-service"))
+			Expect(identity.KeyID).To(Equal(stored.ID))
+			Expect(identity.Role).To(Equal(constants.RoleTeller))
+		})
+
+		It("should reject an unknown role", func() {
+			_, _, err := authSvc.CreateAPIKey(ctx, "payments-service", "superuser")
+			Expect(err).To(MatchError(errors.ErrInvalidRole))
+		})
+
+		It("should reject a revoked key", func() {
+			revokedAt := time.Now()
+			mockKeyRepo.EXPECT().
+				GetByHash(gomock.Any(), gomock.Any()).
+				Return(&model.APIKey{ID: uuid.New(), Name: "old", RevokedAt: &revokedAt}, nil)
+
+			_, err := authSvc.AuthenticateAPIKey(ctx, "bl_revoked")
+			Expect(err).To(MatchError(errors.ErrUnauthenticated))
+		})
+
+		It("should reject an unknown key", func() {
+			mockKeyRepo.EXPECT().GetByHash(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+			_, err := authSvc.AuthenticateAPIKey(ctx, "bl_unknown")
+			Expect(err).To(MatchError(errors.ErrUnauthenticated))
+		})
+
+		It("should authenticate the bootstrap admin key without a lookup", func() {
+			identity, err := authSvc.AuthenticateAPIKey(ctx, "bootstrap-admin-key")
+			Expect(err).To(BeNil())
+			Expect(identity.Role).To(Equal(constants.RoleAdmin))
+		})
+	})
+
+	Describe("JWTs", func() {
+		sign := func(method jwt.SigningMethod, key interface{}, claims jwt.Claims) string {
+			token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+			Expect(err).To(BeNil())
+			return token
+		}
+		validClaims := func() jwt.RegisteredClaims {
+			return jwt.RegisteredClaims{
+				Subject:   "user-42",
+				Issuer:    "https://auth.example.com",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			}
+		}
+
+		It("should accept a token signed with the HMAC secret", func() {
+			identity, err := authSvc.AuthenticateToken(sign(jwt.SigningMethodHS256, []byte("test-secret"), validClaims()))
+			Expect(err).To(BeNil())
+			Expect(identity.Subject).To(Equal("user-42"))
+			Expect(identity.Principal).To(Equal("jwt:user-42"))
+			Expect(identity.Method).To(Equal(service.AuthMethodJWT))
+			Expect(identity.Role).To(Equal(constants.RoleCustomer))
+		})
+
+		It("should take the role from the role claim", func() {
+			claims := jwt.MapClaims{"sub": "auditor-7", "iss": "https://auth.example.com", "exp": time.Now().Add(time.Hour).Unix(), "role": "auditor"}
+			identity, err := authSvc.AuthenticateToken(sign(jwt.SigningMethodHS256, []byte("test-secret"), claims))
+			Expect(err).To(BeNil())
+			Expect(identity.Role).To(Equal(constants.RoleAuditor))
+
+			claims["role"] = "superuser"
+			_, err = authSvc.AuthenticateToken(sign(jwt.SigningMethodHS256, []byte("test-secret"), claims))
+			Expect(err).To(MatchError(errors.ErrUnauthenticated))
+		})
+
+		It("should reject an expired token", func() {
+			claims := validClaims()
+			claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+
+			_, err := authSvc.AuthenticateToken(sign(jwt.SigningMethodHS256, []byte("test-secret"), claims))
+			Expect(err).To(MatchError(errors.ErrUnauthenticated))
+		})
+
+		It("should reject a token without an expiry or from another issuer", func() {
+			noExpiry := validClaims()
+			noExpiry.ExpiresAt = nil
+			_, err := authSvc.AuthenticateToken(sign(jwt.SigningMethodHS256, []byte("test-secret"), noExpiry))
+			Expect(err).To(MatchError(errors.ErrUnauthenticated))
+
+			otherIssuer := validClaims()
+			otherIssuer.Issuer = "https://evil.example.com"
+			_, err = authSvc.AuthenticateToken(sign(jwt.SigningMethodHS256, []byte("test-secret"), otherIssuer))
+			Expect(err).To(MatchError(errors.ErrUnauthenticated))
+		})
+
+		It("should reject a token signed with another secret", func() {
+			_, err := authSvc.AuthenticateToken(sign(jwt.SigningMethodHS256, []byte("wrong"), validClaims()))
+			Expect(err).To(MatchError(errors.ErrUnauthenticated))
+		})
+
+		It("should verify RSA tokens with the configured public key", func() {
+			priv, err := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).To(BeNil())
+			der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+			Expect(err).To(BeNil())
+			keyFile := filepath.Join(GinkgoT().TempDir(), "jwt.pub")
+			Expect(os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600)).To(Succeed())
+
+			cfg.JWTHMACSecret = ""
+			cfg.JWTRSAPublicKeyFile = keyFile
+			authSvc, err = service.NewAuthService(mockKeyRepo, cfg)
+			Expect(err).To(BeNil())
+
+			identity, err := authSvc.AuthenticateToken(sign(jwt.SigningMethodRS256, priv, validClaims()))
+			Expect(err).To(BeNil())
+			Expect(identity.Subject).To(Equal("user-42"))
+
+			// HMAC is no longer configured
+			_, err = authSvc.AuthenticateToken(sign(jwt.SigningMethodHS256, []byte("test-secret"), validClaims()))
+			Expect(err).To(MatchError(errors.ErrUnauthenticated))
+		})
+	})
+})
@@ -0,0 +1,75 @@
+package service_test
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/golang/mock/gomock"
+	"github.com/imranzahoor/banking-ledger/internal/service"
+	"github.com/imranzahoor/banking-ledger/test/mocks"
+)
+
+var _ = Describe("HoldExpirer", func() {
+	var (
+		mockCtrl        *gomock.Controller
+		mockAccountRepo *mocks.MockAccountRepository
+		ctx             context.Context
+		cancel          context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAccountRepo = mocks.NewMockAccountRepository(mockCtrl)
+		ctx, cancel = context.WithCancel(context.TODO())
+	})
+
+	AfterEach(func() {
+		cancel()
+		mockCtrl.Finish()
+	})
+
+	It("should sweep again at once while batches come back full", func() {
+		gomock.InOrder(
+			mockAccountRepo.EXPECT().ExpireHolds(gomock.Any(), gomock.Any(), 100).Return(100, nil),
+			mockAccountRepo.EXPECT().ExpireHolds(gomock.Any(), gomock.Any(), 100).Return(100, nil),
+			mockAccountRepo.EXPECT().ExpireHolds(gomock.Any(), gomock.Any(), 100).DoAndReturn(func(context.Context, time.Time, int) (int, error) {
+				cancel()
+				return 3, nil
+			}),
+		)
+
+		// an hour-long interval means only the full batches can explain the
+		// repeated sweeps
+		Expect(service.NewHoldExpirer(mockAccountRepo, time.Hour).Run(ctx)).To(Succeed())
+	})
+
+	It("should sweep holds that lapsed by now", func() {
+		mockAccountRepo.EXPECT().ExpireHolds(gomock.Any(), gomock.Any(), 100).DoAndReturn(func(_ context.Context, now time.Time, _ int) (int, error) {
+			defer cancel()
+			Expect(now).To(BeTemporally("~", time.Now(), time.Second))
+			Expect(now.Location()).To(Equal(time.UTC))
+			return 0, nil
+		})
+
+		Expect(service.NewHoldExpirer(mockAccountRepo, time.Hour).Run(ctx)).To(Succeed())
+	})
+
+	It("should keep sweeping every interval after a failure", func() {
+		last := mockAccountRepo.EXPECT().ExpireHolds(gomock.Any(), gomock.Any(), 100).DoAndReturn(func(context.Context, time.Time, int) (int, error) {
+			cancel()
+			return 0, nil
+		})
+		gomock.InOrder(
+			mockAccountRepo.EXPECT().ExpireHolds(gomock.Any(), gomock.Any(), 100).Return(0, stderrors.New("connection reset")),
+			last,
+		)
+		// the ticker may still fire once cancel has been called
+		mockAccountRepo.EXPECT().ExpireHolds(gomock.Any(), gomock.Any(), 100).Return(0, nil).After(last).AnyTimes()
+
+		Expect(service.NewHoldExpirer(mockAccountRepo, 10*time.Millisecond).Run(ctx)).To(Succeed())
+	})
+})
@@ -0,0 +1,113 @@
+package service_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/service"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/test/mocks"
+)
+
+var _ = Describe("ReconciliationService", func() {
+	var (
+		mockCtrl        *gomock.Controller
+		mockAccountRepo *mocks.MockAccountRepository
+		mockLedgerRepo  *mocks.MockLedgerRepository
+		mockReportRepo  *mocks.MockReconciliationRepository
+		reconSvc        *service.ReconciliationService
+		ctx             context.Context
+		settled         model.Account
+		recent          model.Account
+		pending         []uuid.UUID
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAccountRepo = mocks.NewMockAccountRepository(mockCtrl)
+		mockLedgerRepo = mocks.NewMockLedgerRepository(mockCtrl)
+		mockReportRepo = mocks.NewMockReconciliationRepository(mockCtrl)
+		ctx = context.TODO()
+
+		reconSvc = service.NewReconciliationService(mockAccountRepo, mockLedgerRepo, mockReportRepo,
+			config.Config{ReconciliationGracePeriod: 5 * time.Minute})
+
+		settled = model.Account{ID: uuid.New(), Balance: 1000, Currency: "USD", UpdatedAt: time.Now().Add(-time.Hour)}
+		recent = model.Account{ID: uuid.New(), Balance: 50, Currency: "USD", UpdatedAt: time.Now()}
+		pending = nil
+
+		mockReportRepo.EXPECT().SaveReport(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+		mockAccountRepo.EXPECT().
+			ListPendingLedgerAccounts(gomock.Any()).
+			DoAndReturn(func(context.Context) ([]uuid.UUID, error) { return pending, nil })
+		mockAccountRepo.EXPECT().
+			ListAccounts(gomock.Any(), uuid.Nil, gomock.Any()).
+			Return([]model.Account{settled, recent}, nil)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should report a drifted balance and skip accounts still changing", func() {
+		mockLedgerRepo.EXPECT().
+			GetBalanceAsOf(gomock.Any(), settled.ID, gomock.Any()).
+			Return(&model.BalanceSnapshot{AccountID: settled.ID, Balance: 900}, nil)
+
+		report, err := reconSvc.Run(ctx, constants.TriggerManual, false)
+		Expect(err).To(BeNil())
+		Expect(report.Status).To(Equal(constants.ReconciliationCompleted))
+		Expect(report.AccountsChecked).To(Equal(1))
+		Expect(report.AccountsSkipped).To(Equal(1))
+		Expect(report.Discrepancies).To(HaveLen(1))
+		Expect(report.Discrepancies[0].Difference).To(Equal(int64(100)))
+		Expect(report.Discrepancies[0].CorrectionID).To(Equal(uuid.Nil))
+	})
+
+	It("should repair a discrepancy with a balanced adjustment against the clearing account", func() {
+		mockLedgerRepo.EXPECT().
+			GetBalanceAsOf(gomock.Any(), settled.ID, gomock.Any()).
+			Return(&model.BalanceSnapshot{AccountID: settled.ID, Balance: 1200}, nil)
+		mockLedgerRepo.EXPECT().
+			InsertJournalEntry(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, entry *model.JournalEntry) error {
+				Expect(entry.Validate()).To(Succeed())
+				Expect(entry.Postings[0].AccountID).To(Equal(settled.ID))
+				Expect(entry.Postings[0].Direction).To(Equal(constants.Debit))
+				Expect(entry.Postings[1].AccountID).To(Equal(constants.ClearingAccountID))
+				Expect(entry.Postings[1].Amount).To(Equal(int64(200)))
+				return nil
+			})
+		mockLedgerRepo.EXPECT().
+			InsertTransactions(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, entries []*model.Transaction) error {
+				Expect(entries).To(HaveLen(1))
+				Expect(entries[0].Direction).To(Equal(constants.Debit))
+				return nil
+			})
+
+		report, err := reconSvc.Run(ctx, constants.TriggerScheduled, true)
+		Expect(err).To(BeNil())
+		Expect(report.Discrepancies).To(HaveLen(1))
+		Expect(report.Discrepancies[0].Difference).To(Equal(int64(-200)))
+		Expect(report.Discrepancies[0].CorrectionID).NotTo(Equal(uuid.Nil))
+	})
+
+	It("should not repair an account whose ledger entries are still pending", func() {
+		// the consumer applied a transaction but its ledger write keeps failing
+		pending = []uuid.UUID{settled.ID}
+
+		report, err := reconSvc.Run(ctx, constants.TriggerScheduled, true)
+		Expect(err).To(BeNil())
+		Expect(report.AccountsChecked).To(Equal(0))
+		Expect(report.PendingLedger).To(Equal([]uuid.UUID{settled.ID}))
+		Expect(report.Discrepancies).To(BeEmpty())
+	})
+})
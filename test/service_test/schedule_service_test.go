@@ -0,0 +1,258 @@
+package service_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/imranzahoor/banking-ledger/internal/model"
+	"github.com/imranzahoor/banking-ledger/internal/service"
+	"github.com/imranzahoor/banking-ledger/pkg/config"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
+	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/test/mocks"
+)
+
+var _ = Describe("ScheduleService", func() {
+	var (
+		mockCtrl         *gomock.Controller
+		mockScheduleRepo *mocks.MockScheduleRepository
+		mockAccountRepo  *mocks.MockAccountRepository
+		mockIdemRepo     *mocks.MockIdempotencyRepository
+		mockTxnRepo      *mocks.MockTransactionRepository
+		scheduleSvc      *service.ScheduleService
+		ctx              context.Context
+		account          *model.Account
+		sched            *model.Schedule
+		now              time.Time
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockScheduleRepo = mocks.NewMockScheduleRepository(mockCtrl)
+		mockAccountRepo = mocks.NewMockAccountRepository(mockCtrl)
+		mockIdemRepo = mocks.NewMockIdempotencyRepository(mockCtrl)
+		mockTxnRepo = mocks.NewMockTransactionRepository(mockCtrl)
+		ctx = context.TODO()
+
+		transactionSvc := service.NewTransactionService(
+			mockAccountRepo, mocks.NewMockLedgerRepository(mockCtrl), mockIdemRepo, mockTxnRepo,
+			config.Config{KafkaTopic: "transactions"},
+		)
+		scheduleSvc = service.NewScheduleService(mockScheduleRepo, transactionSvc)
+
+		account = &model.Account{ID: uuid.New(), Balance: 1000, Currency: "USD", Status: constants.AccountActive}
+		now = time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+		sched = &model.Schedule{
+			ID:        uuid.New(),
+			AccountID: account.ID,
+			Type:      constants.Withdrawal,
+			Amount:    500,
+			Currency:  "USD",
+			Cron:      "0 9 1 * *",
+			Status:    constants.ScheduleActive,
+			NextRunAt: &now,
+		}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("CreateSchedule", func() {
+		It("should require exactly one of run_at and cron", func() {
+			runAt := time.Now().Add(time.Hour)
+			sched.RunAt = &runAt
+
+			Expect(scheduleSvc.CreateSchedule(ctx, sched)).To(MatchError(errors.ErrInvalidSchedule))
+		})
+
+		It("should reject an invalid cron expression", func() {
+			sched.Cron = "every monday"
+
+			Expect(scheduleSvc.CreateSchedule(ctx, sched)).To(MatchError(errors.ErrInvalidSchedule))
+		})
+
+		It("should store a recurring schedule with its first run", func() {
+			sched.Currency = ""
+			mockAccountRepo.EXPECT().
+				GetAccountByID(gomock.Any(), account.ID.String()).
+				Return(account, nil)
+			mockScheduleRepo.EXPECT().CreateSchedule(gomock.Any(), sched).Return(nil)
+
+			Expect(scheduleSvc.CreateSchedule(ctx, sched)).To(Succeed())
+			Expect(sched.Currency).To(Equal("USD"))
+			Expect(sched.NextRunAt.Day()).To(Equal(1))
+			Expect(sched.NextRunAt.After(time.Now())).To(BeTrue())
+		})
+	})
+
+	Describe("RunDue", func() {
+		var expectDue func()
+
+		BeforeEach(func() {
+			mockAccountRepo.EXPECT().
+				GetAccountByID(gomock.Any(), account.ID.String()).
+				Return(account, nil).
+				AnyTimes()
+
+			expectDue = func() {
+				mockScheduleRepo.EXPECT().
+					ListDue(gomock.Any(), now, gomock.Any()).
+					Return([]model.Schedule{*sched}, nil)
+			}
+		})
+
+		It("should enqueue the transaction and advance to the next occurrence", func() {
+			expectDue()
+			mockIdemRepo.EXPECT().GetByKey(gomock.Any(), "scheduler", gomock.Any()).Return(nil, nil)
+			mockTxnRepo.EXPECT().CreatePendingWithKey(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+			mockScheduleRepo.EXPECT().
+				RecordExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, exec *model.ScheduleExecution, next *time.Time) error {
+					Expect(exec.Outcome).To(Equal(constants.ExecutionEnqueued))
+					Expect(exec.TransactionID).NotTo(Equal(uuid.Nil))
+					Expect(exec.DueAt).To(Equal(now))
+					Expect(*next).To(Equal(time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)))
+					return nil
+				})
+
+			executed, err := scheduleSvc.RunDue(ctx, now)
+			Expect(err).To(BeNil())
+			Expect(executed).To(Equal(1))
+		})
+
+		It("should skip an occurrence the account cannot cover when asked to", func() {
+			account.Balance = 100
+			sched.SkipInsufficientFunds = true
+			expectDue()
+
+			mockIdemRepo.EXPECT().GetByKey(gomock.Any(), "scheduler", gomock.Any()).Return(nil, nil)
+			mockScheduleRepo.EXPECT().
+				RecordExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, exec *model.ScheduleExecution, _ *time.Time) error {
+					Expect(exec.Outcome).To(Equal(constants.ExecutionSkipped))
+					Expect(exec.TransactionID).To(Equal(uuid.Nil))
+					return nil
+				})
+
+			executed, err := scheduleSvc.RunDue(ctx, now)
+			Expect(err).To(BeNil())
+			Expect(executed).To(Equal(1))
+		})
+
+		It("should replay an occurrence enqueued before a crash", func() {
+			var stored *model.IdempotencyKey
+			mockIdemRepo.EXPECT().GetByKey(gomock.Any(), "scheduler", gomock.Any()).Return(nil, nil)
+			mockTxnRepo.EXPECT().
+				CreatePendingWithKey(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ *model.Transaction, _ *model.OutboxMessage, key *model.IdempotencyKey) (bool, error) {
+					stored = key
+					return true, nil
+				})
+			// the execution is lost, so the occurrence is due again
+			mockScheduleRepo.EXPECT().RecordExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.ErrFake)
+			expectDue()
+			_, err := scheduleSvc.RunDue(ctx, now)
+			Expect(err).To(BeNil())
+
+			mockIdemRepo.EXPECT().
+				GetByKey(gomock.Any(), "scheduler", gomock.Any()).
+				DoAndReturn(func(_ context.Context, _, key string) (*model.IdempotencyKey, error) {
+					return stored, nil
+				})
+			mockScheduleRepo.EXPECT().
+				RecordExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, exec *model.ScheduleExecution, _ *time.Time) error {
+					Expect(exec.Outcome).To(Equal(constants.ExecutionEnqueued))
+					Expect(exec.TransactionID).To(Equal(stored.TransactionID))
+					return nil
+				})
+			expectDue()
+
+			executed, err := scheduleSvc.RunDue(ctx, now)
+			Expect(err).To(BeNil())
+			Expect(executed).To(Equal(1))
+		})
+
+		It("should not enqueue an occurrence twice", func() {
+			expectDue()
+			txnID := uuid.New()
+			var stored model.IdempotencyKey
+			mockIdemRepo.EXPECT().
+				GetByKey(gomock.Any(), "scheduler", gomock.Any()).
+				Return(nil, nil)
+			// a second scheduler instance enqueued the occurrence first
+			mockTxnRepo.EXPECT().
+				CreatePendingWithKey(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ *model.Transaction, _ *model.OutboxMessage, key *model.IdempotencyKey) (bool, error) {
+					stored = *key
+					stored.TransactionID = txnID
+					return false, nil
+				})
+			mockIdemRepo.EXPECT().
+				GetByKey(gomock.Any(), "scheduler", gomock.Any()).
+				DoAndReturn(func(_ context.Context, _, key string) (*model.IdempotencyKey, error) {
+					return &stored, nil
+				})
+			mockScheduleRepo.EXPECT().
+				RecordExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, exec *model.ScheduleExecution, _ *time.Time) error {
+					Expect(exec.Outcome).To(Equal(constants.ExecutionEnqueued))
+					Expect(exec.TransactionID).To(Equal(txnID))
+					return nil
+				})
+
+			executed, err := scheduleSvc.RunDue(ctx, now)
+			Expect(err).To(BeNil())
+			Expect(executed).To(Equal(1))
+		})
+	})
+
+	Describe("Scheduler", func() {
+		It("should run what is due by the clock on each check", func() {
+			cancelCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			// the clock starts an hour before sched is due and moves an hour on
+			// every check
+			clock := now.Add(-time.Hour)
+			tick := func() time.Time {
+				t := clock
+				clock = clock.Add(time.Hour)
+				return t
+			}
+
+			mockAccountRepo.EXPECT().GetAccountByID(gomock.Any(), account.ID.String()).Return(account, nil)
+			mockIdemRepo.EXPECT().GetByKey(gomock.Any(), "scheduler", gomock.Any()).Return(nil, nil)
+			mockTxnRepo.EXPECT().CreatePendingWithKey(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+			last := mockScheduleRepo.EXPECT().
+				ListDue(gomock.Any(), now.Add(time.Hour), 100).
+				DoAndReturn(func(context.Context, time.Time, int) ([]model.Schedule, error) {
+					cancel()
+					return nil, nil
+				})
+			gomock.InOrder(
+				mockScheduleRepo.EXPECT().ListDue(gomock.Any(), now.Add(-time.Hour), 100).Return(nil, nil),
+				mockScheduleRepo.EXPECT().ListDue(gomock.Any(), now, 100).Return([]model.Schedule{*sched}, nil),
+				mockScheduleRepo.EXPECT().
+					RecordExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, exec *model.ScheduleExecution, next *time.Time) error {
+						Expect(exec.DueAt).To(Equal(now))
+						Expect(*next).To(Equal(time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)))
+						return nil
+					}),
+				last,
+			)
+			// the ticker may still fire once cancel has been called
+			mockScheduleRepo.EXPECT().ListDue(gomock.Any(), gomock.Any(), 100).Return(nil, nil).After(last).AnyTimes()
+
+			scheduler := service.NewSchedulerWithClock(scheduleSvc, time.Millisecond, tick)
+			Expect(scheduler.Run(cancelCtx)).To(Succeed())
+		})
+	})
+})
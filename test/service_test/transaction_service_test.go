@@ -2,14 +2,23 @@ package service_test
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	"github.com/imranzahoor/banking-ledger/internal/model"
 	"github.com/imranzahoor/banking-ledger/internal/service"
 	"github.com/imranzahoor/banking-ledger/pkg/config"
+	"github.com/imranzahoor/banking-ledger/pkg/constants"
 	"github.com/imranzahoor/banking-ledger/pkg/errors"
+	"github.com/imranzahoor/banking-ledger/pkg/logger"
+	"github.com/imranzahoor/banking-ledger/pkg/metrics"
+	"github.com/imranzahoor/banking-ledger/pkg/tracing"
 	"github.com/imranzahoor/banking-ledger/test/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -20,6 +29,8 @@ var _ = Describe("TransactionService", func() {
 		mockCtrl        *gomock.Controller
 		mockAccountRepo *mocks.MockAccountRepository
 		mockLedgerRepo  *mocks.MockLedgerRepository
+		mockIdemRepo    *mocks.MockIdempotencyRepository
+		mockTxnRepo     *mocks.MockTransactionRepository
 		transactionSvc  service.TransactionServiceInterface
 		ctx             context.Context
 		cfg             config.Config
@@ -29,6 +40,8 @@ var _ = Describe("TransactionService", func() {
 		mockCtrl = gomock.NewController(GinkgoT())
 		mockAccountRepo = mocks.NewMockAccountRepository(mockCtrl)
 		mockLedgerRepo = mocks.NewMockLedgerRepository(mockCtrl)
+		mockIdemRepo = mocks.NewMockIdempotencyRepository(mockCtrl)
+		mockTxnRepo = mocks.NewMockTransactionRepository(mockCtrl)
 		ctx = context.TODO()
 
 		cfg = config.Config{
@@ -36,7 +49,7 @@ var _ = Describe("TransactionService", func() {
 			KafkaTopic:   "transactions",
 		}
 
-		transactionSvc = service.NewTransactionService(mockAccountRepo, mockLedgerRepo, cfg)
+		transactionSvc = service.NewTransactionService(mockAccountRepo, mockLedgerRepo, mockIdemRepo, mockTxnRepo, cfg)
 	})
 
 	AfterEach(func() {
@@ -51,17 +64,158 @@ var _ = Describe("TransactionService", func() {
 				Amount:    1000,
 			}
 
+			mockTxnRepo.EXPECT().
+				CreatePending(gomock.Any(), txn, gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ *model.Transaction, msg *model.OutboxMessage) error {
+					Expect(txn.Status).To(Equal(constants.StatusPending))
+					Expect(msg.Topic).To(Equal("transactions"))
+					Expect(msg.Key).To(Equal(txn.ID.String()))
+					return nil
+				}).
+				Times(1)
+
 			err := transactionSvc.EnqueueTransaction(ctx, txn)
 			Expect(err).To(BeNil())
 			Expect(txn.ID).NotTo(Equal(uuid.Nil))
 		})
 
+		It("should carry the request ID in the message headers", func() {
+			txn := &model.Transaction{AccountID: uuid.New(), Type: "deposit", Amount: 1000}
+
+			mockTxnRepo.EXPECT().
+				CreatePending(gomock.Any(), txn, gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ *model.Transaction, msg *model.OutboxMessage) error {
+					Expect(msg.Headers).To(HaveKeyWithValue(logger.MessageHeader, "req-123"))
+					return nil
+				}).
+				Times(1)
+
+			err := transactionSvc.EnqueueTransaction(logger.WithRequestID(ctx, "req-123"), txn)
+			Expect(err).To(BeNil())
+		})
+
+		It("should carry the trace context in the message headers", func() {
+			_, err := tracing.Setup(ctx, tracing.ExporterNone, "test")
+			Expect(err).To(BeNil())
+			provider := sdktrace.NewTracerProvider()
+			spanCtx, span := provider.Tracer("test").Start(ctx, "POST /api/v1/transactions")
+			defer span.End()
+
+			var headers map[string]string
+			mockTxnRepo.EXPECT().
+				CreatePending(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ *model.Transaction, msg *model.OutboxMessage) error {
+					headers = msg.Headers
+					return nil
+				})
+
+			txn := &model.Transaction{AccountID: uuid.New(), Type: "deposit", Amount: 1000}
+			Expect(transactionSvc.EnqueueTransaction(spanCtx, txn)).To(Succeed())
+			Expect(headers).To(HaveKeyWithValue("traceparent", ContainSubstring(span.SpanContext().TraceID().String())))
+
+			consumed := trace.SpanContextFromContext(tracing.ExtractHeaders(context.Background(), headers))
+			Expect(consumed.TraceID()).To(Equal(span.SpanContext().TraceID()))
+			Expect(consumed.IsRemote()).To(BeTrue())
+		})
+
+		It("should count enqueued transactions by type", func() {
+			enqueued := metrics.TransactionsEnqueued.WithLabelValues("withdrawal")
+			before := testutil.ToFloat64(enqueued)
+
+			mockTxnRepo.EXPECT().CreatePending(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			mockTxnRepo.EXPECT().CreatePending(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.ErrFake)
+
+			Expect(transactionSvc.EnqueueTransaction(ctx, &model.Transaction{AccountID: uuid.New(), Type: "withdrawal", Amount: 1})).To(Succeed())
+			Expect(transactionSvc.EnqueueTransaction(ctx, &model.Transaction{AccountID: uuid.New(), Type: "withdrawal", Amount: 1})).NotTo(Succeed())
+			Expect(testutil.ToFloat64(enqueued) - before).To(Equal(1.0))
+		})
+
 		It("should return error for invalid transaction marshal", func() {
 			invalidTxn := &model.Transaction{}
 
+			mockTxnRepo.EXPECT().
+				CreatePending(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(nil).
+				Times(1)
+
 			err := transactionSvc.EnqueueTransaction(ctx, invalidTxn)
 			Expect(err).To(BeNil())
 		})
+
+		It("should fail when the outbox write fails", func() {
+			txn := &model.Transaction{AccountID: uuid.New(), Type: "deposit", Amount: 1000}
+
+			mockTxnRepo.EXPECT().
+				CreatePending(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(errors.ErrFake).
+				Times(1)
+
+			err := transactionSvc.EnqueueTransaction(ctx, txn)
+			Expect(err).To(MatchError(errors.ErrFake))
+		})
+	})
+
+	Describe("GetTransaction", func() {
+		It("should return the transaction with its status", func() {
+			txn := &model.Transaction{ID: uuid.New(), Status: constants.StatusFailed, FailureReason: "insufficient funds"}
+			mockTxnRepo.EXPECT().GetByID(gomock.Any(), txn.ID).Return(txn, nil).Times(1)
+
+			result, err := transactionSvc.GetTransaction(ctx, txn.ID)
+			Expect(err).To(BeNil())
+			Expect(result.Status).To(Equal(constants.StatusFailed))
+		})
+
+		It("should return ErrTransactionNotFound for an unknown ID", func() {
+			id := uuid.New()
+			mockTxnRepo.EXPECT().GetByID(gomock.Any(), id).Return(nil, errors.ErrTransactionNotFound).Times(1)
+
+			_, err := transactionSvc.GetTransaction(ctx, id)
+			Expect(err).To(MatchError(errors.ErrTransactionNotFound))
+		})
+	})
+
+	Describe("WaitForCompletion", func() {
+		var txn *model.Transaction
+
+		BeforeEach(func() {
+			txn = &model.Transaction{ID: uuid.New(), AccountID: uuid.New(), Status: constants.StatusPending}
+		})
+
+		It("should return the account balance once the transaction completes", func() {
+			completed := *txn
+			completed.Status = constants.StatusCompleted
+			account := &model.Account{ID: txn.AccountID, Balance: 4200}
+
+			gomock.InOrder(
+				mockTxnRepo.EXPECT().GetByID(gomock.Any(), txn.ID).Return(txn, nil),
+				mockTxnRepo.EXPECT().GetByID(gomock.Any(), txn.ID).Return(&completed, nil),
+			)
+			mockAccountRepo.EXPECT().GetAccountByID(gomock.Any(), txn.AccountID.String()).Return(account, nil)
+
+			final, acc, err := transactionSvc.WaitForCompletion(ctx, txn.ID, time.Second)
+			Expect(err).To(BeNil())
+			Expect(final.Status).To(Equal(constants.StatusCompleted))
+			Expect(acc.Balance).To(Equal(int64(4200)))
+		})
+
+		It("should return a failed transaction with its reason", func() {
+			txn.Status = constants.StatusFailed
+			txn.FailureReason = "insufficient funds"
+			mockTxnRepo.EXPECT().GetByID(gomock.Any(), txn.ID).Return(txn, nil)
+
+			final, acc, err := transactionSvc.WaitForCompletion(ctx, txn.ID, time.Second)
+			Expect(err).To(BeNil())
+			Expect(final.FailureReason).To(Equal("insufficient funds"))
+			Expect(acc).To(BeNil())
+		})
+
+		It("should give up with the pending transaction on timeout", func() {
+			mockTxnRepo.EXPECT().GetByID(gomock.Any(), txn.ID).Return(txn, nil).AnyTimes()
+
+			final, _, err := transactionSvc.WaitForCompletion(ctx, txn.ID, 250*time.Millisecond)
+			Expect(err).To(BeNil())
+			Expect(final.Status).To(Equal(constants.StatusPending))
+		})
 	})
 
 	Describe("GetTransactions", func() {
@@ -73,11 +227,11 @@ var _ = Describe("TransactionService", func() {
 			}
 
 			mockLedgerRepo.EXPECT().
-				GetTransactionsByAccountID(gomock.Any(), accountID, int64(10), int64(0)).
+				GetTransactionsByAccountID(gomock.Any(), accountID, model.TransactionFilter{}, int64(10), int64(0)).
 				Return(transactions, nil).
 				Times(1)
 
-			result, err := transactionSvc.GetTransactions(accountID, 10, 0)
+			result, err := transactionSvc.GetTransactions(accountID, model.TransactionFilter{}, 10, 0)
 			Expect(err).To(BeNil())
 			Expect(result).To(Equal(transactions))
 		})
@@ -85,13 +239,309 @@ var _ = Describe("TransactionService", func() {
 		It("should return error if ledger repo fails", func() {
 			accountID := uuid.New()
 			mockLedgerRepo.EXPECT().
-				GetTransactionsByAccountID(gomock.Any(), accountID, int64(10), int64(0)).
+				GetTransactionsByAccountID(gomock.Any(), accountID, model.TransactionFilter{}, int64(10), int64(0)).
 				Return(nil, errors.ErrFake).
 				Times(1)
 
-			result, err := transactionSvc.GetTransactions(accountID, 10, 0)
+			result, err := transactionSvc.GetTransactions(accountID, model.TransactionFilter{}, 10, 0)
 			Expect(err).To(HaveOccurred())
 			Expect(result).To(BeNil())
 		})
+
+		It("should pass a valid filter through to the ledger", func() {
+			accountID := uuid.New()
+			from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+			minAmount := int64(100)
+			filter := model.TransactionFilter{
+				From:        &from,
+				Types:       []constants.TransactionType{constants.Deposit, constants.Withdrawal},
+				MinAmount:   &minAmount,
+				Description: "rent",
+			}
+
+			mockLedgerRepo.EXPECT().
+				GetTransactionsByAccountID(gomock.Any(), accountID, filter, int64(10), int64(0)).
+				Return(nil, nil)
+
+			_, err := transactionSvc.GetTransactions(accountID, filter, 10, 0)
+			Expect(err).To(BeNil())
+		})
+
+		DescribeTable("should reject a filter that cannot match",
+			func(filter model.TransactionFilter, expected error) {
+				_, err := transactionSvc.GetTransactions(uuid.New(), filter, 10, 0)
+				Expect(err).To(MatchError(expected))
+			},
+			Entry("from not before to", func() model.TransactionFilter {
+				t := time.Now()
+				return model.TransactionFilter{From: &t, To: &t}
+			}(), errors.ErrInvalidDateRange),
+			Entry("min above max", func() model.TransactionFilter {
+				lo, hi := int64(500), int64(100)
+				return model.TransactionFilter{MinAmount: &lo, MaxAmount: &hi}
+			}(), errors.ErrInvalidAmountRange),
+			Entry("a type that never reaches the ledger",
+				model.TransactionFilter{Types: []constants.TransactionType{constants.Hold}}, errors.ErrInvalidTransactionType),
+			Entry("an overlong description",
+				model.TransactionFilter{Description: strings.Repeat("a", 101)}, errors.ErrInvalidDescriptionFilter),
+		)
+	})
+
+	Describe("GetTransactionsPage", func() {
+		It("should return the page and the cursor of the next one", func() {
+			accountID := uuid.New()
+			page := []model.Transaction{{ID: uuid.New(), AccountID: accountID, Amount: 1000, Type: "deposit"}}
+
+			mockLedgerRepo.EXPECT().
+				GetTransactionsPage(gomock.Any(), accountID, model.TransactionFilter{}, "AAABjq", int64(1)).
+				Return(page, "AAABjr", nil)
+
+			result, next, err := transactionSvc.GetTransactionsPage(ctx, accountID, model.TransactionFilter{}, "AAABjq", 1)
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(page))
+			Expect(next).To(Equal("AAABjr"))
+		})
+
+		It("should require a positive limit", func() {
+			_, _, err := transactionSvc.GetTransactionsPage(ctx, uuid.New(), model.TransactionFilter{}, "", 0)
+			Expect(err).To(MatchError(errors.ErrInvalidLimit))
+		})
+	})
+
+	Describe("ValidateAccounts", func() {
+		var account *model.Account
+
+		BeforeEach(func() {
+			account = &model.Account{ID: uuid.New(), Balance: 1000, Currency: "EUR", Status: constants.AccountActive}
+			mockAccountRepo.EXPECT().
+				GetAccountByID(gomock.Any(), account.ID.String()).
+				Return(account, nil).
+				Times(1)
+		})
+
+		It("should default to the account currency", func() {
+			txn := &model.Transaction{AccountID: account.ID, Type: "deposit", Amount: 100}
+			Expect(transactionSvc.ValidateAccounts(ctx, txn)).To(Succeed())
+			Expect(txn.Currency).To(Equal("EUR"))
+		})
+
+		It("should reject a currency the account does not hold", func() {
+			txn := &model.Transaction{AccountID: account.ID, Type: "deposit", Amount: 100, Currency: "usd"}
+			Expect(transactionSvc.ValidateAccounts(ctx, txn)).To(MatchError(errors.ErrCurrencyMismatch))
+		})
+
+		It("should accept deposits but reject withdrawals on a frozen account", func() {
+			account.Status = constants.AccountFrozen
+			deposit := &model.Transaction{AccountID: account.ID, Type: "deposit", Amount: 100}
+			Expect(transactionSvc.ValidateAccounts(ctx, deposit)).To(Succeed())
+
+			mockAccountRepo.EXPECT().
+				GetAccountByID(gomock.Any(), account.ID.String()).
+				Return(account, nil).
+				Times(1)
+			withdrawal := &model.Transaction{AccountID: account.ID, Type: "withdrawal", Amount: 100}
+			Expect(transactionSvc.ValidateAccounts(ctx, withdrawal)).To(MatchError(errors.ErrAccountFrozen))
+		})
+
+		It("should reject a transfer into a closed account", func() {
+			dest := &model.Account{ID: uuid.New(), Currency: "EUR", Status: constants.AccountClosed}
+			mockAccountRepo.EXPECT().
+				GetAccountByID(gomock.Any(), dest.ID.String()).
+				Return(dest, nil).
+				Times(1)
+
+			txn := &model.Transaction{AccountID: account.ID, CounterpartyID: dest.ID, Type: "transfer", Amount: 100}
+			Expect(transactionSvc.ValidateAccounts(ctx, txn)).To(MatchError(errors.ErrAccountClosed))
+		})
+	})
+
+	Describe("HasSufficientFunds", func() {
+		It("should report whether the balance covers the amount", func() {
+			account := &model.Account{ID: uuid.New(), Balance: 1000}
+			mockAccountRepo.EXPECT().
+				GetAccountByID(gomock.Any(), account.ID.String()).
+				Return(account, nil).
+				Times(2)
+
+			ok, err := transactionSvc.HasSufficientFunds(ctx, account.ID.String(), 1000)
+			Expect(err).To(BeNil())
+			Expect(ok).To(BeTrue())
+
+			ok, err = transactionSvc.HasSufficientFunds(ctx, account.ID.String(), 1001)
+			Expect(err).To(BeNil())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should return ErrAccountNotFound for an unknown account", func() {
+			accountID := uuid.New().String()
+			mockAccountRepo.EXPECT().
+				GetAccountByID(gomock.Any(), accountID).
+				Return(nil, nil).
+				Times(1)
+
+			_, err := transactionSvc.HasSufficientFunds(ctx, accountID, 100)
+			Expect(err).To(MatchError(errors.ErrAccountNotFound))
+		})
+
+		It("should exclude funds reserved by holds", func() {
+			account := &model.Account{ID: uuid.New(), Balance: 1000, HeldBalance: 400}
+			mockAccountRepo.EXPECT().
+				GetAccountByID(gomock.Any(), account.ID.String()).
+				Return(account, nil).
+				Times(1)
+
+			ok, err := transactionSvc.HasSufficientFunds(ctx, account.ID.String(), 700)
+			Expect(err).To(BeNil())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should let an overdraft limit cover the shortfall", func() {
+			account := &model.Account{ID: uuid.New(), Balance: 100, OverdraftLimit: 500}
+			mockAccountRepo.EXPECT().
+				GetAccountByID(gomock.Any(), account.ID.String()).
+				Return(account, nil).
+				Times(2)
+
+			ok, err := transactionSvc.HasSufficientFunds(ctx, account.ID.String(), 600)
+			Expect(err).To(BeNil())
+			Expect(ok).To(BeTrue())
+
+			ok, err = transactionSvc.HasSufficientFunds(ctx, account.ID.String(), 601)
+			Expect(err).To(BeNil())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("idempotency keys", func() {
+		var (
+			txn    *model.Transaction
+			stored *model.IdempotencyKey
+		)
+
+		BeforeEach(func() {
+			txn = &model.Transaction{AccountID: uuid.New(), Type: "deposit", Amount: 1000}
+			stored = nil
+		})
+
+		keyFor := func(txn *model.Transaction) *model.IdempotencyKey {
+			key, err := service.NewIdempotencyKey("jwt:user-42", "key-1", txn)
+			Expect(err).To(BeNil())
+			return key
+		}
+
+		enqueue := func() {
+			key := keyFor(txn)
+			mockIdemRepo.EXPECT().GetByKey(gomock.Any(), "jwt:user-42", "key-1").Return(nil, nil).Times(1)
+			replayed, err := transactionSvc.ReplayIdempotencyKey(ctx, key, txn)
+			Expect(err).To(BeNil())
+			Expect(replayed).To(BeFalse())
+
+			mockTxnRepo.EXPECT().
+				CreatePendingWithKey(gomock.Any(), txn, gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ *model.Transaction, _ *model.OutboxMessage, key *model.IdempotencyKey) (bool, error) {
+					stored = key
+					return true, nil
+				}).
+				Times(1)
+
+			replayed, err = transactionSvc.EnqueueIdempotent(ctx, key, txn)
+			Expect(err).To(BeNil())
+			Expect(replayed).To(BeFalse())
+			Expect(stored.Scope).To(Equal("jwt:user-42"))
+			Expect(stored.Key).To(Equal("key-1"))
+			Expect(stored.TransactionID).To(Equal(txn.ID))
+		}
+
+		It("should store an unused key with the pending transaction", func() {
+			enqueue()
+		})
+
+		It("should replay the original transaction ID for an identical request", func() {
+			enqueue()
+			originalID := txn.ID
+
+			retry := &model.Transaction{AccountID: txn.AccountID, Type: "deposit", Amount: 1000}
+			mockIdemRepo.EXPECT().GetByKey(gomock.Any(), "jwt:user-42", "key-1").Return(stored, nil).Times(1)
+
+			replayed, err := transactionSvc.ReplayIdempotencyKey(ctx, keyFor(retry), retry)
+			Expect(err).To(BeNil())
+			Expect(replayed).To(BeTrue())
+			Expect(retry.ID).To(Equal(originalID))
+		})
+
+		It("should fingerprint the request as sent", func() {
+			key := keyFor(txn)
+
+			// validation fills in the account's currency after the key is built
+			txn.Currency = "USD"
+			Expect(keyFor(txn).Fingerprint).NotTo(Equal(key.Fingerprint))
+			txn.Currency = ""
+			Expect(keyFor(txn).Fingerprint).To(Equal(key.Fingerprint))
+		})
+
+		It("should reject a reused key with a different request body", func() {
+			enqueue()
+
+			other := &model.Transaction{AccountID: txn.AccountID, Type: "deposit", Amount: 2000}
+			mockIdemRepo.EXPECT().GetByKey(gomock.Any(), "jwt:user-42", "key-1").Return(stored, nil).Times(1)
+
+			_, err := transactionSvc.ReplayIdempotencyKey(ctx, keyFor(other), other)
+			Expect(err).To(MatchError(errors.ErrDuplicateRequest))
+		})
+
+		It("should replay a key a concurrent request stored first", func() {
+			enqueue()
+			originalID := txn.ID
+
+			// both requests found the key unused before either committed
+			retry := &model.Transaction{AccountID: txn.AccountID, Type: "deposit", Amount: 1000}
+			mockTxnRepo.EXPECT().CreatePendingWithKey(gomock.Any(), retry, gomock.Any(), gomock.Any()).Return(false, nil).Times(1)
+			mockIdemRepo.EXPECT().GetByKey(gomock.Any(), "jwt:user-42", "key-1").Return(stored, nil).Times(1)
+
+			replayed, err := transactionSvc.EnqueueIdempotent(ctx, keyFor(retry), retry)
+			Expect(err).To(BeNil())
+			Expect(replayed).To(BeTrue())
+			Expect(retry.ID).To(Equal(originalID))
+		})
+	})
+
+	Describe("SettleHold", func() {
+		var hold *model.Hold
+
+		BeforeEach(func() {
+			hold = &model.Hold{ID: uuid.New(), AccountID: uuid.New(), Amount: 500, Currency: "USD", Status: constants.HoldActive}
+			mockAccountRepo.EXPECT().
+				GetHold(gomock.Any(), hold.ID).
+				Return(hold, nil).
+				Times(1)
+		})
+
+		It("should capture the full hold when no amount is given", func() {
+			txn, err := transactionSvc.SettleHold(ctx, hold.ID, constants.Capture, 0)
+			Expect(err).To(BeNil())
+			Expect(txn.AccountID).To(Equal(hold.AccountID))
+			Expect(txn.HoldID).To(Equal(hold.ID))
+			Expect(txn.Amount).To(Equal(int64(500)))
+		})
+
+		It("should reject a capture larger than the hold", func() {
+			_, err := transactionSvc.SettleHold(ctx, hold.ID, constants.Capture, 501)
+			Expect(err).To(MatchError(errors.ErrCaptureExceedsHold))
+		})
+
+		It("should reject settling a hold that is no longer active", func() {
+			hold.Status = constants.HoldExpired
+			_, err := transactionSvc.SettleHold(ctx, hold.ID, constants.Void, 0)
+			Expect(err).To(MatchError(errors.ErrHoldNotActive))
+		})
+	})
+
+	It("should default a new hold's expiry to the configured TTL", func() {
+		cfg.HoldTTL = time.Hour
+		transactionSvc = service.NewTransactionService(mockAccountRepo, mockLedgerRepo, mockIdemRepo, mockTxnRepo, cfg)
+
+		txn := transactionSvc.NewHold(uuid.New(), 100, "", "card auth", 0)
+		Expect(txn.Type).To(Equal(constants.Hold))
+		Expect(*txn.ExpiresAt).To(BeTemporally("~", time.Now().UTC().Add(time.Hour), time.Minute))
 	})
 })